@@ -0,0 +1,153 @@
+// Package postgres provides PostgreSQL-specific extensions for pubsub.
+//
+// Waker wakes queue workers in all processes sharing a database using LISTEN/NOTIFY:
+//
+//	waker, err := postgres.NewWaker(db, dsn, postgres.DefaultChannel)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer waker.Close()
+//
+//	publisher, _ := pubsub.NewPublisher(..., pubsub.WithPublisherWakeup(waker))
+//	worker, _ := pubsub.NewQueueWorker(..., pubsub.WithWakeup(waker))
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultChannel is the NOTIFY channel used when none is given.
+const DefaultChannel = "pubsub_queue"
+
+// listenerPingInterval is how often an idle listener connection is checked.
+const listenerPingInterval = 90 * time.Second
+
+// Waker implements pubsub.WakeupNotifier and pubsub.WakeupSource using PostgreSQL LISTEN/NOTIFY.
+//
+// NotifyQueued sends NOTIFY on the shared channel; every Waker listening on the channel
+// (in any process) then signals its worker. Notifications are coalesced. After a lost
+// connection the listener reconnects and signals once, since notifications may have been missed.
+//
+// Thread safety: Safe for concurrent use.
+type Waker struct {
+	db           *sql.DB
+	channel      string
+	listener     notificationListener
+	pingInterval time.Duration
+	pinging      atomic.Bool
+	wakeups      chan struct{}
+	done         chan struct{}
+	closeOnce    sync.Once
+	closeErr     error
+}
+
+// notificationListener is the part of *pq.Listener used by Waker.
+type notificationListener interface {
+	NotificationChannel() <-chan *pq.Notification
+	Ping() error
+	Close() error
+}
+
+// NewWaker creates a Waker that sends notifications through db and listens on a dedicated
+// connection opened from dsn. An empty channel means DefaultChannel.
+// Call Close to stop listening.
+func NewWaker(db *sql.DB, dsn, channel string) (*Waker, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db cannot be nil")
+	}
+	if channel == "" {
+		channel = DefaultChannel
+	}
+
+	listener := pq.NewListener(dsn, time.Second, time.Minute, nil)
+	if err := listener.Listen(channel); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to listen on channel %q: %w", channel, err)
+	}
+
+	return newWaker(db, channel, listener, listenerPingInterval), nil
+}
+
+// newWaker creates a Waker on a listener that already listens on channel.
+func newWaker(db *sql.DB, channel string, listener notificationListener, pingInterval time.Duration) *Waker {
+	w := &Waker{
+		db:           db,
+		channel:      channel,
+		listener:     listener,
+		pingInterval: pingInterval,
+		wakeups:      make(chan struct{}, 1),
+		done:         make(chan struct{}),
+	}
+	go w.listen()
+
+	return w
+}
+
+// NotifyQueued sends a notification to all workers listening on the channel.
+func (w *Waker) NotifyQueued(ctx context.Context) error {
+	if _, err := w.db.ExecContext(ctx, "SELECT pg_notify($1, '')", w.channel); err != nil {
+		return fmt.Errorf("failed to notify channel %q: %w", w.channel, err)
+	}
+	return nil
+}
+
+// Wakeups returns the channel signaled for every received notification.
+func (w *Waker) Wakeups() <-chan struct{} {
+	return w.wakeups
+}
+
+// Close stops listening and closes the listener connection.
+// Subsequent calls return the result of the first one.
+func (w *Waker) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		w.closeErr = w.listener.Close()
+	})
+	return w.closeErr
+}
+
+// listen forwards notifications to the wakeups channel until Close is called.
+func (w *Waker) listen() {
+	ticker := time.NewTicker(w.pingInterval)
+	defer ticker.Stop()
+
+	notifications := w.listener.NotificationChannel()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-notifications:
+			// A nil notification means the connection was re-established: wake anyway
+			w.signal()
+		case <-ticker.C:
+			w.ping()
+		}
+	}
+}
+
+// ping checks the listener connection in the background, skipping the check
+// while the previous one is still running.
+func (w *Waker) ping() {
+	if !w.pinging.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer w.pinging.Store(false)
+		_ = w.listener.Ping()
+	}()
+}
+
+// signal wakes the worker without blocking.
+func (w *Waker) signal() {
+	select {
+	case w.wakeups <- struct{}{}:
+	default: // A wakeup is already pending
+	}
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testListener is a notificationListener without a connection.
+type testListener struct {
+	notify  chan *pq.Notification
+	pings   atomic.Int32
+	release chan struct{}
+	closes  atomic.Int32
+}
+
+func newTestListener() *testListener {
+	return &testListener{notify: make(chan *pq.Notification), release: make(chan struct{})}
+}
+
+func (l *testListener) NotificationChannel() <-chan *pq.Notification {
+	return l.notify
+}
+
+// Ping blocks until release is closed.
+func (l *testListener) Ping() error {
+	l.pings.Add(1)
+	<-l.release
+	return nil
+}
+
+func (l *testListener) Close() error {
+	if l.closes.Add(1) > 1 {
+		return errors.New("listener already closed")
+	}
+	return nil
+}
+
+func TestWaker_Close(t *testing.T) {
+	listener := newTestListener()
+	w := newWaker(nil, DefaultChannel, listener, time.Hour)
+
+	require.NoError(t, w.Close())
+	assert.NotPanics(t, func() { assert.NoError(t, w.Close()) })
+	assert.Equal(t, int32(1), listener.closes.Load())
+}
+
+func TestWaker_Wakeups(t *testing.T) {
+	tests := []struct {
+		name         string
+		notification *pq.Notification
+	}{
+		{"notification", &pq.Notification{Channel: DefaultChannel}},
+		{"reconnect", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener := newTestListener()
+			w := newWaker(nil, DefaultChannel, listener, time.Hour)
+			defer func() { _ = w.Close() }()
+
+			listener.notify <- tt.notification
+			select {
+			case <-w.Wakeups():
+			case <-time.After(time.Second):
+				t.Fatal("no wakeup")
+			}
+		})
+	}
+}
+
+func TestWaker_PingSkippedWhileRunning(t *testing.T) {
+	listener := newTestListener()
+	w := newWaker(nil, DefaultChannel, listener, time.Millisecond)
+
+	// Ping blocks, so the ticks in between must not start more of them
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), listener.pings.Load())
+
+	close(listener.release)
+	require.Eventually(t, func() bool { return listener.pings.Load() > 1 }, time.Second, time.Millisecond,
+		"pings resume once the previous one returned")
+	require.NoError(t, w.Close())
+}
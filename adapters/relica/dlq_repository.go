@@ -28,7 +28,7 @@ func NewDLQRepositoryWithPrefix(sqlDB *sql.DB, driverName, prefix string) *DLQRe
 }
 
 func (r *DLQRepository) tableName() string {
-	return r.tablePrefix + "dead_letter_queue"
+	return r.tablePrefix + "dlq"
 }
 
 // Load retrieves a DLQ item by ID.
@@ -147,29 +147,32 @@ func (r *DLQRepository) FindByMessageID(ctx context.Context, messageID int64) (m
 // GetStats retrieves DLQ statistics.
 func (r *DLQRepository) GetStats(ctx context.Context) (model.DLQStats, error) {
 	var stats model.DLQStats
-	var totalCount, unresolvedCount int64
+	var total struct {
+		Count int64 `db:"total"`
+	}
 
-	err := r.db.WithContext(ctx).Select("COUNT(*)").From(r.tableName()).One(&totalCount)
+	err := r.db.WithContext(ctx).Select("COUNT(*) AS total").From(r.tableName()).One(&total)
 	if err != nil {
 		return stats, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to count total DLQ items", err)
 	}
-	stats.TotalItems = int(totalCount)
+	stats.TotalItems = int(total.Count)
 
-	err = r.db.WithContext(ctx).Select("COUNT(*)").From(r.tableName()).Where("is_resolved = ?", false).One(&unresolvedCount)
+	stats.UnresolvedItems, err = r.CountUnresolved(ctx)
 	if err != nil {
-		return stats, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to count unresolved DLQ items", err)
+		return stats, err
 	}
-	stats.UnresolvedItems = int(unresolvedCount)
 	stats.ResolvedItems = stats.TotalItems - stats.UnresolvedItems
 	return stats, nil
 }
 
 // CountUnresolved returns the count of unresolved DLQ items.
 func (r *DLQRepository) CountUnresolved(ctx context.Context) (int, error) {
-	var count int64
-	err := r.db.WithContext(ctx).Select("COUNT(*)").From(r.tableName()).Where("is_resolved = ?", false).One(&count)
+	var unresolved struct {
+		Count int64 `db:"unresolved"`
+	}
+	err := r.db.WithContext(ctx).Select("COUNT(*) AS unresolved").From(r.tableName()).Where("is_resolved = ?", false).One(&unresolved)
 	if err != nil {
 		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to count unresolved DLQ items", err)
 	}
-	return int(count), nil
+	return int(unresolved.Count), nil
 }
@@ -0,0 +1,73 @@
+package relica
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/model"
+	"github.com/coregx/relica"
+)
+
+// HeartbeatRepository implements pubsub.HeartbeatRepository using Relica ORM.
+type HeartbeatRepository struct {
+	db          *relica.DB
+	tablePrefix string
+}
+
+// NewHeartbeatRepository creates a new HeartbeatRepository with default table prefix.
+func NewHeartbeatRepository(sqlDB *sql.DB, driverName string) *HeartbeatRepository {
+	return &HeartbeatRepository{db: relica.WrapDB(sqlDB, driverName), tablePrefix: "pubsub_"}
+}
+
+// NewHeartbeatRepositoryWithPrefix creates a new HeartbeatRepository with custom table prefix.
+func NewHeartbeatRepositoryWithPrefix(sqlDB *sql.DB, driverName, prefix string) *HeartbeatRepository {
+	return &HeartbeatRepository{db: relica.WrapDB(sqlDB, driverName), tablePrefix: prefix}
+}
+
+func (r *HeartbeatRepository) tableName() string {
+	return r.tablePrefix + "worker_heartbeat"
+}
+
+// Save records a heartbeat, creating or replacing the worker's entry.
+func (r *HeartbeatRepository) Save(ctx context.Context, m model.WorkerHeartbeat) error {
+	_, err := r.db.Builder().WithContext(ctx).Upsert(r.tableName(), map[string]interface{}{
+		"worker_id":    m.WorkerID,
+		"heartbeat_at": m.HeartbeatAt,
+	}).OnConflict("worker_id").DoUpdate("heartbeat_at").WithContext(ctx).Execute()
+	if err != nil {
+		return pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to save heartbeat", err)
+	}
+	return nil
+}
+
+// FindAlive retrieves the workers whose last heartbeat is at or after since.
+func (r *HeartbeatRepository) FindAlive(ctx context.Context, since time.Time) ([]model.WorkerHeartbeat, error) {
+	var heartbeats []model.WorkerHeartbeat
+	err := r.db.WithContext(ctx).Select("*").
+		From(r.tableName()).
+		Where("heartbeat_at >= ?", since).
+		OrderBy("worker_id ASC").
+		WithContext(ctx).
+		All(&heartbeats)
+	if err != nil {
+		return nil, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to find live workers", err)
+	}
+	if len(heartbeats) == 0 {
+		return nil, pubsub.ErrNoData
+	}
+	return heartbeats, nil
+}
+
+// Delete removes a worker's heartbeat.
+func (r *HeartbeatRepository) Delete(ctx context.Context, workerID string) error {
+	_, err := r.db.WithContext(ctx).Delete(r.tableName()).
+		Where("worker_id = ?", workerID).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to delete heartbeat", err)
+	}
+	return nil
+}
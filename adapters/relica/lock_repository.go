@@ -0,0 +1,87 @@
+package relica
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/model"
+	"github.com/coregx/relica"
+)
+
+// LockRepository implements pubsub.LockRepository using Relica ORM.
+type LockRepository struct {
+	db          *relica.DB
+	tablePrefix string
+}
+
+// NewLockRepository creates a new LockRepository with default table prefix.
+func NewLockRepository(sqlDB *sql.DB, driverName string) *LockRepository {
+	return &LockRepository{db: relica.WrapDB(sqlDB, driverName), tablePrefix: "pubsub_"}
+}
+
+// NewLockRepositoryWithPrefix creates a new LockRepository with custom table prefix.
+func NewLockRepositoryWithPrefix(sqlDB *sql.DB, driverName, prefix string) *LockRepository {
+	return &LockRepository{db: relica.WrapDB(sqlDB, driverName), tablePrefix: prefix}
+}
+
+func (r *LockRepository) tableName() string {
+	return r.tablePrefix + "lock"
+}
+
+// Acquire takes or renews the named lock for holder until expiresAt.
+//
+// The conditional UPDATE renews our own or takes over an expired lock; a missing lock is
+// created by INSERT, whose primary key lets only one of several competing holders win.
+func (r *LockRepository) Acquire(ctx context.Context, name, holder string, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+	result, err := r.db.WithContext(ctx).Update(r.tableName()).
+		Set(map[string]interface{}{
+			"holder":     holder,
+			"expires_at": expiresAt,
+		}).
+		Where("name = ? AND (holder = ? OR expires_at <= ?)", name, holder, now).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return false, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to acquire lock", err)
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated > 0 {
+		return true, nil
+	}
+
+	_, insertErr := r.db.WithContext(ctx).Insert(r.tableName(), map[string]interface{}{
+		"name":       name,
+		"holder":     holder,
+		"expires_at": expiresAt,
+	}).Execute()
+	if insertErr == nil {
+		return true, nil
+	}
+
+	// The lock exists: held by another holder, or renewed without changes
+	// (MySQL reports 0 affected rows when the values are the same)
+	var lock model.Lock
+	err = r.db.WithContext(ctx).Select("*").
+		From(r.tableName()).
+		Where("name = ?", name).
+		WithContext(ctx).
+		One(&lock)
+	if err != nil {
+		return false, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to acquire lock", insertErr)
+	}
+	return lock.IsHeldBy(holder, now), nil
+}
+
+// Release frees the named lock if it is held by holder.
+func (r *LockRepository) Release(ctx context.Context, name, holder string) error {
+	_, err := r.db.WithContext(ctx).Delete(r.tableName()).
+		Where("name = ? AND holder = ?", name, holder).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to release lock", err)
+	}
+	return nil
+}
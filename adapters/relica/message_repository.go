@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/coregx/pubsub"
 	"github.com/coregx/pubsub/model"
@@ -30,6 +33,14 @@ func (r *MessageRepository) tableName() string {
 	return r.tablePrefix + "message"
 }
 
+func (r *MessageRepository) queueTableName() string {
+	return r.tablePrefix + "queue"
+}
+
+func (r *MessageRepository) dlqTableName() string {
+	return r.tablePrefix + "dlq"
+}
+
 // Load retrieves a message by ID.
 func (r *MessageRepository) Load(ctx context.Context, id int64) (model.Message, error) {
 	var msg model.Message
@@ -78,7 +89,7 @@ func (r *MessageRepository) FindOutdatedMessages(ctx context.Context, days int)
 	var messages []model.Message
 	err := r.db.WithContext(ctx).Select("*").
 		From(r.tableName()).
-		Where("created_at < DATE_SUB(NOW(), INTERVAL ? DAY)", days).
+		Where("created_at < ?", time.Now().AddDate(0, 0, -days)).
 		OrderBy("created_at ASC").
 		WithContext(ctx).
 		All(&messages)
@@ -90,3 +101,144 @@ func (r *MessageRepository) FindOutdatedMessages(ctx context.Context, days int)
 	}
 	return messages, nil
 }
+
+// FindByIdempotencyKey finds the message of a topic that holds the idempotency key.
+func (r *MessageRepository) FindByIdempotencyKey(ctx context.Context, topicID int64, key string) (model.Message, error) {
+	var msg model.Message
+	err := r.db.WithContext(ctx).Select("*").
+		From(r.tableName()).
+		Where("topic_id = ? AND idempotency_key = ?", topicID, key).
+		One(&msg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return msg, pubsub.ErrNoData
+	}
+	if err != nil {
+		return msg, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to find message by idempotency key", err)
+	}
+	return msg, nil
+}
+
+// ReleaseIdempotencyKey clears the idempotency key of a message.
+func (r *MessageRepository) ReleaseIdempotencyKey(ctx context.Context, id int64) error {
+	_, err := r.db.WithContext(ctx).Update(r.tableName()).
+		Set(map[string]interface{}{"idempotency_key": nil}).
+		Where("id = ?", id).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to release idempotency key", err)
+	}
+	return nil
+}
+
+// purgeableCondition selects messages without pending or failed queue items and without
+// unresolved DLQ entries. Parameters: sent status, false.
+func (r *MessageRepository) purgeableCondition() string {
+	return fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %[2]s q WHERE q.message_id = %[1]s.id AND q.status <> ?)"+
+		" AND NOT EXISTS (SELECT 1 FROM %[3]s d WHERE d.message_id = %[1]s.id AND d.is_resolved = ?)",
+		r.tableName(), r.queueTableName(), r.dlqTableName())
+}
+
+// FindPurgeable finds up to limit fully handled messages of a topic created before the cutoff.
+func (r *MessageRepository) FindPurgeable(ctx context.Context, topicID int64, before time.Time, limit int) ([]model.Message, error) {
+	var messages []model.Message
+	err := r.db.WithContext(ctx).Select("*").
+		From(r.tableName()).
+		Where("topic_id = ? AND created_at < ? AND "+r.purgeableCondition(),
+			topicID, before, model.QueueStatusSent, false).
+		OrderBy("created_at ASC", "id ASC").
+		Limit(int64(limit)).
+		WithContext(ctx).
+		All(&messages)
+	if err != nil {
+		return nil, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to find purgeable messages", err)
+	}
+	if len(messages) == 0 {
+		return nil, pubsub.ErrNoData
+	}
+	return messages, nil
+}
+
+// Purge removes messages with their sent queue items (and resolved DLQ entries if
+// purgeResolvedDLQ is set) in one transaction. The purgeable condition is checked again,
+// so messages requeued in the meantime are kept.
+func (r *MessageRepository) Purge(ctx context.Context, ids []int64, purgeResolvedDLQ bool) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to begin purge", err)
+	}
+	defer func() { _ = tx.Rollback() }() // No-op after Commit
+
+	var purgeable []struct {
+		ID int64 `db:"id"`
+	}
+	err = tx.Select("id").
+		From(r.tableName()).
+		Where(inCondition("id", len(ids))+" AND "+r.purgeableCondition(),
+			append(int64Args(ids), model.QueueStatusSent, false)...).
+		WithContext(ctx).
+		All(&purgeable)
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to check purgeable messages", err)
+	}
+	if len(purgeable) == 0 {
+		return 0, nil
+	}
+
+	purgeIDs := make([]int64, len(purgeable))
+	for i, m := range purgeable {
+		purgeIDs[i] = m.ID
+	}
+	args := int64Args(purgeIDs)
+
+	// Dependent rows first: queue items reference messages.
+	// DLQ entries hold a copy of the message data, so they can outlive it.
+	tables := []string{r.queueTableName()}
+	if purgeResolvedDLQ {
+		tables = append(tables, r.dlqTableName())
+	}
+	for _, table := range tables {
+		_, err := tx.Delete(table).
+			Where(inCondition("message_id", len(args)), args...).
+			WithContext(ctx).
+			Execute()
+		if err != nil {
+			return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to purge message references", err)
+		}
+	}
+
+	result, err := tx.Delete(r.tableName()).
+		Where(inCondition("id", len(args)), args...).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to purge messages", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to count purged messages", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to commit purge", err)
+	}
+	return int(purged), nil
+}
+
+// inCondition returns "column IN (?, ...)" with n placeholders.
+func inCondition(column string, n int) string {
+	return column + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", n), ", ") + ")"
+}
+
+// int64Args converts IDs to query parameters.
+func int64Args(ids []int64) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
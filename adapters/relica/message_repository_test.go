@@ -0,0 +1,155 @@
+package relica
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/internal/sqlitetest"
+	"github.com/coregx/pubsub/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// age moves the creation time of a message into the past.
+func (db *testDB) age(t *testing.T, messageID int64, age time.Duration) {
+	t.Helper()
+
+	_, err := db.Exec("UPDATE pubsub_message SET created_at = ? WHERE id = ?", time.Now().Add(-age), messageID)
+	require.NoError(t, err)
+}
+
+// deadLetter saves a DLQ entry for a message, resolved or not.
+func (db *testDB) deadLetter(t *testing.T, subscriptionID, messageID int64, resolved bool) model.DeadLetterQueue {
+	t.Helper()
+
+	item := model.NewDeadLetterQueue(subscriptionID, messageID, 0, 5, "timeout", "Max attempts exceeded",
+		time.Now(), time.Now(), "{}", "http://example.com/hook")
+	if resolved {
+		item.Resolve("operator", "replayed")
+	}
+	item, err := NewDLQRepository(db.DB, sqlitetest.DriverName).Save(context.Background(), item)
+	require.NoError(t, err)
+	return item
+}
+
+func messageIDs(messages []model.Message) []int64 {
+	ids := make([]int64, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func TestMessageRepository_FindPurgeable(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewMessageRepository(db.DB, sqlitetest.DriverName)
+	queue := NewQueueRepository(db.DB, sqlitetest.DriverName)
+	subscriptionID := db.subscription(t)
+	cutoff := time.Now().Add(-time.Hour)
+
+	sent := newQueueItem(t, db, queue, subscriptionID, func(q *model.Queue) { q.MarkSent() })
+	pending := newQueueItem(t, db, queue, subscriptionID)
+	failed := newQueueItem(t, db, queue, subscriptionID, func(q *model.Queue) { q.Status = model.QueueStatusFailed })
+	fresh := newQueueItem(t, db, queue, subscriptionID, func(q *model.Queue) { q.MarkSent() })
+	unresolved := db.message(t)
+	db.deadLetter(t, subscriptionID, unresolved, false)
+	resolved := db.message(t)
+	db.deadLetter(t, subscriptionID, resolved, true)
+	unqueued := db.message(t)
+
+	for _, id := range []int64{sent.MessageID, pending.MessageID, failed.MessageID, unresolved, resolved, unqueued} {
+		db.age(t, id, 2*time.Hour)
+	}
+	db.age(t, fresh.MessageID, time.Minute)
+
+	messages, err := repo.FindPurgeable(ctx, db.topicID, cutoff, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{sent.MessageID, resolved, unqueued}, messageIDs(messages))
+
+	_, err = repo.FindPurgeable(ctx, db.topicID+1, cutoff, 10)
+	assert.ErrorIs(t, err, pubsub.ErrNoData, "other topic")
+}
+
+func TestMessageRepository_FindPurgeable_Limit(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewMessageRepository(db.DB, sqlitetest.DriverName)
+
+	var ids []int64
+	for i := range 5 {
+		id := db.message(t)
+		db.age(t, id, time.Duration(10-i)*time.Hour) // Oldest first
+		ids = append(ids, id)
+	}
+
+	messages, err := repo.FindPurgeable(ctx, db.topicID, time.Now(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, ids[:2], messageIDs(messages))
+
+	purged, err := repo.Purge(ctx, messageIDs(messages), false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	messages, err = repo.FindPurgeable(ctx, db.topicID, time.Now(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, ids[2:4], messageIDs(messages), "next batch")
+}
+
+func TestMessageRepository_Purge(t *testing.T) {
+	tests := []struct {
+		name             string
+		purgeResolvedDLQ bool
+	}{
+		{"keeps resolved DLQ entries", false},
+		{"purges resolved DLQ entries", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newTestDB(t)
+			repo := NewMessageRepository(db.DB, sqlitetest.DriverName)
+			queue := NewQueueRepository(db.DB, sqlitetest.DriverName)
+			dlq := NewDLQRepository(db.DB, sqlitetest.DriverName)
+			subscriptionID := db.subscription(t)
+
+			sent := newQueueItem(t, db, queue, subscriptionID, func(q *model.Queue) { q.MarkSent() })
+			resolved := db.message(t)
+			resolvedEntry := db.deadLetter(t, subscriptionID, resolved, true)
+			requeued := newQueueItem(t, db, queue, subscriptionID, func(q *model.Queue) { q.MarkSent() })
+			unresolved := db.message(t)
+			db.deadLetter(t, subscriptionID, unresolved, false)
+
+			// Requeued after it was found purgeable: skipped
+			_, err := db.Exec("UPDATE pubsub_queue SET status = ? WHERE id = ?", model.QueueStatusPending, requeued.ID)
+			require.NoError(t, err)
+
+			purged, err := repo.Purge(ctx, []int64{sent.MessageID, resolved, requeued.MessageID, unresolved}, tt.purgeResolvedDLQ)
+			require.NoError(t, err)
+			assert.Equal(t, 2, purged)
+
+			for _, id := range []int64{sent.MessageID, resolved} {
+				_, err := repo.Load(ctx, id)
+				assert.ErrorIs(t, err, pubsub.ErrNoData, "message %d purged", id)
+			}
+			for _, id := range []int64{requeued.MessageID, unresolved} {
+				_, err := repo.Load(ctx, id)
+				assert.NoError(t, err, "message %d kept", id)
+			}
+			_, err = queue.Load(ctx, sent.ID)
+			assert.ErrorIs(t, err, pubsub.ErrNoData, "sent queue item purged")
+			_, err = queue.Load(ctx, requeued.ID)
+			assert.NoError(t, err)
+
+			_, err = dlq.Load(ctx, resolvedEntry.ID)
+			if tt.purgeResolvedDLQ {
+				assert.ErrorIs(t, err, pubsub.ErrNoData)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package relica
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/model"
+)
+
+// OutboxRepository implements pubsub.OutboxRepository with plain SQL on the caller's *sql.Tx,
+// since Relica cannot adopt a transaction it did not begin.
+type OutboxRepository struct {
+	driverName  string
+	tablePrefix string
+}
+
+// NewOutboxRepository creates a new OutboxRepository with default table prefix.
+func NewOutboxRepository(driverName string) *OutboxRepository {
+	return NewOutboxRepositoryWithPrefix(driverName, "pubsub_")
+}
+
+// NewOutboxRepositoryWithPrefix creates a new OutboxRepository with custom table prefix.
+func NewOutboxRepositoryWithPrefix(driverName, prefix string) *OutboxRepository {
+	return &OutboxRepository{driverName: driverName, tablePrefix: prefix}
+}
+
+func (r *OutboxRepository) messageTableName() string {
+	return r.tablePrefix + "message"
+}
+
+func (r *OutboxRepository) queueTableName() string {
+	return r.tablePrefix + "queue"
+}
+
+func (r *OutboxRepository) dlqTableName() string {
+	return r.tablePrefix + "dlq"
+}
+
+// column is a column name and the value to insert.
+type column struct {
+	name  string
+	value interface{}
+}
+
+// SaveMessage inserts a message within tx.
+func (r *OutboxRepository) SaveMessage(ctx context.Context, tx *sql.Tx, m model.Message) (model.Message, error) {
+	id, err := r.insert(ctx, tx, r.messageTableName(), []column{
+		{"topic_id", m.TopicID},
+		{"identifier", m.Identifier},
+		{"data", m.Data},
+		{"created_at", m.CreatedAt},
+		{"ordering_key", m.OrderingKey},
+		{"priority", m.Priority},
+		{"idempotency_key", m.IdempotencyKey},
+	})
+	if err != nil {
+		return m, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to insert message", err)
+	}
+	m.ID = id
+	return m, nil
+}
+
+// SaveQueueItem inserts a queue item within tx.
+func (r *OutboxRepository) SaveQueueItem(ctx context.Context, tx *sql.Tx, m *model.Queue) error {
+	id, err := r.insert(ctx, tx, r.queueTableName(), []column{
+		{"subscription_id", m.SubscriptionID},
+		{"message_id", m.MessageID},
+		{"status", m.Status},
+		{"attempt_count", m.AttemptCount},
+		{"last_attempt_at", m.LastAttemptAt},
+		{"next_retry_at", m.NextRetryAt},
+		{"last_error", m.LastError},
+		{"expires_at", m.ExpiresAt},
+		{"sequence_number", m.SequenceNumber},
+		{"ordering_key", m.OrderingKey},
+		{"priority", m.Priority},
+		{"priority_at", m.PriorityAt},
+		{"operation_timestamp", m.OperationTimestamp},
+		{"retry_after_seconds", m.RetryAfterSeconds},
+		{"lease_owner", m.LeaseOwner},
+		{"lease_expires_at", m.LeaseExpiresAt},
+		{"retry_at", m.RetryAt},
+		{"is_complete", m.IsComplete},
+		{"completed_at", m.CompletedAt},
+		{"created_at", m.CreatedAt},
+	})
+	if err != nil {
+		return pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to insert queue item", err)
+	}
+	m.ID = id
+	return nil
+}
+
+// SaveDeadLetter inserts a DLQ entry within tx.
+func (r *OutboxRepository) SaveDeadLetter(ctx context.Context, tx *sql.Tx, m model.DeadLetterQueue) (model.DeadLetterQueue, error) {
+	id, err := r.insert(ctx, tx, r.dlqTableName(), []column{
+		{"subscription_id", m.SubscriptionID},
+		{"message_id", m.MessageID},
+		{"original_queue_id", m.OriginalQueueID},
+		{"attempt_count", m.AttemptCount},
+		{"last_error", m.LastError},
+		{"failure_reason", m.FailureReason},
+		{"first_attempt_at", m.FirstAttemptAt},
+		{"last_attempt_at", m.LastAttemptAt},
+		{"moved_to_dlq_at", m.MovedToDLQAt},
+		{"message_data", m.MessageData},
+		{"callback_url", m.CallbackURL},
+		{"is_resolved", m.IsResolved},
+		{"resolved_at", m.ResolvedAt},
+		{"resolved_by", m.ResolvedBy},
+		{"resolution_note", m.ResolutionNote},
+		{"created_at", m.CreatedAt},
+	})
+	if err != nil {
+		return m, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to insert DLQ", err)
+	}
+	m.ID = id
+	return m, nil
+}
+
+// DeleteOldest deletes up to count of the oldest undelivered, unleased items of a subscription within tx.
+func (r *OutboxRepository) DeleteOldest(ctx context.Context, tx *sql.Tx, subscriptionID int64, count int) (int, error) {
+	if count <= 0 {
+		return 0, nil
+	}
+	now := time.Now()
+	const unleased = "(lease_expires_at IS NULL OR lease_expires_at <= ?)"
+
+	query := fmt.Sprintf("SELECT id FROM %s WHERE subscription_id = ? AND status <> ? AND "+unleased+" ORDER BY id ASC LIMIT %d",
+		r.queueTableName(), count)
+	ids, err := queryIDs(ctx, tx, rebindFor(r.driverName, query), subscriptionID, model.QueueStatusSent, now)
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to find oldest items", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// Check the lease again: a worker may have claimed an item in the meantime
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE %s AND "+unleased, r.queueTableName(), inCondition("id", len(ids)))
+	result, err := tx.ExecContext(ctx, rebindFor(r.driverName, deleteQuery), append(int64Args(ids), now)...)
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to delete oldest items", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to count deleted items", err)
+	}
+	return int(deleted), nil
+}
+
+// ReleaseIdempotencyKey clears the idempotency key of a message within tx.
+func (r *OutboxRepository) ReleaseIdempotencyKey(ctx context.Context, tx *sql.Tx, id int64) error {
+	query := fmt.Sprintf("UPDATE %s SET idempotency_key = NULL WHERE id = ?", r.messageTableName())
+	if _, err := tx.ExecContext(ctx, rebindFor(r.driverName, query), id); err != nil {
+		return pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to release idempotency key", err)
+	}
+	return nil
+}
+
+// FindByIdempotencyKey finds the message of a topic that holds the idempotency key within tx.
+func (r *OutboxRepository) FindByIdempotencyKey(ctx context.Context, tx *sql.Tx, topicID int64, key string) (model.Message, error) {
+	query := fmt.Sprintf("SELECT id, topic_id, identifier, data, created_at, ordering_key, priority, idempotency_key"+
+		" FROM %s WHERE topic_id = ? AND idempotency_key = ?", r.messageTableName())
+
+	var m model.Message
+	err := tx.QueryRowContext(ctx, rebindFor(r.driverName, query), topicID, key).
+		Scan(&m.ID, &m.TopicID, &m.Identifier, &m.Data, &m.CreatedAt, &m.OrderingKey, &m.Priority, &m.IdempotencyKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return m, pubsub.ErrNoData
+	}
+	if err != nil {
+		return m, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to find message by idempotency key", err)
+	}
+	return m, nil
+}
+
+// FindSubscriptionIDs returns the subscriptions a message has queue items for within tx.
+func (r *OutboxRepository) FindSubscriptionIDs(ctx context.Context, tx *sql.Tx, messageID int64) ([]int64, error) {
+	query := fmt.Sprintf("SELECT subscription_id FROM %s WHERE message_id = ? ORDER BY id ASC", r.queueTableName())
+	ids, err := queryIDs(ctx, tx, rebindFor(r.driverName, query), messageID)
+	if err != nil {
+		return nil, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to find queues by message", err)
+	}
+	if ids == nil {
+		ids = []int64{}
+	}
+	return ids, nil
+}
+
+// CountBacklog counts the undelivered queue items of a subscription within tx.
+func (r *OutboxRepository) CountBacklog(ctx context.Context, tx *sql.Tx, subscriptionID int64) (int, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE subscription_id = ? AND status <> ?", r.queueTableName())
+
+	var backlog int
+	err := tx.QueryRowContext(ctx, rebindFor(r.driverName, query), subscriptionID, model.QueueStatusSent).Scan(&backlog)
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to count backlog", err)
+	}
+	return backlog, nil
+}
+
+// insert inserts a row within tx and returns its ID
+// (RETURNING on PostgreSQL, which has no LastInsertId).
+func (r *OutboxRepository) insert(ctx context.Context, tx *sql.Tx, table string, columns []column) (int64, error) {
+	names := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+		args[i] = c.value
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(names, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+
+	if isPostgres(r.driverName) {
+		var id int64
+		err := tx.QueryRowContext(ctx, rebindFor(r.driverName, query+" RETURNING id"), args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
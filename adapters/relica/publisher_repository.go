@@ -68,7 +68,7 @@ func (r *PublisherRepository) Save(ctx context.Context, m model.Publisher) (mode
 // GetByPublisherCode retrieves a publisher by its unique code.
 func (r *PublisherRepository) GetByPublisherCode(ctx context.Context, publisherCode string) (model.Publisher, error) {
 	var pub model.Publisher
-	err := r.db.WithContext(ctx).Select("*").From(r.tableName()).Where("publisher_code = ?", publisherCode).One(&pub)
+	err := r.db.WithContext(ctx).Select("*").From(r.tableName()).Where("code = ?", publisherCode).One(&pub)
 	if errors.Is(err, sql.ErrNoRows) {
 		return pub, pubsub.ErrNoData
 	}
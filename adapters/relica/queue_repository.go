@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/coregx/pubsub"
@@ -14,21 +16,22 @@ import (
 // QueueRepository implements pubsub.QueueRepository using Relica.
 type QueueRepository struct {
 	db          *relica.DB
+	sqlDB       *sql.DB // Raw access for row-locking claims the query builder cannot express
+	driverName  string
 	tablePrefix string
 }
 
 // NewQueueRepository creates a new QueueRepository with default table prefix.
 func NewQueueRepository(sqlDB *sql.DB, driverName string) *QueueRepository {
-	return &QueueRepository{
-		db:          relica.WrapDB(sqlDB, driverName),
-		tablePrefix: "pubsub_",
-	}
+	return NewQueueRepositoryWithPrefix(sqlDB, driverName, "pubsub_")
 }
 
 // NewQueueRepositoryWithPrefix creates a new QueueRepository with custom table prefix.
 func NewQueueRepositoryWithPrefix(sqlDB *sql.DB, driverName, prefix string) *QueueRepository {
 	return &QueueRepository{
 		db:          relica.WrapDB(sqlDB, driverName),
+		sqlDB:       sqlDB,
+		driverName:  driverName,
 		tablePrefix: prefix,
 	}
 }
@@ -37,6 +40,10 @@ func (r *QueueRepository) tableName() string {
 	return r.tablePrefix + "queue"
 }
 
+func (r *QueueRepository) subscriptionTableName() string {
+	return r.tablePrefix + "subscription"
+}
+
 // Load retrieves a queue item by ID.
 func (r *QueueRepository) Load(ctx context.Context, id int64) (model.Queue, error) {
 	var queue model.Queue
@@ -130,6 +137,27 @@ func (r *QueueRepository) FindBySubscriptionID(ctx context.Context, subscription
 	return queues, nil
 }
 
+// FindAllByMessageID retrieves the queue items of a message for all subscriptions.
+func (r *QueueRepository) FindAllByMessageID(ctx context.Context, messageID int64) ([]model.Queue, error) {
+	var queues []model.Queue
+
+	err := r.db.WithContext(ctx).Select("*").
+		From(r.tableName()).
+		Where("message_id = ?", messageID).
+		OrderBy("id ASC").
+		All(&queues)
+
+	if err != nil {
+		return nil, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to find queues by message", err)
+	}
+
+	if len(queues) == 0 {
+		return nil, pubsub.ErrNoData
+	}
+
+	return queues, nil
+}
+
 // FindPendingItems retrieves pending queue items ready for first delivery.
 func (r *QueueRepository) FindPendingItems(ctx context.Context, limit int) ([]model.Queue, error) {
 	var queues []model.Queue
@@ -139,7 +167,7 @@ func (r *QueueRepository) FindPendingItems(ctx context.Context, limit int) ([]mo
 	err := r.db.WithContext(ctx).Select("*").
 		From(r.tableName()).
 		Where("status = ? AND next_retry_at <= ?", model.QueueStatusPending, now).
-		OrderBy("created_at ASC").
+		OrderBy("priority_at ASC", "id ASC").
 		Limit(int64(limit)).
 		WithContext(ctx).
 		All(&queues)
@@ -164,7 +192,7 @@ func (r *QueueRepository) FindRetryableItems(ctx context.Context, limit int) ([]
 	err := r.db.WithContext(ctx).Select("*").
 		From(r.tableName()).
 		Where("status = ? AND next_retry_at <= ?", model.QueueStatusFailed, now).
-		OrderBy("created_at ASC").
+		OrderBy("priority_at ASC", "id ASC").
 		Limit(int64(limit)).
 		WithContext(ctx).
 		All(&queues)
@@ -180,6 +208,273 @@ func (r *QueueRepository) FindRetryableItems(ctx context.Context, limit int) ([]
 	return queues, nil
 }
 
+// ClaimItems atomically leases due queue items with the given status to owner.
+//
+// MySQL and PostgreSQL select candidates with SELECT ... FOR UPDATE SKIP LOCKED inside a
+// transaction, so concurrent workers claim disjoint batches without waiting on each other.
+// SQLite serializes writers, so a single UPDATE ... RETURNING statement is equivalent.
+// Items whose lease expired (e.g. the owning worker died) are claimable again.
+// Higher priorities are claimed first (priority_at order, see model.Queue.Prioritize).
+// Items of paused subscriptions stay in the queue until the subscription is resumed.
+func (r *QueueRepository) ClaimItems(
+	ctx context.Context,
+	status model.QueueStatus,
+	owner string,
+	leaseDuration time.Duration,
+	limit int,
+) ([]model.Queue, error) {
+	now := time.Now()
+	condition := claimCondition + fmt.Sprintf(notPausedCondition, r.subscriptionTableName())
+	return r.claim(ctx, condition, []interface{}{status, now, now, now, true, now}, owner, now.Add(leaseDuration), limit)
+}
+
+// ClaimPartitionItems works like ClaimItems, limited to subscriptions in the given partitions.
+func (r *QueueRepository) ClaimPartitionItems(
+	ctx context.Context,
+	status model.QueueStatus,
+	partitions pubsub.PartitionSet,
+	owner string,
+	leaseDuration time.Duration,
+	limit int,
+) ([]model.Queue, error) {
+	if partitions.Count <= 0 || len(partitions.Owned) == 0 {
+		return nil, pubsub.ErrNoData
+	}
+
+	now := time.Now()
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(partitions.Owned)), ", ")
+	condition := claimCondition + fmt.Sprintf(notPausedCondition, r.subscriptionTableName()) +
+		" AND q.subscription_id %% ? IN (" + placeholders + ")" // %% survives the table name formatting
+	args := []interface{}{status, now, now, now, true, now, partitions.Count}
+	for _, partition := range partitions.Owned {
+		args = append(args, partition)
+	}
+	return r.claim(ctx, condition, args, owner, now.Add(leaseDuration), limit)
+}
+
+// ClaimExpiredItems atomically leases expired, undelivered queue items to owner.
+func (r *QueueRepository) ClaimExpiredItems(
+	ctx context.Context,
+	owner string,
+	leaseDuration time.Duration,
+	limit int,
+) ([]model.Queue, error) {
+	now := time.Now()
+	condition := expiredCondition + fmt.Sprintf(notPausedCondition, r.subscriptionTableName())
+	return r.claim(ctx, condition, []interface{}{model.QueueStatusSent, now, now, true, now}, owner, now.Add(leaseDuration), limit)
+}
+
+// claim leases up to limit items matching condition (see claimCondition) and loads them.
+func (r *QueueRepository) claim(
+	ctx context.Context,
+	condition string,
+	args []interface{},
+	owner string,
+	leaseExpiresAt time.Time,
+	limit int,
+) ([]model.Queue, error) {
+	var ids []int64
+	var err error
+	if r.isSQLite() {
+		ids, err = r.claimReturning(ctx, condition, args, owner, leaseExpiresAt, limit)
+	} else {
+		ids, err = r.claimSkipLocked(ctx, condition, args, owner, leaseExpiresAt, limit)
+	}
+	if err != nil {
+		return nil, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to claim queue items", err)
+	}
+
+	if len(ids) == 0 {
+		return nil, pubsub.ErrNoData
+	}
+
+	idValues := make([]interface{}, len(ids))
+	for i, id := range ids {
+		idValues[i] = id
+	}
+
+	var queues []model.Queue
+	err = r.db.WithContext(ctx).Select("*").
+		From(r.tableName()).
+		Where(relica.HashExp{"id": idValues}).
+		OrderBy("priority_at ASC", "id ASC").
+		WithContext(ctx).
+		All(&queues)
+
+	if err != nil {
+		return nil, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to load claimed items", err)
+	}
+
+	return queues, nil
+}
+
+// claimCondition selects due, unexpired items of table alias q that are not leased by a live worker
+// and are the head of their ordering key (no earlier unsent item with the same key).
+// The table name is filled in with fmt (%[1]s).
+const claimCondition = "q.status = ? AND q.next_retry_at <= ? AND q.expires_at > ? AND (q.lease_expires_at IS NULL OR q.lease_expires_at <= ?)" +
+	" AND (q.ordering_key = '' OR NOT EXISTS (SELECT 1 FROM %[1]s p WHERE p.subscription_id = q.subscription_id" +
+	" AND p.ordering_key = q.ordering_key AND p.sequence_number < q.sequence_number AND p.status <> 'sent'))"
+
+// notPausedCondition excludes items of table alias q whose subscription is paused.
+// The subscription table name is filled in with fmt (%s).
+const notPausedCondition = " AND NOT EXISTS (SELECT 1 FROM %s s WHERE s.id = q.subscription_id" +
+	" AND s.is_paused = ? AND (s.resume_at IS NULL OR s.resume_at > ?))"
+
+// expiredCondition selects undelivered items of table alias q whose expiry has passed
+// and that are not leased by a live worker.
+const expiredCondition = "q.status <> ? AND q.expires_at <= ? AND (q.lease_expires_at IS NULL OR q.lease_expires_at <= ?)"
+
+// claimSkipLocked claims items using row locks that other workers skip (MySQL, PostgreSQL).
+func (r *QueueRepository) claimSkipLocked(
+	ctx context.Context,
+	condition string,
+	args []interface{},
+	owner string,
+	leaseExpiresAt time.Time,
+	limit int,
+) ([]int64, error) {
+	tx, err := r.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }() // No-op after Commit
+
+	query := fmt.Sprintf("SELECT q.id FROM %[1]s q WHERE "+condition+" ORDER BY q.priority_at ASC, q.id ASC LIMIT %[2]d FOR UPDATE SKIP LOCKED",
+		r.tableName(), limit)
+	ids, err := queryIDs(ctx, tx, r.rebind(query), args...)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	update := fmt.Sprintf("UPDATE %s SET lease_owner = ?, lease_expires_at = ? WHERE id IN (%s)",
+		r.tableName(), placeholders)
+	updateArgs := []interface{}{owner, leaseExpiresAt}
+	for _, id := range ids {
+		updateArgs = append(updateArgs, id)
+	}
+	if _, err := tx.ExecContext(ctx, r.rebind(update), updateArgs...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// claimReturning claims items in a single UPDATE ... RETURNING statement (SQLite 3.35+).
+func (r *QueueRepository) claimReturning(
+	ctx context.Context,
+	condition string,
+	args []interface{},
+	owner string,
+	leaseExpiresAt time.Time,
+	limit int,
+) ([]int64, error) {
+	query := fmt.Sprintf("UPDATE %[1]s SET lease_owner = ?, lease_expires_at = ? "+
+		"WHERE id IN (SELECT q.id FROM %[1]s q WHERE "+condition+" ORDER BY q.priority_at ASC, q.id ASC LIMIT %[2]d) RETURNING id",
+		r.tableName(), limit)
+	return queryIDs(ctx, r.sqlDB, query, append([]interface{}{owner, leaseExpiresAt}, args...)...)
+}
+
+// queryIDs runs a query returning a single id column.
+func queryIDs(ctx context.Context, q interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}, query string, args ...interface{}) ([]int64, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// isSQLite reports whether the repository uses a SQLite driver.
+func (r *QueueRepository) isSQLite() bool {
+	return strings.HasPrefix(r.driverName, "sqlite")
+}
+
+// rebind converts ? placeholders to $n for PostgreSQL drivers.
+func (r *QueueRepository) rebind(query string) string {
+	return rebindFor(r.driverName, query)
+}
+
+// isPostgres reports whether the driver is a PostgreSQL driver.
+func isPostgres(driverName string) bool {
+	return driverName == "postgres" || driverName == "pgx"
+}
+
+// rebindFor converts ? placeholders to $n if driverName is a PostgreSQL driver.
+func rebindFor(driverName, query string) string {
+	if !isPostgres(driverName) {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// DeleteScheduled deletes the not yet due, unleased pending queue items of a message.
+func (r *QueueRepository) DeleteScheduled(ctx context.Context, messageID int64) (int, error) {
+	now := time.Now()
+
+	result, err := r.db.WithContext(ctx).Delete(r.tableName()).
+		Where("message_id = ? AND status = ? AND attempt_count = 0 AND next_retry_at > ?"+
+			" AND (lease_expires_at IS NULL OR lease_expires_at <= ?)",
+			messageID, model.QueueStatusPending, now, now).
+		WithContext(ctx).
+		Execute()
+
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to delete scheduled items", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to count deleted scheduled items", err)
+	}
+
+	return int(deleted), nil
+}
+
+// ExtendExpiry moves the expiry of a subscription's undelivered items to until where it is earlier.
+func (r *QueueRepository) ExtendExpiry(ctx context.Context, subscriptionID int64, until time.Time) (int, error) {
+	result, err := r.db.WithContext(ctx).Update(r.tableName()).
+		Set(map[string]interface{}{"expires_at": until}).
+		Where("subscription_id = ? AND status <> ? AND expires_at < ?", subscriptionID, model.QueueStatusSent, until).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to extend queue item expiry", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to count extended queue items", err)
+	}
+
+	return int(updated), nil
+}
+
 // FindExpiredItems retrieves expired queue items that should be cleaned up.
 func (r *QueueRepository) FindExpiredItems(ctx context.Context, limit int) ([]model.Queue, error) {
 	var queues []model.Queue
@@ -205,6 +500,67 @@ func (r *QueueRepository) FindExpiredItems(ctx context.Context, limit int) ([]mo
 	return queues, nil
 }
 
+// CountBacklog counts the undelivered queue items of a subscription.
+func (r *QueueRepository) CountBacklog(ctx context.Context, subscriptionID int64) (int, error) {
+	var backlog struct {
+		Count int64 `db:"backlog"`
+	}
+	err := r.db.WithContext(ctx).Select("COUNT(*) AS backlog").
+		From(r.tableName()).
+		Where("subscription_id = ? AND status <> ?", subscriptionID, model.QueueStatusSent).
+		WithContext(ctx).
+		One(&backlog)
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to count backlog", err)
+	}
+	return int(backlog.Count), nil
+}
+
+// DeleteOldest deletes up to count of the oldest undelivered, unleased queue items of a subscription.
+func (r *QueueRepository) DeleteOldest(ctx context.Context, subscriptionID int64, count int) (int, error) {
+	if count <= 0 {
+		return 0, nil
+	}
+	now := time.Now()
+	const unleased = "(lease_expires_at IS NULL OR lease_expires_at <= ?)"
+
+	var oldest []struct {
+		ID int64 `db:"id"`
+	}
+	err := r.db.WithContext(ctx).Select("id").
+		From(r.tableName()).
+		Where("subscription_id = ? AND status <> ? AND "+unleased, subscriptionID, model.QueueStatusSent, now).
+		OrderBy("id ASC").
+		Limit(int64(count)).
+		WithContext(ctx).
+		All(&oldest)
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to find oldest items", err)
+	}
+	if len(oldest) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, len(oldest))
+	for i, item := range oldest {
+		ids[i] = item.ID
+	}
+	// Check the lease again: a worker may have claimed an item in the meantime
+	result, err := r.db.WithContext(ctx).Delete(r.tableName()).
+		Where(inCondition("id", len(ids))+" AND "+unleased, append(int64Args(ids), now)...).
+		WithContext(ctx).
+		Execute()
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to delete oldest items", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to count deleted items", err)
+	}
+	return int(deleted), nil
+}
+
 // UpdateNextRetry updates the next retry time and attempt count.
 func (r *QueueRepository) UpdateNextRetry(ctx context.Context, id int64, nextRetryAt time.Time, attemptCount int) error {
 	_, err := r.db.WithContext(ctx).Update(r.tableName()).
@@ -0,0 +1,341 @@
+package relica
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/internal/sqlitetest"
+	"github.com/coregx/pubsub/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLease = time.Minute
+
+// newQueueItem saves a pending queue item for a new message.
+func newQueueItem(t *testing.T, db *testDB, repo *QueueRepository, subscriptionID int64, configure ...func(*model.Queue)) model.Queue {
+	t.Helper()
+
+	item := model.NewQueue(subscriptionID, db.message(t))
+	for _, fn := range configure {
+		fn(&item)
+	}
+	_, err := repo.Save(context.Background(), &item)
+	require.NoError(t, err)
+	require.NotZero(t, item.ID)
+	return item
+}
+
+func claimedIDs(items []model.Queue) []int64 {
+	ids := make([]int64, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestQueueRepository_SaveRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewQueueRepository(db.DB, sqlitetest.DriverName)
+	subscriptionID := db.subscription(t)
+
+	item := newQueueItem(t, db, repo, subscriptionID, func(q *model.Queue) {
+		q.OrderingKey = "order-1"
+		q.Prioritize(3, time.Second)
+	})
+
+	loaded, err := repo.Load(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, subscriptionID, loaded.SubscriptionID)
+	assert.Equal(t, item.MessageID, loaded.MessageID)
+	assert.Equal(t, model.QueueStatusPending, loaded.Status)
+	assert.Equal(t, "order-1", loaded.OrderingKey)
+	assert.Equal(t, 3, loaded.Priority)
+	assert.WithinDuration(t, item.CreatedAt, loaded.CreatedAt, time.Millisecond)
+	assert.WithinDuration(t, item.ExpiresAt, loaded.ExpiresAt, time.Millisecond)
+	assert.True(t, loaded.RetryAt.Valid)
+
+	loaded.MarkFailedWithRetryAfter(errors.New("503"), 30*time.Second)
+	_, err = repo.Save(ctx, &loaded)
+	require.NoError(t, err)
+
+	failed, err := repo.Load(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.QueueStatusFailed, failed.Status)
+	assert.Equal(t, 1, failed.AttemptCount)
+	assert.Equal(t, "503", failed.LastError.String)
+	assert.Equal(t, int64(30), failed.RetryAfterSeconds.Int64)
+
+	failed.MarkSent()
+	_, err = repo.Save(ctx, &failed)
+	require.NoError(t, err)
+
+	sent, err := repo.Load(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.QueueStatusSent, sent.Status)
+	assert.True(t, sent.IsComplete)
+	assert.True(t, sent.CompletedAt.Valid)
+}
+
+func TestQueueRepository_ClaimItems(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewQueueRepository(db.DB, sqlitetest.DriverName)
+	subscriptionID := db.subscription(t)
+
+	low := newQueueItem(t, db, repo, subscriptionID)
+	high := newQueueItem(t, db, repo, subscriptionID, func(q *model.Queue) { q.Prioritize(5, time.Minute) })
+	newQueueItem(t, db, repo, subscriptionID, func(q *model.Queue) { q.Schedule(time.Now().Add(time.Hour)) })
+	newQueueItem(t, db, repo, subscriptionID, func(q *model.Queue) { q.ExpiresAt = time.Now().Add(-time.Second) })
+	newQueueItem(t, db, repo, subscriptionID, func(q *model.Queue) { q.Status = model.QueueStatusFailed })
+
+	claimed, err := repo.ClaimItems(ctx, model.QueueStatusPending, "worker-1", testLease, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{high.ID, low.ID}, claimedIDs(claimed), "due items in priority order")
+	for _, item := range claimed {
+		assert.Equal(t, subscriptionID, item.SubscriptionID)
+		assert.NotZero(t, item.MessageID)
+		assert.False(t, item.CreatedAt.IsZero())
+		assert.True(t, item.HasLease("worker-1"))
+	}
+
+	_, err = repo.ClaimItems(ctx, model.QueueStatusPending, "worker-2", testLease, 10)
+	assert.ErrorIs(t, err, pubsub.ErrNoData, "leased items are not claimed again")
+
+	// Persisting the outcome releases the lease
+	claimed[0].MarkSent()
+	_, err = repo.Save(ctx, &claimed[0])
+	require.NoError(t, err)
+	claimed[1].Defer(time.Now())
+	_, err = repo.Save(ctx, &claimed[1])
+	require.NoError(t, err)
+
+	reclaimed, err := repo.ClaimItems(ctx, model.QueueStatusPending, "worker-2", testLease, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{low.ID}, claimedIDs(reclaimed))
+}
+
+func TestQueueRepository_ClaimItems_Limit(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewQueueRepository(db.DB, sqlitetest.DriverName)
+	subscriptionID := db.subscription(t)
+
+	for range 5 {
+		newQueueItem(t, db, repo, subscriptionID)
+	}
+
+	first, err := repo.ClaimItems(ctx, model.QueueStatusPending, "worker-1", testLease, 3)
+	require.NoError(t, err)
+	assert.Len(t, first, 3)
+
+	second, err := repo.ClaimItems(ctx, model.QueueStatusPending, "worker-2", testLease, 3)
+	require.NoError(t, err)
+	assert.Len(t, second, 2)
+	assert.NotContains(t, claimedIDs(first), second[0].ID)
+}
+
+func TestQueueRepository_ClaimItems_LeaseExpiry(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewQueueRepository(db.DB, sqlitetest.DriverName)
+	item := newQueueItem(t, db, repo, db.subscription(t))
+
+	// A lease that has already run out, as left behind by a crashed worker
+	claimed, err := repo.ClaimItems(ctx, model.QueueStatusPending, "crashed", -time.Second, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.False(t, claimed[0].HasLease("crashed"))
+
+	reclaimed, err := repo.ClaimItems(ctx, model.QueueStatusPending, "worker-2", testLease, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{item.ID}, claimedIDs(reclaimed))
+	assert.True(t, reclaimed[0].HasLease("worker-2"))
+}
+
+func TestQueueRepository_ClaimItems_OrderingKey(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewQueueRepository(db.DB, sqlitetest.DriverName)
+	subscriptionID := db.subscription(t)
+	otherSubscriptionID := db.subscription(t)
+
+	keyed := func(q *model.Queue) { q.OrderingKey = "order-1" }
+	first := newQueueItem(t, db, repo, subscriptionID, keyed)
+	second := newQueueItem(t, db, repo, subscriptionID, keyed)
+	other := newQueueItem(t, db, repo, otherSubscriptionID, keyed)
+
+	claimed, err := repo.ClaimItems(ctx, model.QueueStatusPending, "worker-1", testLease, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{first.ID, other.ID}, claimedIDs(claimed),
+		"only the head of each subscription's ordering key is claimable")
+
+	// A failed head still blocks its successors
+	for i := range claimed {
+		if claimed[i].ID == first.ID {
+			claimed[i].MarkFailed(errors.New("down"), 0)
+			_, err = repo.Save(ctx, &claimed[i])
+			require.NoError(t, err)
+		}
+	}
+	claimed, err = repo.ClaimItems(ctx, model.QueueStatusPending, "worker-1", testLease, 10)
+	assert.ErrorIs(t, err, pubsub.ErrNoData)
+
+	claimed, err = repo.ClaimItems(ctx, model.QueueStatusFailed, "worker-1", testLease, 10)
+	require.NoError(t, err)
+	require.Equal(t, []int64{first.ID}, claimedIDs(claimed))
+
+	claimed[0].MarkSent()
+	_, err = repo.Save(ctx, &claimed[0])
+	require.NoError(t, err)
+
+	claimed, err = repo.ClaimItems(ctx, model.QueueStatusPending, "worker-1", testLease, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{second.ID}, claimedIDs(claimed))
+}
+
+func TestQueueRepository_ClaimItems_PausedSubscription(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewQueueRepository(db.DB, sqlitetest.DriverName)
+	paused := db.subscription(t)
+	resumed := db.subscription(t)
+	active := db.subscription(t)
+
+	_, err := db.Exec("UPDATE pubsub_subscription SET is_paused = 1 WHERE id = ?", paused)
+	require.NoError(t, err)
+	_, err = db.Exec("UPDATE pubsub_subscription SET is_paused = 1, resume_at = ? WHERE id = ?", time.Now().Add(-time.Second), resumed)
+	require.NoError(t, err)
+
+	newQueueItem(t, db, repo, paused)
+	resumedItem := newQueueItem(t, db, repo, resumed)
+	activeItem := newQueueItem(t, db, repo, active)
+
+	claimed, err := repo.ClaimItems(ctx, model.QueueStatusPending, "worker-1", testLease, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{resumedItem.ID, activeItem.ID}, claimedIDs(claimed))
+}
+
+func TestQueueRepository_ClaimPartitionItems(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewQueueRepository(db.DB, sqlitetest.DriverName)
+
+	byPartition := make(map[int][]int64)
+	for range 4 {
+		subscriptionID := db.subscription(t)
+		item := newQueueItem(t, db, repo, subscriptionID)
+		partition := int(subscriptionID % 2)
+		byPartition[partition] = append(byPartition[partition], item.ID)
+	}
+
+	claimed, err := repo.ClaimPartitionItems(ctx, model.QueueStatusPending,
+		pubsub.PartitionSet{Count: 2, Owned: []int{1}}, "worker-1", testLease, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, byPartition[1], claimedIDs(claimed))
+
+	claimed, err = repo.ClaimPartitionItems(ctx, model.QueueStatusPending,
+		pubsub.PartitionSet{Count: 2, Owned: []int{0, 1}}, "worker-2", testLease, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, byPartition[0], claimedIDs(claimed))
+
+	_, err = repo.ClaimPartitionItems(ctx, model.QueueStatusPending,
+		pubsub.PartitionSet{Count: 2}, "worker-3", testLease, 10)
+	assert.ErrorIs(t, err, pubsub.ErrNoData)
+}
+
+func TestQueueRepository_ClaimExpiredItems(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewQueueRepository(db.DB, sqlitetest.DriverName)
+	subscriptionID := db.subscription(t)
+
+	expired := func(q *model.Queue) { q.ExpiresAt = time.Now().Add(-time.Second) }
+	pending := newQueueItem(t, db, repo, subscriptionID, expired)
+	failed := newQueueItem(t, db, repo, subscriptionID, expired, func(q *model.Queue) { q.Status = model.QueueStatusFailed })
+	newQueueItem(t, db, repo, subscriptionID, expired, func(q *model.Queue) { q.MarkSent() })
+	newQueueItem(t, db, repo, subscriptionID)
+
+	claimed, err := repo.ClaimExpiredItems(ctx, "worker-1", testLease, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{pending.ID, failed.ID}, claimedIDs(claimed))
+	for _, item := range claimed {
+		assert.Equal(t, subscriptionID, item.SubscriptionID)
+		assert.True(t, item.HasLease("worker-1"))
+	}
+
+	_, err = repo.ClaimExpiredItems(ctx, "worker-2", testLease, 10)
+	assert.ErrorIs(t, err, pubsub.ErrNoData, "leased items are not claimed again")
+
+	require.NoError(t, repo.Delete(ctx, &claimed[0]))
+	_, err = repo.Load(ctx, claimed[0].ID)
+	assert.ErrorIs(t, err, pubsub.ErrNoData)
+}
+
+func TestQueueRepository_ClaimExpiredItems_PausedSubscription(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewQueueRepository(db.DB, sqlitetest.DriverName)
+	paused := db.subscription(t)
+	active := db.subscription(t)
+
+	_, err := db.Exec("UPDATE pubsub_subscription SET is_paused = 1 WHERE id = ?", paused)
+	require.NoError(t, err)
+
+	expired := func(q *model.Queue) { q.ExpiresAt = time.Now().Add(-time.Second) }
+	pausedItem := newQueueItem(t, db, repo, paused, expired)
+	activeItem := newQueueItem(t, db, repo, active, expired)
+
+	claimed, err := repo.ClaimExpiredItems(ctx, "worker-1", testLease, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{activeItem.ID}, claimedIDs(claimed), "the held backlog does not expire")
+
+	_, err = db.Exec("UPDATE pubsub_subscription SET is_paused = 0 WHERE id = ?", paused)
+	require.NoError(t, err)
+	claimed, err = repo.ClaimExpiredItems(ctx, "worker-1", testLease, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{pausedItem.ID}, claimedIDs(claimed))
+}
+
+func TestQueueRepository_ExtendExpiry(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewQueueRepository(db.DB, sqlitetest.DriverName)
+	subscriptionID := db.subscription(t)
+	other := db.subscription(t)
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	expired := func(q *model.Queue) { q.ExpiresAt = time.Now().Add(-time.Second) }
+	pending := newQueueItem(t, db, repo, subscriptionID, expired)
+	failed := newQueueItem(t, db, repo, subscriptionID, expired, func(q *model.Queue) { q.Status = model.QueueStatusFailed })
+	sent := newQueueItem(t, db, repo, subscriptionID, expired, func(q *model.Queue) { q.MarkSent() })
+	later := newQueueItem(t, db, repo, subscriptionID, func(q *model.Queue) { q.ExpiresAt = until.Add(time.Hour) })
+	otherItem := newQueueItem(t, db, repo, other, expired)
+
+	extended, err := repo.ExtendExpiry(ctx, subscriptionID, until)
+	require.NoError(t, err)
+	assert.Equal(t, 2, extended)
+
+	tests := []struct {
+		name    string
+		item    model.Queue
+		wantExp time.Time
+	}{
+		{"pending", pending, until},
+		{"failed", failed, until},
+		{"sent keeps its expiry", sent, sent.ExpiresAt},
+		{"later expiry is not shortened", later, later.ExpiresAt},
+		{"other subscription", otherItem, otherItem.ExpiresAt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := repo.Load(ctx, tt.item.ID)
+			require.NoError(t, err)
+			assert.WithinDuration(t, tt.wantExp, loaded.ExpiresAt, time.Second)
+		})
+	}
+}
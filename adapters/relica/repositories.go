@@ -15,6 +15,9 @@ type Repositories struct {
 	Publisher    pubsub.PublisherRepository
 	Subscriber   pubsub.SubscriberRepository
 	Topic        pubsub.TopicRepository
+	Heartbeat    pubsub.HeartbeatRepository
+	Lock         pubsub.LockRepository
+	Outbox       pubsub.OutboxRepository // For Publisher.PublishTx (see pubsub.WithOutbox)
 }
 
 // NewRepositories creates all repository implementations using Relica.
@@ -31,6 +34,9 @@ func NewRepositories(db *sql.DB, driverName string) *Repositories {
 		Publisher:    NewPublisherRepository(db, driverName),
 		Subscriber:   NewSubscriberRepository(db, driverName),
 		Topic:        NewTopicRepository(db, driverName),
+		Heartbeat:    NewHeartbeatRepository(db, driverName),
+		Lock:         NewLockRepository(db, driverName),
+		Outbox:       NewOutboxRepository(driverName),
 	}
 }
 
@@ -44,5 +50,8 @@ func NewRepositoriesWithPrefix(db *sql.DB, driverName, prefix string) *Repositor
 		Publisher:    NewPublisherRepositoryWithPrefix(db, driverName, prefix),
 		Subscriber:   NewSubscriberRepositoryWithPrefix(db, driverName, prefix),
 		Topic:        NewTopicRepositoryWithPrefix(db, driverName, prefix),
+		Heartbeat:    NewHeartbeatRepositoryWithPrefix(db, driverName, prefix),
+		Lock:         NewLockRepositoryWithPrefix(db, driverName, prefix),
+		Outbox:       NewOutboxRepositoryWithPrefix(driverName, prefix),
 	}
 }
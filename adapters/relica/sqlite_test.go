@@ -0,0 +1,55 @@
+package relica
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/coregx/pubsub/internal/sqlitetest"
+	"github.com/stretchr/testify/require"
+)
+
+// testDB is a SQLite database with the PubSub schema and rows to attach queue items to.
+type testDB struct {
+	*sql.DB
+	topicID      int64
+	subscriberID int64
+}
+
+// newTestDB opens a SQLite database with one topic and subscriber.
+func newTestDB(t *testing.T) *testDB {
+	t.Helper()
+
+	db := &testDB{DB: sqlitetest.Open(t)}
+	now := time.Now()
+	db.topicID = db.insert(t, "INSERT INTO pubsub_topic (code, name, description, created_at) VALUES ('orders', 'orders', '', ?)", now)
+	db.subscriberID = db.insert(t, "INSERT INTO pubsub_subscriber (client_id, name, created_at) VALUES (0, 'billing', ?)", now)
+	return db
+}
+
+// insert runs an INSERT and returns the new row ID.
+func (db *testDB) insert(t *testing.T, query string, args ...interface{}) int64 {
+	t.Helper()
+
+	result, err := db.Exec(query, args...)
+	require.NoError(t, err)
+	id, err := result.LastInsertId()
+	require.NoError(t, err)
+	return id
+}
+
+// subscription creates an active subscription of the test subscriber to the test topic.
+func (db *testDB) subscription(t *testing.T) int64 {
+	t.Helper()
+
+	return db.insert(t, "INSERT INTO pubsub_subscription (subscriber_id, topic_id, identifier, is_active, created_at) VALUES (?, ?, 'all', 1, ?)",
+		db.subscriberID, db.topicID, time.Now())
+}
+
+// message creates a message on the test topic.
+func (db *testDB) message(t *testing.T) int64 {
+	t.Helper()
+
+	return db.insert(t, "INSERT INTO pubsub_message (topic_id, identifier, data, created_at) VALUES (?, 'all', '{}', ?)",
+		db.topicID, time.Now())
+}
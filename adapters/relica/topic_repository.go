@@ -68,7 +68,7 @@ func (r *TopicRepository) Save(ctx context.Context, m model.Topic) (model.Topic,
 // GetByTopicCode retrieves a topic by its unique code.
 func (r *TopicRepository) GetByTopicCode(ctx context.Context, topicCode string) (model.Topic, error) {
 	var topic model.Topic
-	err := r.db.WithContext(ctx).Select("*").From(r.tableName()).Where("topic_code = ?", topicCode).One(&topic)
+	err := r.db.WithContext(ctx).Select("*").From(r.tableName()).Where("code = ?", topicCode).One(&topic)
 	if errors.Is(err, sql.ErrNoRows) {
 		return topic, pubsub.ErrNoData
 	}
@@ -77,3 +77,21 @@ func (r *TopicRepository) GetByTopicCode(ctx context.Context, topicCode string)
 	}
 	return topic, nil
 }
+
+// FindWithRetention retrieves the topics with a retention period.
+func (r *TopicRepository) FindWithRetention(ctx context.Context) ([]model.Topic, error) {
+	var topics []model.Topic
+	err := r.db.WithContext(ctx).Select("*").
+		From(r.tableName()).
+		Where("retention_days > ?", 0).
+		OrderBy("id ASC").
+		WithContext(ctx).
+		All(&topics)
+	if err != nil {
+		return nil, pubsub.NewErrorWithCause(pubsub.ErrCodeDatabase, "failed to find topics with retention", err)
+	}
+	if len(topics) == 0 {
+		return nil, pubsub.ErrNoData
+	}
+	return topics, nil
+}
@@ -0,0 +1,199 @@
+package pubsub
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/coregx/pubsub/model"
+)
+
+// DefaultHighWater is the fraction of the max queue depth at which NotifyBacklogHighWater
+// fires when BackpressureConfig.HighWater is not set.
+const DefaultHighWater = 0.8
+
+// OverflowPolicy decides what happens to a message for a subscription whose queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowReject fails the publish with ErrQueueFull; nothing is stored.
+	OverflowReject OverflowPolicy = "reject"
+
+	// OverflowDropOldest queues the message and deletes the subscription's oldest undelivered items.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+
+	// OverflowDeadLetter moves the message for the full subscription to the DLQ instead of queueing it.
+	OverflowDeadLetter OverflowPolicy = "dead_letter"
+)
+
+// BackpressureConfig limits the backlog of undelivered queue items per subscription
+// (see WithBackpressure).
+//
+// The limit is checked when publishing, so it can be exceeded slightly by concurrent publishes.
+// Subscription.MaxQueueDepth overrides MaxDepth per subscription.
+type BackpressureConfig struct {
+	MaxDepth  int            // Max undelivered items per subscription (0 = only Subscription.MaxQueueDepth)
+	Policy    OverflowPolicy // Handling of messages for full queues (default: OverflowReject)
+	HighWater float64        // Fraction of the max depth that triggers NotifyBacklogHighWater (default: DefaultHighWater)
+	DLQ       DLQRepository  // Dead letter queue, required for OverflowDeadLetter
+}
+
+// validate checks the configuration values.
+func (c BackpressureConfig) validate() error {
+	if c.MaxDepth < 0 {
+		return fmt.Errorf("max queue depth must be >= 0, got %d", c.MaxDepth)
+	}
+	switch c.Policy {
+	case "", OverflowReject, OverflowDropOldest:
+	case OverflowDeadLetter:
+		if c.DLQ == nil {
+			return fmt.Errorf("overflow policy %q requires a DLQ repository", c.Policy)
+		}
+	default:
+		return fmt.Errorf("unknown overflow policy %q", c.Policy)
+	}
+	if c.HighWater < 0 || c.HighWater > 1 {
+		return fmt.Errorf("high water must be between 0 and 1, got %v", c.HighWater)
+	}
+	return nil
+}
+
+// withDefaults returns the configuration with unset values defaulted.
+func (c BackpressureConfig) withDefaults() BackpressureConfig {
+	if c.Policy == "" {
+		c.Policy = OverflowReject
+	}
+	if c.HighWater == 0 {
+		c.HighWater = DefaultHighWater
+	}
+	return c
+}
+
+// ThrottledSubscription reports a subscription whose queue was full when a message was published.
+type ThrottledSubscription struct {
+	SubscriptionID int64
+	Depth          int            // Undelivered items before the publish
+	MaxDepth       int            // Effective max depth
+	Policy         OverflowPolicy // OverflowDropOldest or OverflowDeadLetter
+	Dropped        int            // Oldest items deleted to make room (OverflowDropOldest)
+}
+
+// BacklogAlert is passed to BacklogNotifier.NotifyBacklogHighWater.
+type BacklogAlert struct {
+	SubscriptionID int64
+	Depth          int // Undelivered items after the publish
+	HighWaterMark  int
+	MaxDepth       int
+	At             time.Time
+}
+
+// subscriptionBacklog is the queue depth of a subscription before a publish.
+type subscriptionBacklog struct {
+	depth    int
+	maxDepth int
+}
+
+// full reports whether the backlog has reached its max depth.
+func (b subscriptionBacklog) full() bool {
+	return b.depth >= b.maxDepth
+}
+
+// highWaterMark returns the depth at which NotifyBacklogHighWater fires.
+func (b subscriptionBacklog) highWaterMark(highWater float64) int {
+	return max(1, int(math.Ceil(float64(b.maxDepth)*highWater)))
+}
+
+// loadBacklogs counts the backlog of the subscriptions with a max depth (within tx if not nil).
+// Returns an error wrapping ErrQueueFull if a queue is full and the policy is OverflowReject.
+func (p *Publisher) loadBacklogs(ctx context.Context, tx *sql.Tx, subscriptions []model.Subscription) (map[int64]subscriptionBacklog, error) {
+	backlogs := make(map[int64]subscriptionBacklog)
+	for _, subscription := range subscriptions {
+		maxDepth := p.backpressure.MaxDepth
+		if subscription.MaxQueueDepth > 0 {
+			maxDepth = subscription.MaxQueueDepth
+		}
+		if maxDepth == 0 {
+			continue
+		}
+
+		depth, err := p.countBacklog(ctx, tx, subscription.ID)
+		if err != nil {
+			return nil, NewErrorWithCause(ErrCodeDatabase, "failed to count subscription backlog", err)
+		}
+		backlog := subscriptionBacklog{depth: depth, maxDepth: maxDepth}
+		if backlog.full() && p.backpressure.Policy == OverflowReject {
+			return nil, NewErrorWithCause(ErrCodeQueueFull,
+				fmt.Sprintf("queue of subscription %d is full (%d of %d items)", subscription.ID, depth, maxDepth), ErrQueueFull)
+		}
+		backlogs[subscription.ID] = backlog
+	}
+	return backlogs, nil
+}
+
+// countBacklog counts the backlog of a subscription through the outbox if tx is not nil,
+// else through the repository.
+func (p *Publisher) countBacklog(ctx context.Context, tx *sql.Tx, subscriptionID int64) (int, error) {
+	if tx != nil {
+		return p.outbox.CountBacklog(ctx, tx, subscriptionID)
+	}
+	return p.queueRepo.CountBacklog(ctx, subscriptionID)
+}
+
+// deadLetterOverflow stores the message for a full subscription in the DLQ (within tx if not nil).
+// NotifyDLQItemAdded is only called without tx, since the entry may still be rolled back.
+func (p *Publisher) deadLetterOverflow(ctx context.Context, tx *sql.Tx, subscription model.Subscription, message model.Message, backlog subscriptionBacklog) error {
+	now := time.Now()
+	dlqItem := model.NewDeadLetterQueue(
+		subscription.ID, message.ID, 0, 0,
+		"", fmt.Sprintf("Queue full (%d of %d items)", backlog.depth, backlog.maxDepth),
+		now, now, message.Data, "",
+	)
+	if tx != nil {
+		_, err := p.outbox.SaveDeadLetter(ctx, tx, dlqItem)
+		return err
+	}
+
+	dlqItem, err := p.backpressure.DLQ.Save(ctx, dlqItem)
+	if err != nil {
+		return err
+	}
+	if err := p.notificationService.NotifyDLQItemAdded(ctx, dlqItem); err != nil {
+		p.logger.Warnf("Failed to send DLQ notification: %v", err)
+	}
+	return nil
+}
+
+// dropOldest deletes the oldest items of a subscription that exceed its max depth
+// after a new item was queued (within tx if not nil). Returns the number of deleted items.
+func (p *Publisher) dropOldest(ctx context.Context, tx *sql.Tx, subscriptionID int64, backlog subscriptionBacklog) (int, error) {
+	count := backlog.depth + 1 - backlog.maxDepth
+	if tx != nil {
+		return p.outbox.DeleteOldest(ctx, tx, subscriptionID, count)
+	}
+	return p.queueRepo.DeleteOldest(ctx, subscriptionID, count)
+}
+
+// notifyHighWater fires NotifyBacklogHighWater if the new item made the backlog cross its high-water mark
+// and the notification service implements BacklogNotifier.
+func (p *Publisher) notifyHighWater(ctx context.Context, subscriptionID int64, backlog subscriptionBacklog) {
+	notifier, ok := p.notificationService.(BacklogNotifier)
+	if !ok {
+		return
+	}
+	mark := backlog.highWaterMark(p.backpressure.HighWater)
+	if backlog.depth >= mark || backlog.depth+1 < mark {
+		return
+	}
+	alert := BacklogAlert{
+		SubscriptionID: subscriptionID,
+		Depth:          backlog.depth + 1,
+		HighWaterMark:  mark,
+		MaxDepth:       backlog.maxDepth,
+		At:             time.Now(),
+	}
+	if err := notifier.NotifyBacklogHighWater(ctx, alert); err != nil {
+		p.logger.Warnf("Failed to send backlog notification: %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+package pubsub_test
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/adapters/relica"
+	"github.com/coregx/pubsub/internal/sqlitetest"
+	"github.com/coregx/pubsub/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// publisherFixture is a SQLite database with one topic, subscriber and subscription.
+type publisherFixture struct {
+	db           *sql.DB
+	repos        *relica.Repositories
+	topic        model.Topic
+	subscription model.Subscription
+}
+
+func newPublisherFixture(t *testing.T) *publisherFixture {
+	t.Helper()
+	ctx := context.Background()
+	db := sqlitetest.Open(t)
+	repos := relica.NewRepositories(db, sqlitetest.DriverName)
+
+	topic, err := repos.Topic.Save(ctx, model.NewTopic("user.signup", "User Signup", ""))
+	require.NoError(t, err)
+	subscriber, err := repos.Subscriber.Save(ctx, model.NewSubscriber(1, "billing", "http://127.0.0.1:1/unused"))
+	require.NoError(t, err)
+	subscription, err := repos.Subscription.Save(ctx, model.NewSubscription(subscriber.ID, topic.ID, "user-123", ""))
+	require.NoError(t, err)
+
+	return &publisherFixture{db: db, repos: repos, topic: topic, subscription: subscription}
+}
+
+// publisher creates a publisher on the fixture's repositories.
+func (f *publisherFixture) publisher(t *testing.T, opts ...pubsub.PublisherOption) *pubsub.Publisher {
+	t.Helper()
+	opts = append([]pubsub.PublisherOption{
+		pubsub.WithPublisherRepositories(f.repos.Message, f.repos.Queue, f.repos.Subscription, f.repos.Topic),
+		pubsub.WithPublisherLogger(&pubsub.NoopLogger{}),
+	}, opts...)
+	publisher, err := pubsub.NewPublisher(opts...)
+	require.NoError(t, err)
+	return publisher
+}
+
+// request returns a publish request for the fixture's topic and identifier.
+func (f *publisherFixture) request() pubsub.PublishRequest {
+	return pubsub.PublishRequest{
+		TopicCode:  f.topic.Code,
+		Identifier: f.subscription.Identifier,
+		Data:       `{"userId":123}`,
+	}
+}
+
+// backlog counts the subscription's undelivered queue items.
+func (f *publisherFixture) backlog(t *testing.T) int {
+	t.Helper()
+	depth, err := f.repos.Queue.CountBacklog(context.Background(), f.subscription.ID)
+	require.NoError(t, err)
+	return depth
+}
+
+// testNotifications records the publisher's notifications.
+type testNotifications struct {
+	pubsub.NoOpNotificationService
+	mu     sync.Mutex
+	alerts []pubsub.BacklogAlert
+	dlq    []model.DeadLetterQueue
+}
+
+func (n *testNotifications) NotifyBacklogHighWater(_ context.Context, alert pubsub.BacklogAlert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func (n *testNotifications) NotifyDLQItemAdded(_ context.Context, item model.DeadLetterQueue) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.dlq = append(n.dlq, item)
+	return nil
+}
+
+func TestWithBackpressure_Validation(t *testing.T) {
+	f := newPublisherFixture(t)
+	dlq := f.repos.DLQ
+
+	tests := []struct {
+		name    string
+		config  pubsub.BackpressureConfig
+		wantErr bool
+	}{
+		{"defaults", pubsub.BackpressureConfig{MaxDepth: 10}, false},
+		{"only subscription limits", pubsub.BackpressureConfig{}, false},
+		{"drop oldest", pubsub.BackpressureConfig{MaxDepth: 10, Policy: pubsub.OverflowDropOldest}, false},
+		{"dead letter", pubsub.BackpressureConfig{MaxDepth: 10, Policy: pubsub.OverflowDeadLetter, DLQ: dlq}, false},
+		{"dead letter without DLQ", pubsub.BackpressureConfig{MaxDepth: 10, Policy: pubsub.OverflowDeadLetter}, true},
+		{"negative depth", pubsub.BackpressureConfig{MaxDepth: -1}, true},
+		{"unknown policy", pubsub.BackpressureConfig{MaxDepth: 10, Policy: "drop_newest"}, true},
+		{"negative high water", pubsub.BackpressureConfig{MaxDepth: 10, HighWater: -0.1}, true},
+		{"high water above 1", pubsub.BackpressureConfig{MaxDepth: 10, HighWater: 1.5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pubsub.NewPublisher(
+				pubsub.WithPublisherRepositories(f.repos.Message, f.repos.Queue, f.repos.Subscription, f.repos.Topic),
+				pubsub.WithPublisherLogger(&pubsub.NoopLogger{}),
+				pubsub.WithBackpressure(tt.config),
+			)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPublisher_OverflowPolicies(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        pubsub.OverflowPolicy
+		wantErr       error
+		wantQueued    int
+		wantThrottled []pubsub.ThrottledSubscription
+		wantBacklog   int
+		wantDropped   bool // The oldest item was deleted
+		wantDLQ       bool // The overflowing message was dead-lettered
+	}{
+		{
+			name:        "reject",
+			policy:      pubsub.OverflowReject,
+			wantErr:     pubsub.ErrQueueFull,
+			wantBacklog: 2,
+		},
+		{
+			name:       "drop oldest",
+			policy:     pubsub.OverflowDropOldest,
+			wantQueued: 1,
+			wantThrottled: []pubsub.ThrottledSubscription{
+				{Depth: 2, MaxDepth: 2, Policy: pubsub.OverflowDropOldest, Dropped: 1},
+			},
+			wantBacklog: 2,
+			wantDropped: true,
+		},
+		{
+			name:       "dead letter",
+			policy:     pubsub.OverflowDeadLetter,
+			wantQueued: 0,
+			wantThrottled: []pubsub.ThrottledSubscription{
+				{Depth: 2, MaxDepth: 2, Policy: pubsub.OverflowDeadLetter},
+			},
+			wantBacklog: 2,
+			wantDLQ:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newPublisherFixture(t)
+			notifications := &testNotifications{}
+			publisher := f.publisher(t,
+				pubsub.WithPublisherNotifications(notifications),
+				pubsub.WithBackpressure(pubsub.BackpressureConfig{MaxDepth: 2, Policy: tt.policy, DLQ: f.repos.DLQ}),
+			)
+
+			oldest, err := publisher.Publish(ctx, f.request())
+			require.NoError(t, err)
+			_, err = publisher.Publish(ctx, f.request())
+			require.NoError(t, err)
+
+			result, err := publisher.Publish(ctx, f.request())
+			assert.Equal(t, tt.wantBacklog, f.backlog(t))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			for i := range tt.wantThrottled {
+				tt.wantThrottled[i].SubscriptionID = f.subscription.ID
+			}
+			assert.Equal(t, tt.wantQueued, result.QueueItemsCreated)
+			assert.Equal(t, tt.wantThrottled, result.Throttled)
+
+			_, err = f.repos.Queue.FindByMessageID(ctx, f.subscription.ID, oldest.MessageID)
+			assert.Equal(t, tt.wantDropped, pubsub.IsNoData(err), "oldest item dropped")
+
+			dlq, err := f.repos.DLQ.FindByMessageID(ctx, result.MessageID)
+			if tt.wantDLQ {
+				require.NoError(t, err)
+				assert.Equal(t, "Queue full (2 of 2 items)", dlq.FailureReason)
+				assert.NotContains(t, result.SubscriptionsIDs, f.subscription.ID)
+				require.Len(t, notifications.dlq, 1)
+				assert.Equal(t, dlq.ID, notifications.dlq[0].ID)
+			} else {
+				assert.True(t, pubsub.IsNoData(err))
+				assert.Empty(t, notifications.dlq)
+			}
+		})
+	}
+}
+
+func TestPublisher_SubscriptionMaxQueueDepth(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+	f.subscription.MaxQueueDepth = 1
+	_, err := f.repos.Subscription.Save(ctx, f.subscription)
+	require.NoError(t, err)
+
+	// The subscription limit applies without a global MaxDepth
+	publisher := f.publisher(t, pubsub.WithBackpressure(pubsub.BackpressureConfig{}))
+	_, err = publisher.Publish(ctx, f.request())
+	require.NoError(t, err)
+	_, err = publisher.Publish(ctx, f.request())
+	assert.ErrorIs(t, err, pubsub.ErrQueueFull)
+	assert.Equal(t, 1, f.backlog(t))
+}
+
+func TestPublisher_BacklogHighWater(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+	notifications := &testNotifications{}
+	publisher := f.publisher(t,
+		pubsub.WithPublisherNotifications(notifications),
+		pubsub.WithBackpressure(pubsub.BackpressureConfig{MaxDepth: 5, HighWater: 0.6}),
+	)
+
+	for range 4 {
+		result, err := publisher.Publish(ctx, f.request())
+		require.NoError(t, err)
+		assert.Empty(t, result.Throttled)
+	}
+
+	// Fired once, when the backlog reached 3 of 5
+	require.Len(t, notifications.alerts, 1)
+	alert := notifications.alerts[0]
+	assert.Equal(t, f.subscription.ID, alert.SubscriptionID)
+	assert.Equal(t, 3, alert.Depth)
+	assert.Equal(t, 3, alert.HighWaterMark)
+	assert.Equal(t, 5, alert.MaxDepth)
+}
+
+func TestPublisher_PublishTxBackpressure(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+	notifications := &testNotifications{}
+	publisher := f.publisher(t,
+		pubsub.WithOutbox(relica.NewOutboxRepository(sqlitetest.DriverName)),
+		pubsub.WithPublisherNotifications(notifications),
+		pubsub.WithBackpressure(pubsub.BackpressureConfig{MaxDepth: 1, Policy: pubsub.OverflowDeadLetter, DLQ: f.repos.DLQ, HighWater: 1}),
+	)
+
+	publishTx := func(commit bool) []pubsub.ThrottledSubscription {
+		tx, err := f.db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+
+		// The backlog is counted within tx: the first publish fills the queue for the second
+		_, err = publisher.PublishTx(ctx, tx, f.request())
+		require.NoError(t, err)
+		result, err := publisher.PublishTx(ctx, tx, f.request())
+		require.NoError(t, err)
+
+		if commit {
+			require.NoError(t, tx.Commit())
+		} else {
+			require.NoError(t, tx.Rollback())
+		}
+		return result.Throttled
+	}
+
+	wantThrottled := []pubsub.ThrottledSubscription{
+		{SubscriptionID: f.subscription.ID, Depth: 1, MaxDepth: 1, Policy: pubsub.OverflowDeadLetter},
+	}
+	assert.Equal(t, wantThrottled, publishTx(false))
+	assert.Zero(t, f.backlog(t))
+	stats, err := f.repos.DLQ.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.TotalItems, "dead-lettered overflow rolled back")
+
+	assert.Equal(t, wantThrottled, publishTx(true))
+	assert.Equal(t, 1, f.backlog(t))
+	stats, err = f.repos.DLQ.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.TotalItems)
+
+	// Nothing is notified for a transaction that may be rolled back
+	assert.Empty(t, notifications.dlq)
+	assert.Empty(t, notifications.alerts)
+}
+
+// plainNotifications implements only NotificationService, without BacklogNotifier.
+type plainNotifications struct {
+	pubsub.NotificationService
+}
+
+func TestPublisher_BacklogHighWaterRequiresNotifier(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+	publisher := f.publisher(t,
+		pubsub.WithPublisherNotifications(plainNotifications{}),
+		pubsub.WithBackpressure(pubsub.BackpressureConfig{MaxDepth: 2, HighWater: 0.5}),
+	)
+
+	// Crossing the high-water mark is not reported to services without BacklogNotifier
+	for range 2 {
+		_, err := publisher.Publish(ctx, f.request())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, f.backlog(t))
+}
@@ -0,0 +1,208 @@
+package pubsub
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a subscriber's circuit breaker.
+type CircuitState string
+
+const (
+	// CircuitClosed allows deliveries (normal operation).
+	CircuitClosed CircuitState = "closed"
+
+	// CircuitOpen skips deliveries; queue items are rescheduled without an attempt.
+	CircuitOpen CircuitState = "open"
+
+	// CircuitHalfOpen allows a single probe delivery to test whether the subscriber recovered.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitBreakerConfig configures the per-subscriber circuit breaker of QueueWorker.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive transient failures that opens the circuit.
+	FailureThreshold int
+
+	// OpenDuration is how long the circuit stays open before a probe delivery is allowed.
+	OpenDuration time.Duration
+}
+
+// DefaultCircuitBreakerConfig returns the default circuit breaker configuration:
+// open after 5 consecutive failures, probe again after 1 minute.
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold: 5,
+		OpenDuration:     time.Minute,
+	}
+}
+
+// validate checks the configuration values.
+func (c CircuitBreakerConfig) validate() error {
+	if c.FailureThreshold <= 0 {
+		return fmt.Errorf("circuit breaker failure threshold must be > 0, got %d", c.FailureThreshold)
+	}
+	if c.OpenDuration <= 0 {
+		return fmt.Errorf("circuit breaker open duration must be > 0, got %v", c.OpenDuration)
+	}
+	return nil
+}
+
+// CircuitStateChange describes a circuit breaker state transition for a subscriber.
+// It is passed to CircuitStateNotifier.NotifyCircuitStateChanged.
+type CircuitStateChange struct {
+	SubscriberID int64
+	From         CircuitState
+	To           CircuitState
+	Failures     int       // Consecutive failures at the time of the transition
+	At           time.Time // Time of the transition
+}
+
+// subscriberCircuit holds the breaker state of a single subscriber.
+type subscriberCircuit struct {
+	state          CircuitState
+	failures       int
+	openedAt       time.Time
+	probeStartedAt time.Time
+}
+
+// circuitBreaker tracks delivery health per subscriber.
+//
+// Only transient failures count towards opening the circuit. Successful deliveries
+// and permanent failures (e.g. 410 Gone) prove the endpoint is reachable and reset it.
+//
+// Thread safety: Safe for concurrent use.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	config   CircuitBreakerConfig
+	circuits map[int64]*subscriberCircuit
+	now      func() time.Time
+}
+
+// newCircuitBreaker creates a circuit breaker with all circuits closed.
+func newCircuitBreaker(config CircuitBreakerConfig) *circuitBreaker {
+	return &circuitBreaker{
+		config:   config,
+		circuits: make(map[int64]*subscriberCircuit),
+		now:      time.Now,
+	}
+}
+
+// circuit returns the circuit of a subscriber. Caller must hold mu.
+func (cb *circuitBreaker) circuit(subscriberID int64) *subscriberCircuit {
+	c, ok := cb.circuits[subscriberID]
+	if !ok {
+		c = &subscriberCircuit{state: CircuitClosed}
+		cb.circuits[subscriberID] = c
+	}
+	return c
+}
+
+// allow reports whether a delivery to the subscriber may be attempted.
+// When it may not, retryAt is the earliest time a probe will be allowed.
+// A non-nil change is returned when the call moved the circuit to half-open.
+func (cb *circuitBreaker) allow(subscriberID int64) (allowed bool, retryAt time.Time, change *CircuitStateChange) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
+	c := cb.circuit(subscriberID)
+
+	switch c.state {
+	case CircuitOpen:
+		probeAt := c.openedAt.Add(cb.config.OpenDuration)
+		if now.Before(probeAt) {
+			return false, probeAt, nil
+		}
+		change = cb.transition(subscriberID, c, CircuitHalfOpen, now)
+		c.probeStartedAt = now
+		return true, time.Time{}, change
+
+	case CircuitHalfOpen:
+		// One probe at a time. A probe that never reported back (e.g. the item failed
+		// before delivery) is replaced after OpenDuration.
+		probeDeadline := c.probeStartedAt.Add(cb.config.OpenDuration)
+		if now.Before(probeDeadline) {
+			return false, probeDeadline, nil
+		}
+		c.probeStartedAt = now
+		return true, time.Time{}, nil
+
+	default:
+		return true, time.Time{}, nil
+	}
+}
+
+// releaseProbe gives back the half-open probe slot of a subscriber whose probe item was not
+// delivered (e.g. deferred by the rate limiter), so the next item can probe right away
+// instead of after OpenDuration.
+func (cb *circuitBreaker) releaseProbe(subscriberID int64) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if c, ok := cb.circuits[subscriberID]; ok && c.state == CircuitHalfOpen {
+		c.probeStartedAt = time.Time{}
+	}
+}
+
+// recordSuccess records a delivery that reached the subscriber.
+// Returns a non-nil change if the circuit closed.
+func (cb *circuitBreaker) recordSuccess(subscriberID int64) *CircuitStateChange {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c := cb.circuit(subscriberID)
+	c.failures = 0
+	if c.state == CircuitClosed {
+		return nil
+	}
+	return cb.transition(subscriberID, c, CircuitClosed, cb.now())
+}
+
+// recordFailure records a transient delivery failure.
+// Returns a non-nil change if the circuit opened.
+func (cb *circuitBreaker) recordFailure(subscriberID int64) *CircuitStateChange {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
+	c := cb.circuit(subscriberID)
+	c.failures++
+
+	switch {
+	case c.state == CircuitHalfOpen:
+		// Probe failed: open again for another OpenDuration
+		c.openedAt = now
+		return cb.transition(subscriberID, c, CircuitOpen, now)
+	case c.state == CircuitClosed && c.failures >= cb.config.FailureThreshold:
+		c.openedAt = now
+		return cb.transition(subscriberID, c, CircuitOpen, now)
+	default:
+		return nil
+	}
+}
+
+// state returns the current state of a subscriber's circuit.
+func (cb *circuitBreaker) state(subscriberID int64) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if c, ok := cb.circuits[subscriberID]; ok {
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// transition changes the circuit state. Caller must hold mu.
+func (cb *circuitBreaker) transition(subscriberID int64, c *subscriberCircuit, to CircuitState, at time.Time) *CircuitStateChange {
+	change := &CircuitStateChange{
+		SubscriberID: subscriberID,
+		From:         c.state,
+		To:           to,
+		Failures:     c.failures,
+		At:           at,
+	}
+	c.state = to
+	return change
+}
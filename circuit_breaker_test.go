@@ -0,0 +1,321 @@
+package pubsub
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/coregx/pubsub/model"
+	"github.com/coregx/pubsub/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClock is a manually advanced clock for time-dependent components.
+type testClock struct {
+	now time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestCircuitBreakerConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  CircuitBreakerConfig
+		wantErr bool
+	}{
+		{"default", DefaultCircuitBreakerConfig(), false},
+		{"zero threshold", CircuitBreakerConfig{FailureThreshold: 0, OpenDuration: time.Second}, true},
+		{"negative open duration", CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: -time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// circuitStep is one call on a circuit breaker and its expected outcome.
+type circuitStep struct {
+	advance time.Duration // Clock advance before the call
+	call    string        // "allow", "success", "failure" or "release"
+	allowed bool          // Expected result of allow
+	change  CircuitState  // Expected transition target, "" for none
+	state   CircuitState  // Expected state after the call
+}
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	const subscriberID = 7
+	config := CircuitBreakerConfig{FailureThreshold: 3, OpenDuration: time.Minute}
+
+	tests := []struct {
+		name  string
+		steps []circuitStep
+	}{
+		{
+			name: "failures below threshold keep the circuit closed",
+			steps: []circuitStep{
+				{call: "failure", state: CircuitClosed},
+				{call: "failure", state: CircuitClosed},
+				{call: "allow", allowed: true, state: CircuitClosed},
+			},
+		},
+		{
+			name: "success resets the failure count",
+			steps: []circuitStep{
+				{call: "failure", state: CircuitClosed},
+				{call: "failure", state: CircuitClosed},
+				{call: "success", state: CircuitClosed},
+				{call: "failure", state: CircuitClosed},
+				{call: "failure", state: CircuitClosed},
+				{call: "allow", allowed: true, state: CircuitClosed},
+			},
+		},
+		{
+			name: "threshold opens the circuit until the open duration passed",
+			steps: []circuitStep{
+				{call: "failure", state: CircuitClosed},
+				{call: "failure", state: CircuitClosed},
+				{call: "failure", change: CircuitOpen, state: CircuitOpen},
+				{call: "allow", allowed: false, state: CircuitOpen},
+				{advance: 59 * time.Second, call: "allow", allowed: false, state: CircuitOpen},
+			},
+		},
+		{
+			name: "a single probe after the open duration, success closes",
+			steps: []circuitStep{
+				{call: "failure"}, {call: "failure"}, {call: "failure", change: CircuitOpen, state: CircuitOpen},
+				{advance: time.Minute, call: "allow", allowed: true, change: CircuitHalfOpen, state: CircuitHalfOpen},
+				{call: "allow", allowed: false, state: CircuitHalfOpen},
+				{call: "success", change: CircuitClosed, state: CircuitClosed},
+				{call: "allow", allowed: true, state: CircuitClosed},
+			},
+		},
+		{
+			name: "failed probe opens the circuit again",
+			steps: []circuitStep{
+				{call: "failure"}, {call: "failure"}, {call: "failure", change: CircuitOpen, state: CircuitOpen},
+				{advance: time.Minute, call: "allow", allowed: true, change: CircuitHalfOpen, state: CircuitHalfOpen},
+				{call: "failure", change: CircuitOpen, state: CircuitOpen},
+				{advance: 30 * time.Second, call: "allow", allowed: false, state: CircuitOpen},
+				{advance: 30 * time.Second, call: "allow", allowed: true, change: CircuitHalfOpen, state: CircuitHalfOpen},
+			},
+		},
+		{
+			name: "a probe that never reports back is replaced after the open duration",
+			steps: []circuitStep{
+				{call: "failure"}, {call: "failure"}, {call: "failure", change: CircuitOpen, state: CircuitOpen},
+				{advance: time.Minute, call: "allow", allowed: true, change: CircuitHalfOpen, state: CircuitHalfOpen},
+				{advance: 59 * time.Second, call: "allow", allowed: false, state: CircuitHalfOpen},
+				{advance: time.Second, call: "allow", allowed: true, state: CircuitHalfOpen},
+			},
+		},
+		{
+			name: "a released probe is replaced right away",
+			steps: []circuitStep{
+				{call: "failure"}, {call: "failure"}, {call: "failure", change: CircuitOpen, state: CircuitOpen},
+				{advance: time.Minute, call: "allow", allowed: true, change: CircuitHalfOpen, state: CircuitHalfOpen},
+				{call: "release", state: CircuitHalfOpen},
+				{call: "allow", allowed: true, state: CircuitHalfOpen},
+				{call: "allow", allowed: false, state: CircuitHalfOpen},
+			},
+		},
+		{
+			name: "release has no effect outside half-open",
+			steps: []circuitStep{
+				{call: "release", state: CircuitClosed},
+				{call: "failure"}, {call: "failure"}, {call: "failure", change: CircuitOpen, state: CircuitOpen},
+				{call: "release", state: CircuitOpen},
+				{call: "allow", allowed: false, state: CircuitOpen},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newTestClock()
+			cb := newCircuitBreaker(config)
+			cb.now = clock.Now
+
+			for i, step := range tt.steps {
+				clock.Advance(step.advance)
+
+				var change *CircuitStateChange
+				switch step.call {
+				case "allow":
+					var allowed bool
+					allowed, _, change = cb.allow(subscriberID)
+					assert.Equal(t, step.allowed, allowed, "step %d: allowed", i)
+				case "success":
+					change = cb.recordSuccess(subscriberID)
+				case "failure":
+					change = cb.recordFailure(subscriberID)
+				case "release":
+					cb.releaseProbe(subscriberID)
+				default:
+					t.Fatalf("step %d: unknown call %q", i, step.call)
+				}
+
+				if step.change == "" {
+					assert.Nil(t, change, "step %d: change", i)
+				} else if assert.NotNil(t, change, "step %d: change", i) {
+					assert.Equal(t, step.change, change.To, "step %d: change", i)
+					assert.Equal(t, int64(subscriberID), change.SubscriberID)
+					assert.Equal(t, clock.Now(), change.At)
+				}
+				if step.state != "" {
+					assert.Equal(t, step.state, cb.state(subscriberID), "step %d: state", i)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_RetryAt(t *testing.T) {
+	clock := newTestClock()
+	cb := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute})
+	cb.now = clock.Now
+
+	change := cb.recordFailure(1)
+	require.NotNil(t, change)
+	assert.Equal(t, CircuitClosed, change.From)
+	assert.Equal(t, 1, change.Failures)
+	openedAt := clock.Now()
+
+	clock.Advance(10 * time.Second)
+	allowed, retryAt, _ := cb.allow(1)
+	assert.False(t, allowed)
+	assert.Equal(t, openedAt.Add(time.Minute), retryAt, "deferred until the probe is allowed")
+
+	// Circuits are per subscriber
+	allowed, _, _ = cb.allow(2)
+	assert.True(t, allowed)
+	assert.Equal(t, CircuitClosed, cb.state(2))
+}
+
+// testSubscriptions is a SubscriptionRepository holding one subscription.
+type testSubscriptions struct {
+	SubscriptionRepository
+	subscription model.Subscription
+}
+
+func (s *testSubscriptions) Load(_ context.Context, id int64) (model.Subscription, error) {
+	if id != s.subscription.ID {
+		return model.Subscription{}, ErrNoData
+	}
+	return s.subscription, nil
+}
+
+// testMessages is a MessageRepository holding one message.
+type testMessages struct {
+	MessageRepository
+	message model.Message
+}
+
+func (m *testMessages) Load(_ context.Context, id int64) (model.Message, error) {
+	if id != m.message.ID {
+		return model.Message{}, ErrNoData
+	}
+	return m.message, nil
+}
+
+// testQueueSaves is a QueueRepository that accepts saves.
+type testQueueSaves struct {
+	QueueRepository
+}
+
+func (testQueueSaves) Save(_ context.Context, m *model.Queue) (*model.Queue, error) {
+	return m, nil
+}
+
+func TestQueueWorker_RateLimitedProbeReleasesCircuit(t *testing.T) {
+	ctx := context.Background()
+	clock := newTestClock()
+	cb := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute})
+	cb.now = clock.Now
+	rl := newRateLimiter()
+	rl.now = clock.Now
+	subscribers := &testSubscribers{subscriber: model.Subscriber{
+		ID: 1, WebhookURL: "https://a.example/hook", IsActive: true, RateLimit: 1, RateBurst: 1,
+	}}
+	provider := NewSubscriberTransmitterProvider(subscribers)
+	w := &QueueWorker{
+		qr:                  testQueueSaves{},
+		mr:                  &testMessages{message: model.Message{ID: 1, Data: `{"userId":123}`}},
+		sr:                  &testSubscriptions{subscription: model.Subscription{ID: 1, SubscriberID: 1}},
+		transmitterProvider: provider,
+		rateLimitProvider:   provider,
+		targetProvider:      provider,
+		retryStrategy:       retry.DefaultStrategy(),
+		logger:              &NoopLogger{},
+		notificationService: &NoOpNotificationService{},
+		circuitBreaker:      cb,
+		rateLimiter:         rl,
+		workerID:            "worker-a",
+	}
+	claimed := func() *model.Queue {
+		item := model.NewQueue(1, 1)
+		item.LeaseOwner = sql.NullString{String: w.workerID, Valid: true}
+		item.LeaseExpiresAt = sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}
+		return &item
+	}
+
+	// The circuit opened a minute ago, and the subscriber's token is used up
+	cb.recordFailure(1)
+	clock.Advance(time.Minute)
+	allowed, _ := rl.allow(1, RateLimit{PerSecond: 1, Burst: 1}, "https://a.example/hook")
+	require.True(t, allowed)
+
+	// The probe is deferred by the rate limiter before any delivery
+	err := w.processQueueItem(ctx, claimed())
+	require.ErrorIs(t, err, errDeliveryDeferred)
+	assert.Equal(t, CircuitHalfOpen, cb.state(1))
+
+	// The probe slot was given back: the next item may probe without waiting OpenDuration
+	allowed, _, _ = cb.allow(1)
+	assert.True(t, allowed)
+}
+
+// testCircuitNotifications is a NotificationService that implements CircuitStateNotifier.
+type testCircuitNotifications struct {
+	NoOpNotificationService
+	changes []CircuitStateChange
+}
+
+func (n *testCircuitNotifications) NotifyCircuitStateChanged(_ context.Context, change CircuitStateChange) error {
+	n.changes = append(n.changes, change)
+	return nil
+}
+
+// testNotificationService implements only NotificationService.
+type testNotificationService struct {
+	NotificationService
+}
+
+func TestQueueWorker_NotifyCircuitStateChanged(t *testing.T) {
+	ctx := context.Background()
+	change := &CircuitStateChange{SubscriberID: 1, From: CircuitClosed, To: CircuitOpen, Failures: 5}
+
+	notifier := &testCircuitNotifications{}
+	w := &QueueWorker{notificationService: notifier, logger: &NoopLogger{}}
+	w.notifyCircuitStateChanged(ctx, change)
+	w.notifyCircuitStateChanged(ctx, nil)
+	assert.Equal(t, []CircuitStateChange{*change}, notifier.changes)
+
+	// Services without CircuitStateNotifier are not notified
+	w = &QueueWorker{notificationService: testNotificationService{}, logger: &NoopLogger{}}
+	assert.NotPanics(t, func() { w.notifyCircuitStateChanged(ctx, change) })
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/adapters/relica"
+	"github.com/coregx/pubsub/cmd/pubsub-server/internal/api"
+	"github.com/coregx/pubsub/cmd/pubsub-server/internal/config"
+	"github.com/coregx/pubsub/webhook"
+)
+
+// app holds the services and HTTP handler of a wired PubSub server.
+type app struct {
+	publisher           *pubsub.Publisher
+	subscriptionManager *pubsub.SubscriptionManager
+	worker              *pubsub.QueueWorker
+	handler             http.Handler
+}
+
+// wakeup connects the publisher with queue workers.
+type wakeup interface {
+	pubsub.WakeupNotifier
+	pubsub.WakeupSource
+}
+
+// newApp wires publisher, subscription manager, queue worker and HTTP routes
+// on top of the given repositories. A nil wakeup means an in-process channel,
+// which only wakes this process's worker.
+func newApp(repos *relica.Repositories, cfg config.PubSubConfig, logger pubsub.Logger, wake wakeup) (*app, error) {
+	if wake == nil {
+		wake = pubsub.NewChannelWakeup()
+	}
+
+	// Create notification service
+	var notificationService pubsub.NotificationService
+	if cfg.EnableNotifications {
+		notificationService = pubsub.NewLoggingNotificationService(logger)
+	} else {
+		notificationService = &pubsub.NoOpNotificationService{}
+	}
+
+	// Create Publisher service
+	publisherOpts := []pubsub.PublisherOption{
+		pubsub.WithPublisherRepositories(repos.Message, repos.Queue, repos.Subscription, repos.Topic),
+		pubsub.WithPublisherLogger(logger),
+		pubsub.WithPublisherWakeup(wake),
+		pubsub.WithPublisherNotifications(notificationService),
+		pubsub.WithBackpressure(pubsub.BackpressureConfig{
+			MaxDepth:  cfg.MaxQueueDepth,
+			Policy:    pubsub.OverflowPolicy(cfg.OverflowPolicy),
+			HighWater: float64(cfg.QueueHighWater) / 100,
+			DLQ:       repos.DLQ,
+		}),
+	}
+	if cfg.IdempotencyWindow > 0 {
+		publisherOpts = append(publisherOpts, pubsub.WithIdempotencyWindow(time.Duration(cfg.IdempotencyWindow)*time.Second))
+	}
+	publisher, err := pubsub.NewPublisher(publisherOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create SubscriptionManager service
+	subscriptionManager, err := pubsub.NewSubscriptionManager(
+		pubsub.WithSubscriptionManagerRepositories(repos.Subscription, repos.Subscriber, repos.Topic),
+		pubsub.WithSubscriptionManagerQueue(repos.Queue),
+		pubsub.WithSubscriptionManagerLogger(logger),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create webhook delivery: callback URLs come from subscriber configuration
+	gateway, err := newDeliveryGateway(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create QueueWorker (rate limits are read from subscriber configuration)
+	subscriberProvider := pubsub.NewSubscriberTransmitterProvider(repos.Subscriber)
+	workerOpts := []pubsub.Option{
+		pubsub.WithRepositories(repos.Queue, repos.Message, repos.Subscription, repos.DLQ),
+		pubsub.WithTopicRepository(repos.Topic),
+		pubsub.WithDelivery(subscriberProvider, gateway),
+		pubsub.WithLogger(logger),
+		pubsub.WithBatchSize(cfg.BatchSize),
+		pubsub.WithConcurrency(cfg.Concurrency),
+		pubsub.WithLeaseDuration(time.Duration(cfg.LeaseDuration) * time.Second),
+		pubsub.WithNotifications(notificationService),
+		pubsub.WithRateLimiting(subscriberProvider),
+		pubsub.WithWakeup(wake),
+	}
+	if cfg.WorkerID != "" {
+		workerOpts = append(workerOpts, pubsub.WithWorkerID(cfg.WorkerID))
+	}
+	if cfg.Partitions > 0 {
+		workerOpts = append(workerOpts, pubsub.WithPartitioning(pubsub.PartitionConfig{
+			Count:             cfg.Partitions,
+			Heartbeats:        repos.Heartbeat,
+			HeartbeatInterval: time.Duration(cfg.HeartbeatInterval) * time.Second,
+		}))
+	}
+	if cfg.LeaderLeaseDuration > 0 {
+		workerOpts = append(workerOpts, pubsub.WithLeaderElection(repos.Lock, time.Duration(cfg.LeaderLeaseDuration)*time.Second))
+	}
+	if cfg.RetentionInterval > 0 {
+		workerOpts = append(workerOpts, pubsub.WithRetention(pubsub.RetentionConfig{
+			Interval:         time.Duration(cfg.RetentionInterval) * time.Second,
+			BatchSize:        cfg.RetentionBatchSize,
+			PurgeResolvedDLQ: cfg.RetentionPurgeResolvedDLQ,
+		}))
+	}
+	if cfg.MaxWorkerInterval > 0 {
+		workerOpts = append(workerOpts, pubsub.WithAdaptivePolling(time.Duration(cfg.MaxWorkerInterval)*time.Second))
+	}
+	if cfg.CircuitBreakerThreshold > 0 {
+		workerOpts = append(workerOpts, pubsub.WithCircuitBreaker(pubsub.CircuitBreakerConfig{
+			FailureThreshold: cfg.CircuitBreakerThreshold,
+			OpenDuration:     time.Duration(cfg.CircuitBreakerOpenDuration) * time.Second,
+		}))
+	}
+	if cfg.HostRateLimit > 0 {
+		workerOpts = append(workerOpts, pubsub.WithHostRateLimit(pubsub.RateLimit{
+			PerSecond: cfg.HostRateLimit,
+			Burst:     cfg.HostRateBurst,
+		}))
+	}
+
+	worker, err := pubsub.NewQueueWorker(workerOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := api.NewHandler(publisher, subscriptionManager, worker, logger)
+
+	return &app{
+		publisher:           publisher,
+		subscriptionManager: subscriptionManager,
+		worker:              worker,
+		handler:             loggingMiddleware(newRouter(handler, cfg.AdminToken), logger),
+	}, nil
+}
+
+// newDeliveryGateway creates the webhook gateway from configuration.
+func newDeliveryGateway(cfg config.PubSubConfig) (*webhook.Gateway, error) {
+	opts := []webhook.Option{
+		webhook.WithTimeout(time.Duration(cfg.DeliveryTimeout) * time.Second),
+		webhook.WithMaxBodySize(int64(cfg.MaxBodySize)),
+	}
+	if cfg.SigningSecret != "" {
+		opts = append(opts, webhook.WithSigningSecret(cfg.SigningSecret))
+	}
+
+	return webhook.New(opts...)
+}
+
+// newRouter registers the REST API routes.
+// Admin routes are only served with an admin token, to requests that present it.
+func newRouter(handler *api.Handler, adminToken string) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/publish", handler.HandlePublish)
+	mux.HandleFunc("/api/v1/messages/", handler.HandleCancelMessage) // Note trailing slash for :id
+	mux.HandleFunc("/api/v1/subscribe", handler.HandleSubscribe)
+	mux.HandleFunc("/api/v1/subscriptions", handler.HandleListSubscriptions)
+	mux.HandleFunc("/api/v1/subscriptions/", handler.HandleUnsubscribe) // Note trailing slash for :id
+	mux.HandleFunc("/api/v1/subscriptions/{id}/pause", handler.HandlePauseSubscription)
+	mux.HandleFunc("/api/v1/subscriptions/{id}/resume", handler.HandleResumeSubscription)
+	mux.HandleFunc("/api/v1/health", handler.HandleHealth)
+	if adminToken != "" {
+		mux.HandleFunc("/api/v1/admin/stats", handler.RequireToken(adminToken, handler.HandleStats))
+	}
+	return mux
+}
+
+// loggingMiddleware logs HTTP requests.
+func loggingMiddleware(next http.Handler, logger pubsub.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		logger.Infof("%s %s", r.Method, r.URL.Path)
+		next.ServeHTTP(w, r)
+		logger.Debugf("%s %s - %v", r.Method, r.URL.Path, time.Since(start))
+	})
+}
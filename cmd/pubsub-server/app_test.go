@@ -0,0 +1,845 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/adapters/relica"
+	"github.com/coregx/pubsub/cmd/pubsub-server/internal/config"
+	"github.com/coregx/pubsub/internal/sqlitetest"
+	"github.com/coregx/pubsub/model"
+	"github.com/coregx/pubsub/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLogger struct{ t *testing.T }
+
+func (l testLogger) Debugf(format string, args ...interface{}) { l.t.Logf("[DEBUG] "+format, args...) }
+func (l testLogger) Infof(format string, args ...interface{})  { l.t.Logf("[INFO] "+format, args...) }
+func (l testLogger) Warnf(format string, args ...interface{})  { l.t.Logf("[WARN] "+format, args...) }
+func (l testLogger) Errorf(format string, args ...interface{}) { l.t.Logf("[ERROR] "+format, args...) }
+func (l testLogger) Info(message string)                       { l.t.Log("[INFO] " + message) }
+
+// webhookDelivery is a request received by the test webhook.
+type webhookDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+func testPubSubConfig() config.PubSubConfig {
+	return config.PubSubConfig{
+		BatchSize:       10,
+		Concurrency:     1,
+		LeaseDuration:   60,
+		WorkerInterval:  1,
+		DeliveryTimeout: 5,
+		SigningSecret:   "test-secret",
+		MaxBodySize:     1 << 20,
+	}
+}
+
+// testFixture is a wired server with one topic, subscriber and subscription.
+type testFixture struct {
+	app          *app
+	db           *sql.DB
+	repos        *relica.Repositories
+	topic        model.Topic
+	subscriber   model.Subscriber
+	subscription model.Subscription
+}
+
+// newTestFixture wires the server on a SQLite database with the PubSub schema, using the
+// same relica repositories as main. The subscriber's webhook is webhookURL.
+// Configure adjusts the default test configuration.
+func newTestFixture(t *testing.T, webhookURL string, configure ...func(*config.PubSubConfig)) *testFixture {
+	t.Helper()
+	ctx := context.Background()
+	db := sqlitetest.Open(t)
+	repos := relica.NewRepositories(db, sqlitetest.DriverName)
+
+	topic, err := repos.Topic.Save(ctx, model.NewTopic("user.signup", "User Signup", ""))
+	require.NoError(t, err)
+	subscriber, err := repos.Subscriber.Save(ctx, model.NewSubscriber(1, "billing", webhookURL))
+	require.NoError(t, err)
+	subscription, err := repos.Subscription.Save(ctx, model.NewSubscription(subscriber.ID, topic.ID, "user-123", ""))
+	require.NoError(t, err)
+
+	cfg := testPubSubConfig()
+	for _, fn := range configure {
+		fn(&cfg)
+	}
+
+	application, err := newApp(repos, cfg, testLogger{t}, nil)
+	require.NoError(t, err)
+
+	return &testFixture{
+		app:          application,
+		db:           db,
+		repos:        repos,
+		topic:        topic,
+		subscriber:   subscriber,
+		subscription: subscription,
+	}
+}
+
+// publish publishes a message to the fixture's topic and identifier.
+func (f *testFixture) publish(t *testing.T) *pubsub.PublishResult {
+	t.Helper()
+	return f.publishWith(t, pubsub.PublishRequest{})
+}
+
+// publishWith publishes req to the fixture's topic and identifier.
+func (f *testFixture) publishWith(t *testing.T, req pubsub.PublishRequest) *pubsub.PublishResult {
+	t.Helper()
+	req.TopicCode = f.topic.Code
+	req.Identifier = f.subscription.Identifier
+	req.Data = `{"userId":123}`
+
+	result, err := f.app.publisher.Publish(context.Background(), req)
+	require.NoError(t, err)
+	return result
+}
+
+func postJSON(t *testing.T, url string, payload interface{}) *http.Response {
+	t.Helper()
+
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestServer_PublishDeliversToWebhook(t *testing.T) {
+	ctx := context.Background()
+
+	deliveries := make(chan webhookDelivery, 1)
+	subscriberWebhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- webhookDelivery{header: r.Header.Clone(), body: body}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer subscriberWebhook.Close()
+
+	f := newTestFixture(t, subscriberWebhook.URL)
+
+	server := httptest.NewServer(f.app.handler)
+	defer server.Close()
+
+	// Publish via REST API
+	resp := postJSON(t, server.URL+"/api/v1/publish", map[string]interface{}{
+		"topicCode":  f.topic.Code,
+		"identifier": "user-123",
+		"data":       map[string]interface{}{"userId": 123},
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var published struct {
+		Data pubsub.PublishResult `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&published))
+	assert.Equal(t, 1, published.Data.QueueItemsCreated)
+
+	// One worker pass delivers the queued message
+	processed, err := f.app.worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+
+	var delivery webhookDelivery
+	select {
+	case delivery = <-deliveries:
+	default:
+		t.Fatal("webhook did not receive the message")
+	}
+
+	assert.Equal(t, strconv.FormatInt(published.Data.MessageID, 10), delivery.header.Get(webhook.HeaderMessageID))
+	assert.Equal(t, "1", delivery.header.Get(webhook.HeaderAttempt))
+	assert.NoError(t, webhook.Verify(testPubSubConfig().SigningSecret, delivery.header.Get(webhook.HeaderTimestamp),
+		delivery.header.Get(webhook.HeaderSignature), delivery.body, webhook.DefaultTolerance))
+
+	var message model.DataMessage
+	require.NoError(t, json.Unmarshal(delivery.body, &message))
+	data, err := base64.StdEncoding.DecodeString(message.Data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"userId":123}`, string(data))
+
+	// Queue item is marked as sent
+	items, err := f.repos.Queue.FindBySubscriptionID(ctx, f.subscription.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, model.QueueStatusSent, items[0].Status)
+}
+
+func TestServer_InactiveSubscriberIsNotDelivered(t *testing.T) {
+	ctx := context.Background()
+
+	called := false
+	subscriberWebhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer subscriberWebhook.Close()
+
+	f := newTestFixture(t, subscriberWebhook.URL)
+	f.subscriber.IsActive = false
+	_, err := f.repos.Subscriber.Save(ctx, f.subscriber)
+	require.NoError(t, err)
+
+	f.publish(t)
+
+	processed, err := f.app.worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, processed)
+	assert.False(t, called)
+}
+
+func TestServer_ReplicasDeliverEachItemOnce(t *testing.T) {
+	const messages = 20
+
+	var mu sync.Mutex
+	received := map[string]int{}
+	subscriberWebhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.Header.Get(webhook.HeaderMessageID)]++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer subscriberWebhook.Close()
+
+	f := newTestFixture(t, subscriberWebhook.URL, func(cfg *config.PubSubConfig) {
+		cfg.Concurrency = 4
+		cfg.WorkerID = "replica-1"
+	})
+	cfg := testPubSubConfig()
+	cfg.Concurrency = 4
+	cfg.WorkerID = "replica-2"
+	replica, err := newApp(f.repos, cfg, testLogger{t}, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < messages; i++ {
+		f.publish(t)
+	}
+
+	// Both replicas poll the shared store at the same time
+	var wg sync.WaitGroup
+	for _, worker := range []*pubsub.QueueWorker{f.app.worker, replica.worker} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				processed, err := worker.ProcessPendingItems(context.Background())
+				assert.NoError(t, err)
+				if processed == 0 {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, received, messages)
+	for id, count := range received {
+		assert.Equal(t, 1, count, "message %s delivered more than once", id)
+	}
+}
+
+func TestServer_ExpiredLeaseIsReclaimed(t *testing.T) {
+	ctx := context.Background()
+
+	delivered := 0
+	subscriberWebhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		delivered++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer subscriberWebhook.Close()
+
+	f := newTestFixture(t, subscriberWebhook.URL)
+	f.publish(t)
+
+	// A replica claims the item and dies before delivering it
+	claimed, err := f.repos.Queue.ClaimItems(ctx, model.QueueStatusPending, "crashed-replica", 50*time.Millisecond, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	processed, err := f.app.worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, processed, "item is leased by another replica")
+
+	time.Sleep(100 * time.Millisecond)
+
+	processed, err = f.app.worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, 1, delivered)
+
+	item, err := f.repos.Queue.Load(ctx, claimed[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.QueueStatusSent, item.Status)
+	assert.False(t, item.LeaseOwner.Valid)
+}
+
+func TestServer_ScheduleAndCancel(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t, "http://127.0.0.1:1/unused")
+	server := httptest.NewServer(f.app.handler)
+	defer server.Close()
+
+	resp := postJSON(t, server.URL+"/api/v1/publish", map[string]interface{}{
+		"topicCode":    f.topic.Code,
+		"identifier":   "user-123",
+		"data":         map[string]interface{}{"userId": 123},
+		"delaySeconds": 86400,
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var published struct {
+		Data pubsub.PublishResult `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&published))
+
+	item, err := f.repos.Queue.FindByMessageID(ctx, f.subscription.ID, published.Data.MessageID)
+	require.NoError(t, err)
+	assert.True(t, item.IsScheduled())
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), item.NextRetryAt.Time, time.Minute)
+
+	cancel := func(messageID int64) int {
+		req, err := http.NewRequest(http.MethodDelete, server.URL+"/api/v1/messages/"+strconv.FormatInt(messageID, 10), nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, cancel(published.Data.MessageID))
+	assert.Equal(t, http.StatusNotFound, cancel(published.Data.MessageID), "already canceled")
+}
+
+func TestServer_PauseResumeSubscription(t *testing.T) {
+	ctx := context.Background()
+
+	var delivered sync.WaitGroup
+	subscriberWebhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		delivered.Done()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer subscriberWebhook.Close()
+
+	f := newTestFixture(t, subscriberWebhook.URL)
+	server := httptest.NewServer(f.app.handler)
+	defer server.Close()
+
+	subscriptionURL := server.URL + "/api/v1/subscriptions/" + strconv.FormatInt(f.subscription.ID, 10)
+	resp := postJSON(t, subscriptionURL+"/pause", map[string]interface{}{})
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Paused: messages are queued but held
+	f.publish(t)
+	f.publish(t)
+	processed, err := f.app.worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, processed)
+	items, err := f.repos.Queue.FindBySubscriptionID(ctx, f.subscription.ID)
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	// Resuming releases the backlog
+	resp = postJSON(t, subscriptionURL+"/resume", nil)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	delivered.Add(2)
+	processed, err = f.app.worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, processed)
+	delivered.Wait()
+
+	// A pause with a resume time ends by itself
+	_, err = f.app.subscriptionManager.PauseSubscription(ctx, f.subscription.ID, time.Now().Add(50*time.Millisecond))
+	require.NoError(t, err)
+	f.publish(t)
+	processed, err = f.app.worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, processed)
+
+	time.Sleep(60 * time.Millisecond)
+	delivered.Add(1)
+	processed, err = f.app.worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	delivered.Wait()
+
+	// Invalid pause requests
+	resp = postJSON(t, subscriptionURL+"/pause", map[string]interface{}{"pauseSeconds": -1})
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = postJSON(t, server.URL+"/api/v1/subscriptions/999/pause", nil)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServer_PausedBacklogDoesNotExpire(t *testing.T) {
+	ctx := context.Background()
+
+	var delivered sync.WaitGroup
+	subscriberWebhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		delivered.Done()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer subscriberWebhook.Close()
+
+	f := newTestFixture(t, subscriberWebhook.URL)
+	ttl := 20 * time.Millisecond
+
+	// Paused until resumed: the TTL elapses, but the held item is not expired
+	_, err := f.app.subscriptionManager.PauseSubscription(ctx, f.subscription.ID, time.Time{})
+	require.NoError(t, err)
+	held := f.publishWith(t, pubsub.PublishRequest{TTL: ttl})
+	time.Sleep(2 * ttl)
+
+	cleaned, err := f.app.worker.CleanupExpiredItems(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, cleaned)
+
+	// Resuming gives it the topic TTL again, and it is delivered
+	_, err = f.app.subscriptionManager.ResumeSubscription(ctx, f.subscription.ID)
+	require.NoError(t, err)
+	item, err := f.repos.Queue.FindByMessageID(ctx, f.subscription.ID, held.MessageID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(model.DefaultQueueTTL), item.ExpiresAt, time.Minute)
+
+	cleaned, err = f.app.worker.CleanupExpiredItems(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, cleaned)
+	delivered.Add(1)
+	processed, err := f.app.worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	delivered.Wait()
+
+	// Paused with a resume time: items are deliverable for their TTL after it
+	resumeAt := time.Now().Add(2 * ttl)
+	_, err = f.app.subscriptionManager.PauseSubscription(ctx, f.subscription.ID, resumeAt)
+	require.NoError(t, err)
+	held = f.publishWith(t, pubsub.PublishRequest{TTL: ttl})
+	item, err = f.repos.Queue.FindByMessageID(ctx, f.subscription.ID, held.MessageID)
+	require.NoError(t, err)
+	assert.False(t, item.ExpiresAt.Before(resumeAt.Add(ttl)), "expires %v, before resume + TTL", item.ExpiresAt)
+
+	time.Sleep(time.Until(resumeAt))
+	cleaned, err = f.app.worker.CleanupExpiredItems(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, cleaned)
+	delivered.Add(1)
+	processed, err = f.app.worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	delivered.Wait()
+}
+
+func TestServer_WorkerStats(t *testing.T) {
+	subscriberWebhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer subscriberWebhook.Close()
+
+	f := newTestFixture(t, subscriberWebhook.URL, func(cfg *config.PubSubConfig) {
+		cfg.AdminToken = "admin-secret"
+	})
+	server := httptest.NewServer(f.app.handler)
+	defer server.Close()
+
+	f.publish(t)
+	processed, err := f.app.worker.ProcessPendingItems(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, processed)
+
+	// Exposed through the admin endpoint, to requests with the admin token
+	getStats := func(authorization string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/admin/stats", nil)
+		require.NoError(t, err)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+	for _, authorization := range []string{"", "Bearer wrong", "admin-secret"} {
+		resp := getStats(authorization)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "authorization %q", authorization)
+	}
+	resp := getStats("Bearer admin-secret")
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data struct {
+			Delivered     int64 `json:"delivered"`
+			Subscriptions map[string]struct {
+				Delivered int64 `json:"delivered"`
+			} `json:"subscriptions"`
+			DLQ struct {
+				TotalItems int `json:"totalItems"`
+			} `json:"dlq"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, int64(1), body.Data.Delivered)
+	assert.Equal(t, int64(1), body.Data.Subscriptions[strconv.FormatInt(f.subscription.ID, 10)].Delivered)
+	assert.Zero(t, body.Data.DLQ.TotalItems)
+}
+
+func TestServer_AdminEndpointsDisabledByDefault(t *testing.T) {
+	f := newTestFixture(t, "http://127.0.0.1:1/unused")
+	server := httptest.NewServer(f.app.handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/admin/stats")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// archiverFunc adapts a function to pubsub.MessageArchiver.
+type archiverFunc func(ctx context.Context, topic model.Topic, messages []model.Message) error
+
+func (f archiverFunc) ArchiveMessages(ctx context.Context, topic model.Topic, messages []model.Message) error {
+	return f(ctx, topic, messages)
+}
+
+func TestServer_MessageRetention(t *testing.T) {
+	ctx := context.Background()
+
+	subscriberWebhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer subscriberWebhook.Close()
+
+	f := newTestFixture(t, subscriberWebhook.URL)
+	f.topic.RetentionDays = 1
+	_, err := f.repos.Topic.Save(ctx, f.topic)
+	require.NoError(t, err)
+
+	age := func(messageID int64) {
+		message, err := f.repos.Message.Load(ctx, messageID)
+		require.NoError(t, err)
+		message.CreatedAt = time.Now().AddDate(0, 0, -2)
+		_, err = f.repos.Message.Save(ctx, message)
+		require.NoError(t, err)
+	}
+	exists := func(messageID int64) bool {
+		_, err := f.repos.Message.Load(ctx, messageID)
+		return err == nil
+	}
+
+	// Delivered, fresh, pending and dead-lettered messages
+	delivered := f.publish(t)
+	fresh := f.publish(t)
+	deadLettered := f.publish(t)
+	_, err = f.app.worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	pending := f.publish(t)
+	dlq, err := f.repos.DLQ.Save(ctx, model.NewDeadLetterQueue(f.subscription.ID, deadLettered.MessageID, 0, 5,
+		"timeout", "Max attempts exceeded", time.Now(), time.Now(), `{"userId":123}`, subscriberWebhook.URL))
+	require.NoError(t, err)
+	for _, result := range []*pubsub.PublishResult{delivered, deadLettered, pending} {
+		age(result.MessageID)
+	}
+
+	// Only the old, fully handled message is purged, with its queue items
+	purged, err := f.app.worker.PurgeOutdatedMessages(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.False(t, exists(delivered.MessageID))
+	_, err = f.repos.Queue.FindByMessageID(ctx, f.subscription.ID, delivered.MessageID)
+	assert.True(t, pubsub.IsNoData(err), "queue items purged")
+	for _, result := range []*pubsub.PublishResult{fresh, deadLettered, pending} {
+		assert.True(t, exists(result.MessageID))
+	}
+	assert.Equal(t, int64(1), f.app.worker.Stats().Purged)
+
+	// Resolving the DLQ entry releases its message; a running worker archives it first
+	dlq.Resolve("operator", "replayed manually")
+	_, err = f.repos.DLQ.Save(ctx, dlq)
+	require.NoError(t, err)
+
+	gateway, err := webhook.New()
+	require.NoError(t, err)
+	archived := make(chan []model.Message, 1)
+	worker, err := pubsub.NewQueueWorker(
+		pubsub.WithRepositories(f.repos.Queue, f.repos.Message, f.repos.Subscription, f.repos.DLQ),
+		pubsub.WithTopicRepository(f.repos.Topic),
+		pubsub.WithDelivery(pubsub.NewSubscriberTransmitterProvider(f.repos.Subscriber), gateway),
+		pubsub.WithLogger(testLogger{t}),
+		pubsub.WithRetention(pubsub.RetentionConfig{
+			Interval: 10 * time.Millisecond,
+			Archiver: archiverFunc(func(_ context.Context, topic model.Topic, messages []model.Message) error {
+				assert.Equal(t, f.topic.ID, topic.ID)
+				archived <- messages
+				return nil
+			}),
+		}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, worker.Start(time.Hour))
+	defer func() {
+		_, err := worker.Stop(ctx)
+		require.NoError(t, err)
+	}()
+
+	select {
+	case messages := <-archived:
+		require.Len(t, messages, 1)
+		assert.Equal(t, deadLettered.MessageID, messages[0].ID)
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not archived")
+	}
+	require.Eventually(t, func() bool { return !exists(deadLettered.MessageID) }, 2*time.Second, 5*time.Millisecond)
+	kept, err := f.repos.DLQ.FindByMessageID(ctx, deadLettered.MessageID)
+	require.NoError(t, err, "resolved DLQ entry kept for audit")
+	assert.Equal(t, `{"userId":123}`, kept.MessageData)
+	assert.True(t, exists(pending.MessageID))
+
+	// Retention needs the topic settings
+	_, err = pubsub.NewQueueWorker(
+		pubsub.WithRepositories(f.repos.Queue, f.repos.Message, f.repos.Subscription, f.repos.DLQ),
+		pubsub.WithDelivery(pubsub.NewSubscriberTransmitterProvider(f.repos.Subscriber), gateway),
+		pubsub.WithLogger(testLogger{t}),
+		pubsub.WithRetention(pubsub.RetentionConfig{}),
+	)
+	assert.Error(t, err)
+}
+
+func TestServer_FullQueueIsTooManyRequests(t *testing.T) {
+	f := newTestFixture(t, "http://127.0.0.1:1/unused", func(cfg *config.PubSubConfig) {
+		cfg.MaxQueueDepth = 1
+		cfg.OverflowPolicy = "reject"
+	})
+	server := httptest.NewServer(f.app.handler)
+	defer server.Close()
+
+	publish := func() *http.Response {
+		return postJSON(t, server.URL+"/api/v1/publish", map[string]interface{}{
+			"topicCode":  f.topic.Code,
+			"identifier": f.subscription.Identifier,
+			"data":       map[string]interface{}{"userId": 123},
+		})
+	}
+	require.Equal(t, http.StatusCreated, publish().StatusCode)
+	assert.Equal(t, http.StatusTooManyRequests, publish().StatusCode)
+}
+
+func TestServer_IdempotentPublish(t *testing.T) {
+	ctx := context.Background()
+
+	subscriberWebhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer subscriberWebhook.Close()
+
+	f := newTestFixture(t, subscriberWebhook.URL)
+	server := httptest.NewServer(f.app.handler)
+	defer server.Close()
+
+	publish := func(key string) (int, pubsub.PublishResult) {
+		body, err := json.Marshal(map[string]interface{}{
+			"topicCode":  f.topic.Code,
+			"identifier": f.subscription.Identifier,
+			"data":       map[string]interface{}{"userId": 123},
+		})
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/publish", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var response struct {
+			Data pubsub.PublishResult `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		return resp.StatusCode, response.Data
+	}
+
+	status, original := publish("order-42")
+	require.Equal(t, http.StatusCreated, status)
+	assert.False(t, original.Duplicate)
+
+	// Retried publish returns the original result without storing anything
+	status, retried := publish("order-42")
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, retried.Duplicate)
+	assert.Equal(t, original.MessageID, retried.MessageID)
+	assert.Equal(t, original.QueueItemsCreated, retried.QueueItemsCreated)
+	assert.Equal(t, original.SubscriptionsIDs, retried.SubscriptionsIDs)
+	depth, err := f.repos.Queue.CountBacklog(ctx, f.subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, depth)
+
+	// Other keys and publishes without a key are not deduplicated
+	status, other := publish("order-43")
+	assert.Equal(t, http.StatusCreated, status)
+	assert.NotEqual(t, original.MessageID, other.MessageID)
+	_, first := publish("")
+	_, second := publish("")
+	assert.NotEqual(t, first.MessageID, second.MessageID)
+
+	// After the idempotency window the key publishes a new message
+	message, err := f.repos.Message.Load(ctx, original.MessageID)
+	require.NoError(t, err)
+	message.CreatedAt = time.Now().Add(-pubsub.DefaultIdempotencyWindow - time.Minute)
+	_, err = f.repos.Message.Save(ctx, message)
+	require.NoError(t, err)
+
+	status, republished := publish("order-42")
+	assert.Equal(t, http.StatusCreated, status)
+	assert.False(t, republished.Duplicate)
+	assert.NotEqual(t, original.MessageID, republished.MessageID)
+	message, err = f.repos.Message.Load(ctx, original.MessageID)
+	require.NoError(t, err)
+	assert.False(t, message.IdempotencyKey.Valid, "expired key released")
+
+	// Oversized keys are rejected
+	status, _ = publish(strings.Repeat("k", pubsub.MaxIdempotencyKeyLength+1))
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestServer_TransactionalOutbox(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t, "http://127.0.0.1:1/unused")
+
+	db := f.db
+	_, err := db.Exec("CREATE TABLE orders (id INTEGER PRIMARY KEY, total INT NOT NULL)")
+	require.NoError(t, err)
+	count := func(table string) int {
+		var n int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n))
+		return n
+	}
+
+	publisher, err := pubsub.NewPublisher(
+		pubsub.WithPublisherRepositories(f.repos.Message, f.repos.Queue, f.repos.Subscription, f.repos.Topic),
+		pubsub.WithPublisherLogger(testLogger{t}),
+		pubsub.WithOutbox(relica.NewOutboxRepository(sqlitetest.DriverName)),
+	)
+	require.NoError(t, err)
+
+	// placeOrder saves an order and publishes order events in one transaction.
+	placeOrder := func(commit bool) *pubsub.PublishResult {
+		tx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		_, err = tx.ExecContext(ctx, "INSERT INTO orders (total) VALUES (?)", 4200)
+		require.NoError(t, err)
+		result, err := publisher.PublishTx(ctx, tx, pubsub.PublishRequest{
+			TopicCode:  f.topic.Code,
+			Identifier: f.subscription.Identifier,
+			Data:       `{"orderId":1}`,
+		})
+		require.NoError(t, err)
+		if commit {
+			require.NoError(t, tx.Commit())
+		} else {
+			require.NoError(t, tx.Rollback())
+		}
+		return result
+	}
+
+	// Rolled back together with the order
+	placeOrder(false)
+	assert.Zero(t, count("orders"))
+	assert.Zero(t, count("pubsub_message"))
+	assert.Zero(t, count("pubsub_queue"))
+
+	// Committed together with the order
+	result := placeOrder(true)
+	assert.Equal(t, 1, count("orders"))
+	assert.Equal(t, 1, count("pubsub_message"))
+	assert.Equal(t, []int64{f.subscription.ID}, result.SubscriptionsIDs)
+
+	var subscriptionID, messageID int64
+	var status string
+	require.NoError(t, db.QueryRow("SELECT subscription_id, message_id, status FROM pubsub_queue").
+		Scan(&subscriptionID, &messageID, &status))
+	assert.Equal(t, f.subscription.ID, subscriptionID)
+	assert.Equal(t, result.MessageID, messageID)
+	assert.Equal(t, string(model.QueueStatusPending), status)
+
+	// The committed item is in the worker's backlog
+	depth, err := f.repos.Queue.CountBacklog(ctx, f.subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, depth)
+
+	t.Run("requires outbox", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback() }()
+
+		_, err = f.app.publisher.PublishTx(ctx, tx, pubsub.PublishRequest{
+			TopicCode:  f.topic.Code,
+			Identifier: f.subscription.Identifier,
+			Data:       `{"orderId":1}`,
+		})
+		var pubsubErr *pubsub.Error
+		require.ErrorAs(t, err, &pubsubErr)
+		assert.Equal(t, pubsub.ErrCodeConfiguration, pubsubErr.Code)
+	})
+}
+
+func TestServer_PublishWakesWorker(t *testing.T) {
+	delivered := make(chan struct{}, 1)
+	subscriberWebhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		delivered <- struct{}{}
+	}))
+	defer subscriberWebhook.Close()
+
+	f := newTestFixture(t, subscriberWebhook.URL)
+
+	// Poll interval far beyond the test timeout: only the wakeup can trigger delivery
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		f.app.worker.Run(ctx, time.Hour)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	f.publish(t)
+
+	select {
+	case <-delivered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not delivered after publish")
+	}
+}
+
+func TestNewDeliveryGateway_InvalidConfig(t *testing.T) {
+	cfg := testPubSubConfig()
+	cfg.DeliveryTimeout = 0
+
+	_, err := newDeliveryGateway(cfg)
+	assert.Error(t, err)
+}
@@ -2,7 +2,11 @@
 package api
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -15,6 +19,7 @@ import (
 type Handler struct {
 	publisher           *pubsub.Publisher
 	subscriptionManager *pubsub.SubscriptionManager
+	worker              *pubsub.QueueWorker
 	logger              pubsub.Logger
 }
 
@@ -22,20 +27,28 @@ type Handler struct {
 func NewHandler(
 	publisher *pubsub.Publisher,
 	subscriptionManager *pubsub.SubscriptionManager,
+	worker *pubsub.QueueWorker,
 	logger pubsub.Logger,
 ) *Handler {
 	return &Handler{
 		publisher:           publisher,
 		subscriptionManager: subscriptionManager,
+		worker:              worker,
 		logger:              logger,
 	}
 }
 
 // PublishRequest represents a publish message request.
 type PublishRequest struct {
-	TopicCode  string                 `json:"topicCode"`
-	Identifier string                 `json:"identifier"`
-	Data       map[string]interface{} `json:"data"`
+	TopicCode   string                 `json:"topicCode"`
+	Identifier  string                 `json:"identifier"`
+	Data        map[string]interface{} `json:"data"`
+	OrderingKey string                 `json:"orderingKey,omitempty"`
+	Priority    int                    `json:"priority,omitempty"`
+
+	DeliverAt    *time.Time `json:"deliverAt,omitempty"`    // Scheduled first delivery (RFC 3339)
+	DelaySeconds int        `json:"delaySeconds,omitempty"` // Alternative to deliverAt
+	TTLSeconds   int        `json:"ttlSeconds,omitempty"`   // Overrides the topic's message TTL
 }
 
 // SubscribeRequest represents a subscription creation request.
@@ -45,6 +58,42 @@ type SubscribeRequest struct {
 	Identifier   string `json:"identifier"`
 }
 
+// PauseRequest represents a subscription pause request.
+// An empty body pauses until the subscription is resumed.
+type PauseRequest struct {
+	ResumeAt     *time.Time `json:"resumeAt,omitempty"`     // Automatic resume time (RFC 3339)
+	PauseSeconds int        `json:"pauseSeconds,omitempty"` // Alternative to resumeAt
+}
+
+// StatsResponse represents the worker statistics returned by the admin endpoint.
+// Durations are in milliseconds.
+type StatsResponse struct {
+	WorkerID            string                              `json:"workerID"`
+	Partitions          []int                               `json:"partitions,omitempty"`  // Owned partitions when partitioned
+	Leader              bool                                `json:"leader"`                // Runs maintenance tasks
+	LeaderSince         *time.Time                          `json:"leaderSince,omitempty"` // Omitted if not leader or no election
+	Delivered           int64                               `json:"delivered"`
+	Failed              int64                               `json:"failed"`
+	DeadLettered        int64                               `json:"deadLettered"`
+	Expired             int64                               `json:"expired"`
+	Purged              int64                               `json:"purged"` // Messages purged by retention
+	InFlight            int                                 `json:"inFlight"`
+	LastBatchAt         *time.Time                          `json:"lastBatchAt,omitempty"`
+	LastBatchDurationMs float64                             `json:"lastBatchDurationMs"`
+	AvgLatencyMs        float64                             `json:"avgLatencyMs"`
+	P95LatencyMs        float64                             `json:"p95LatencyMs"`
+	Subscriptions       map[int64]SubscriptionStatsResponse `json:"subscriptions"`
+	DLQ                 *model.DLQStats                     `json:"dlq,omitempty"` // Omitted if DLQ stats are unavailable
+}
+
+// SubscriptionStatsResponse represents the worker counters of a single subscription.
+type SubscriptionStatsResponse struct {
+	Delivered    int64 `json:"delivered"`
+	Failed       int64 `json:"failed"`
+	DeadLettered int64 `json:"deadLettered"`
+	Expired      int64 `json:"expired"`
+}
+
 // ErrorResponse represents an error response.
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -77,6 +126,28 @@ func (h *Handler) HandlePublish(w http.ResponseWriter, r *http.Request) {
 		h.respondError(w, http.StatusBadRequest, "topicCode is required", "VALIDATION_ERROR")
 		return
 	}
+	if req.Priority < 0 || req.Priority > model.MaxPriority {
+		h.respondError(w, http.StatusBadRequest, fmt.Sprintf("priority must be between 0 and %d", model.MaxPriority), "VALIDATION_ERROR")
+		return
+	}
+	if req.DelaySeconds < 0 || (req.DelaySeconds > 0 && req.DeliverAt != nil) {
+		h.respondError(w, http.StatusBadRequest, "delaySeconds must be >= 0 and cannot be combined with deliverAt", "VALIDATION_ERROR")
+		return
+	}
+	if req.TTLSeconds < 0 {
+		h.respondError(w, http.StatusBadRequest, "ttlSeconds must be >= 0", "VALIDATION_ERROR")
+		return
+	}
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if len(idempotencyKey) > pubsub.MaxIdempotencyKeyLength {
+		h.respondError(w, http.StatusBadRequest,
+			fmt.Sprintf("Idempotency-Key must be at most %d bytes", pubsub.MaxIdempotencyKeyLength), "VALIDATION_ERROR")
+		return
+	}
+	var deliverAt time.Time
+	if req.DeliverAt != nil {
+		deliverAt = *req.DeliverAt
+	}
 
 	// Convert data to JSON string
 	dataJSON, err := json.Marshal(req.Data)
@@ -87,20 +158,69 @@ func (h *Handler) HandlePublish(w http.ResponseWriter, r *http.Request) {
 
 	// Publish message
 	result, err := h.publisher.Publish(r.Context(), pubsub.PublishRequest{
-		TopicCode:  req.TopicCode,
-		Identifier: req.Identifier,
-		Data:       string(dataJSON),
+		TopicCode:   req.TopicCode,
+		Identifier:  req.Identifier,
+		Data:        string(dataJSON),
+		OrderingKey: req.OrderingKey,
+		Priority:    req.Priority,
+		DeliverAt:   deliverAt,
+		Delay:       time.Duration(req.DelaySeconds) * time.Second,
+		TTL:         time.Duration(req.TTLSeconds) * time.Second,
+
+		IdempotencyKey: idempotencyKey,
 	})
 
+	if errors.Is(err, pubsub.ErrQueueFull) {
+		h.respondError(w, http.StatusTooManyRequests, "Subscription queue is full", pubsub.ErrCodeQueueFull)
+		return
+	}
 	if err != nil {
 		h.logger.Errorf("Failed to publish message: %v", err)
 		h.respondError(w, http.StatusInternalServerError, "Failed to publish message", "PUBLISH_ERROR")
 		return
 	}
 
+	if result.Duplicate {
+		h.respondSuccess(w, http.StatusOK, result, "Message already published")
+		return
+	}
 	h.respondSuccess(w, http.StatusCreated, result, "Message published successfully")
 }
 
+// HandleCancelMessage handles DELETE /api/v1/messages/:id
+// It cancels the deliveries of a scheduled message that are not yet due.
+func (h *Handler) HandleCancelMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	pathParts := splitPath(r.URL.Path)
+	if len(pathParts) < 4 {
+		h.respondError(w, http.StatusBadRequest, "Invalid message ID", "INVALID_ID")
+		return
+	}
+
+	messageID, err := strconv.ParseInt(pathParts[3], 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid message ID", "INVALID_ID")
+		return
+	}
+
+	canceled, err := h.publisher.CancelScheduled(r.Context(), messageID)
+	if err != nil {
+		if pubsub.IsNoData(err) {
+			h.respondError(w, http.StatusNotFound, "No scheduled deliveries found", "NOT_FOUND")
+			return
+		}
+		h.logger.Errorf("Failed to cancel message: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "Failed to cancel message", "CANCEL_ERROR")
+		return
+	}
+
+	h.respondSuccess(w, http.StatusOK, map[string]int{"canceled": canceled}, "Scheduled message canceled")
+}
+
 // HandleSubscribe handles POST /api/v1/subscribe
 func (h *Handler) HandleSubscribe(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -198,6 +318,162 @@ func (h *Handler) HandleUnsubscribe(w http.ResponseWriter, r *http.Request) {
 	h.respondSuccess(w, http.StatusOK, subscription, "Unsubscribed successfully")
 }
 
+// HandlePauseSubscription handles POST /api/v1/subscriptions/:id/pause
+// Messages keep being queued for the subscription, but are not delivered until it is resumed.
+func (h *Handler) HandlePauseSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	subscriptionID, ok := h.subscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	var req PauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "Invalid JSON", "INVALID_JSON")
+		return
+	}
+
+	// Validate request
+	if req.PauseSeconds < 0 || (req.PauseSeconds > 0 && req.ResumeAt != nil) {
+		h.respondError(w, http.StatusBadRequest, "pauseSeconds must be >= 0 and cannot be combined with resumeAt", "VALIDATION_ERROR")
+		return
+	}
+	var resumeAt time.Time
+	if req.ResumeAt != nil {
+		resumeAt = *req.ResumeAt
+	} else if req.PauseSeconds > 0 {
+		resumeAt = time.Now().Add(time.Duration(req.PauseSeconds) * time.Second)
+	}
+	if !resumeAt.IsZero() && !resumeAt.After(time.Now()) {
+		h.respondError(w, http.StatusBadRequest, "resumeAt must be in the future", "VALIDATION_ERROR")
+		return
+	}
+
+	subscription, err := h.subscriptionManager.PauseSubscription(r.Context(), subscriptionID, resumeAt)
+	if err != nil {
+		if errors.Is(err, pubsub.ErrNoData) { // Wrapped in a validation error
+			h.respondError(w, http.StatusNotFound, "Subscription not found", "NOT_FOUND")
+			return
+		}
+		h.logger.Errorf("Failed to pause subscription: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "Failed to pause subscription", "PAUSE_ERROR")
+		return
+	}
+
+	h.respondSuccess(w, http.StatusOK, subscription, "Subscription paused")
+}
+
+// HandleResumeSubscription handles POST /api/v1/subscriptions/:id/resume
+// Messages queued while the subscription was paused are delivered right away.
+func (h *Handler) HandleResumeSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	subscriptionID, ok := h.subscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.subscriptionManager.ResumeSubscription(r.Context(), subscriptionID)
+	if err != nil {
+		if errors.Is(err, pubsub.ErrNoData) { // Wrapped in a validation error
+			h.respondError(w, http.StatusNotFound, "Subscription not found", "NOT_FOUND")
+			return
+		}
+		h.logger.Errorf("Failed to resume subscription: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "Failed to resume subscription", "RESUME_ERROR")
+		return
+	}
+
+	h.respondSuccess(w, http.StatusOK, subscription, "Subscription resumed")
+}
+
+// subscriptionID parses the subscription ID from /api/v1/subscriptions/:id/...
+// Responds with 400 and returns false if the ID is invalid.
+func (h *Handler) subscriptionID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	pathParts := splitPath(r.URL.Path)
+	if len(pathParts) < 4 {
+		h.respondError(w, http.StatusBadRequest, "Invalid subscription ID", "INVALID_ID")
+		return 0, false
+	}
+
+	subscriptionID, err := strconv.ParseInt(pathParts[3], 10, 64)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid subscription ID", "INVALID_ID")
+		return 0, false
+	}
+	return subscriptionID, true
+}
+
+// RequireToken wraps an admin handler so it only serves requests with
+// an "Authorization: Bearer <token>" header.
+func (h *Handler) RequireToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			h.respondError(w, http.StatusUnauthorized, "Unauthorized", "UNAUTHORIZED")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// HandleStats handles GET /api/v1/admin/stats
+// It reports the queue worker's runtime statistics and DLQ statistics.
+func (h *Handler) HandleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	stats := h.worker.Stats()
+	resp := StatsResponse{
+		WorkerID:            stats.WorkerID,
+		Partitions:          stats.Partitions,
+		Leader:              stats.Leader,
+		Delivered:           stats.Delivered,
+		Failed:              stats.Failed,
+		DeadLettered:        stats.DeadLettered,
+		Expired:             stats.Expired,
+		Purged:              stats.Purged,
+		InFlight:            stats.InFlight,
+		LastBatchDurationMs: milliseconds(stats.LastBatchDuration),
+		AvgLatencyMs:        milliseconds(stats.AvgLatency),
+		P95LatencyMs:        milliseconds(stats.P95Latency),
+		Subscriptions:       make(map[int64]SubscriptionStatsResponse, len(stats.Subscriptions)),
+	}
+	if !stats.LastBatchAt.IsZero() {
+		resp.LastBatchAt = &stats.LastBatchAt
+	}
+	if !stats.LeaderSince.IsZero() {
+		resp.LeaderSince = &stats.LeaderSince
+	}
+	for id, counters := range stats.Subscriptions {
+		resp.Subscriptions[id] = SubscriptionStatsResponse(counters)
+	}
+
+	dlqStats, err := h.worker.GetDLQStats(r.Context())
+	if err != nil {
+		h.logger.Warnf("Failed to load DLQ stats: %v", err)
+	} else {
+		resp.DLQ = &dlqStats
+	}
+
+	h.respondSuccess(w, http.StatusOK, resp, "")
+}
+
+// milliseconds converts d to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
 // HandleHealth handles GET /api/v1/health
 func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -36,8 +36,41 @@ type DatabaseConfig struct {
 // PubSubConfig holds PubSub-specific configuration.
 type PubSubConfig struct {
 	BatchSize           int  // Worker batch size
+	Concurrency         int  // Parallel deliveries per batch
 	WorkerInterval      int  // Worker interval in seconds
+	MaxWorkerInterval   int  // Max idle poll interval in seconds for adaptive polling (0 = fixed interval)
 	EnableNotifications bool // Enable notification service
+	ListenNotify        bool // Wake workers of all replicas via LISTEN/NOTIFY (requires the postgres driver)
+
+	WorkerID      string // Lease owner name of this replica (empty = generated)
+	LeaseDuration int    // Seconds claimed queue items are reserved for this replica
+
+	Partitions        int // Subscription partitions split among replicas (0 = disabled)
+	HeartbeatInterval int // Seconds between replica heartbeats when partitioned
+
+	LeaderLeaseDuration int // Seconds a replica leads maintenance tasks without renewal (0 = every replica runs them)
+
+	RetentionInterval         int  // Seconds between message retention runs (0 = disabled)
+	RetentionBatchSize        int  // Messages purged per transaction
+	RetentionPurgeResolvedDLQ bool // Also purge resolved DLQ entries of purged messages
+
+	MaxQueueDepth  int    // Max undelivered queue items per subscription (0 = unlimited)
+	OverflowPolicy string // What happens to messages for full queues: reject, drop_oldest or dead_letter
+	QueueHighWater int    // Backlog percentage of the max depth that triggers a notification
+
+	IdempotencyWindow int // Seconds an Idempotency-Key deduplicates publishes
+
+	AdminToken string // Bearer token for the admin endpoints (empty = admin endpoints disabled)
+
+	DeliveryTimeout int    // Webhook request timeout in seconds
+	SigningSecret   string // HMAC secret for signing webhook requests (empty = unsigned)
+	MaxBodySize     int    // Maximum webhook payload size in bytes (0 = unlimited)
+
+	CircuitBreakerThreshold    int // Consecutive failures that open a subscriber's circuit (0 = disabled)
+	CircuitBreakerOpenDuration int // Seconds a circuit stays open before a probe delivery
+
+	HostRateLimit float64 // Max deliveries per second per destination host (0 = unlimited)
+	HostRateBurst int     // Deliveries allowed at once per host (0 = derived from HostRateLimit)
 }
 
 // Load loads configuration from environment variables.
@@ -59,8 +92,40 @@ func Load() (*Config, error) {
 		},
 		PubSub: PubSubConfig{
 			BatchSize:           getEnvInt("PUBSUB_BATCH_SIZE", 100),
+			Concurrency:         getEnvInt("PUBSUB_CONCURRENCY", 1),
 			WorkerInterval:      getEnvInt("PUBSUB_WORKER_INTERVAL", 30),
+			MaxWorkerInterval:   getEnvInt("PUBSUB_MAX_WORKER_INTERVAL", 0),
 			EnableNotifications: getEnvBool("PUBSUB_ENABLE_NOTIFICATIONS", true),
+			ListenNotify:        getEnvBool("PUBSUB_LISTEN_NOTIFY", false),
+			DeliveryTimeout:     getEnvInt("PUBSUB_DELIVERY_TIMEOUT", 10),
+			SigningSecret:       getEnv("PUBSUB_SIGNING_SECRET", ""),
+			MaxBodySize:         getEnvInt("PUBSUB_MAX_BODY_SIZE", 1048576),
+
+			WorkerID:      getEnv("PUBSUB_WORKER_ID", ""),
+			LeaseDuration: getEnvInt("PUBSUB_LEASE_DURATION", 300),
+
+			Partitions:        getEnvInt("PUBSUB_PARTITIONS", 0),
+			HeartbeatInterval: getEnvInt("PUBSUB_HEARTBEAT_INTERVAL", 10),
+
+			LeaderLeaseDuration: getEnvInt("PUBSUB_LEADER_LEASE_DURATION", 0),
+
+			RetentionInterval:         getEnvInt("PUBSUB_RETENTION_INTERVAL", 0),
+			RetentionBatchSize:        getEnvInt("PUBSUB_RETENTION_BATCH_SIZE", 500),
+			RetentionPurgeResolvedDLQ: getEnvBool("PUBSUB_RETENTION_PURGE_RESOLVED_DLQ", false),
+
+			MaxQueueDepth:  getEnvInt("PUBSUB_MAX_QUEUE_DEPTH", 0),
+			OverflowPolicy: getEnv("PUBSUB_OVERFLOW_POLICY", "reject"),
+			QueueHighWater: getEnvInt("PUBSUB_QUEUE_HIGH_WATER", 80),
+
+			IdempotencyWindow: getEnvInt("PUBSUB_IDEMPOTENCY_WINDOW", 86400),
+
+			AdminToken: getEnv("PUBSUB_ADMIN_TOKEN", ""),
+
+			CircuitBreakerThreshold:    getEnvInt("PUBSUB_CIRCUIT_BREAKER_THRESHOLD", 0),
+			CircuitBreakerOpenDuration: getEnvInt("PUBSUB_CIRCUIT_BREAKER_OPEN_DURATION", 60),
+
+			HostRateLimit: getEnvFloat("PUBSUB_HOST_RATE_LIMIT", 0),
+			HostRateBurst: getEnvInt("PUBSUB_HOST_RATE_BURST", 0),
 		},
 	}
 
@@ -68,6 +133,59 @@ func Load() (*Config, error) {
 	if cfg.Database.Password == "" {
 		return nil, fmt.Errorf("DB_PASSWORD environment variable is required")
 	}
+	if cfg.PubSub.Concurrency <= 0 {
+		return nil, fmt.Errorf("PUBSUB_CONCURRENCY must be > 0, got %d", cfg.PubSub.Concurrency)
+	}
+	if cfg.PubSub.ListenNotify && cfg.Database.Driver != "postgres" {
+		return nil, fmt.Errorf("PUBSUB_LISTEN_NOTIFY requires DB_DRIVER=postgres, got %q", cfg.Database.Driver)
+	}
+	if cfg.PubSub.MaxWorkerInterval < 0 {
+		return nil, fmt.Errorf("PUBSUB_MAX_WORKER_INTERVAL must be >= 0, got %d", cfg.PubSub.MaxWorkerInterval)
+	}
+	if cfg.PubSub.LeaseDuration <= 0 {
+		return nil, fmt.Errorf("PUBSUB_LEASE_DURATION must be > 0, got %d", cfg.PubSub.LeaseDuration)
+	}
+	if cfg.PubSub.Partitions < 0 {
+		return nil, fmt.Errorf("PUBSUB_PARTITIONS must be >= 0, got %d", cfg.PubSub.Partitions)
+	}
+	if cfg.PubSub.HeartbeatInterval <= 0 {
+		return nil, fmt.Errorf("PUBSUB_HEARTBEAT_INTERVAL must be > 0, got %d", cfg.PubSub.HeartbeatInterval)
+	}
+	if cfg.PubSub.LeaderLeaseDuration < 0 {
+		return nil, fmt.Errorf("PUBSUB_LEADER_LEASE_DURATION must be >= 0, got %d", cfg.PubSub.LeaderLeaseDuration)
+	}
+	if cfg.PubSub.RetentionInterval < 0 {
+		return nil, fmt.Errorf("PUBSUB_RETENTION_INTERVAL must be >= 0, got %d", cfg.PubSub.RetentionInterval)
+	}
+	if cfg.PubSub.RetentionBatchSize <= 0 {
+		return nil, fmt.Errorf("PUBSUB_RETENTION_BATCH_SIZE must be > 0, got %d", cfg.PubSub.RetentionBatchSize)
+	}
+	if cfg.PubSub.MaxQueueDepth < 0 {
+		return nil, fmt.Errorf("PUBSUB_MAX_QUEUE_DEPTH must be >= 0, got %d", cfg.PubSub.MaxQueueDepth)
+	}
+	switch cfg.PubSub.OverflowPolicy {
+	case "reject", "drop_oldest", "dead_letter":
+	default:
+		return nil, fmt.Errorf("PUBSUB_OVERFLOW_POLICY must be reject, drop_oldest or dead_letter, got %q", cfg.PubSub.OverflowPolicy)
+	}
+	if cfg.PubSub.QueueHighWater <= 0 || cfg.PubSub.QueueHighWater > 100 {
+		return nil, fmt.Errorf("PUBSUB_QUEUE_HIGH_WATER must be between 1 and 100, got %d", cfg.PubSub.QueueHighWater)
+	}
+	if cfg.PubSub.IdempotencyWindow <= 0 {
+		return nil, fmt.Errorf("PUBSUB_IDEMPOTENCY_WINDOW must be > 0, got %d", cfg.PubSub.IdempotencyWindow)
+	}
+	if cfg.PubSub.DeliveryTimeout <= 0 {
+		return nil, fmt.Errorf("PUBSUB_DELIVERY_TIMEOUT must be > 0, got %d", cfg.PubSub.DeliveryTimeout)
+	}
+	if cfg.PubSub.MaxBodySize < 0 {
+		return nil, fmt.Errorf("PUBSUB_MAX_BODY_SIZE must be >= 0, got %d", cfg.PubSub.MaxBodySize)
+	}
+	if cfg.PubSub.CircuitBreakerThreshold < 0 {
+		return nil, fmt.Errorf("PUBSUB_CIRCUIT_BREAKER_THRESHOLD must be >= 0, got %d", cfg.PubSub.CircuitBreakerThreshold)
+	}
+	if cfg.PubSub.HostRateLimit < 0 || cfg.PubSub.HostRateBurst < 0 {
+		return nil, fmt.Errorf("PUBSUB_HOST_RATE_LIMIT and PUBSUB_HOST_RATE_BURST must be >= 0")
+	}
 
 	return cfg, nil
 }
@@ -106,6 +224,16 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvFloat retrieves environment variable as float or returns default value.
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
 // getEnvBool retrieves environment variable as boolean or returns default value.
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
@@ -12,9 +12,8 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/adapters/postgres"
 	"github.com/coregx/pubsub/adapters/relica"
-	"github.com/coregx/pubsub/cmd/pubsub-server/internal/api"
 	"github.com/coregx/pubsub/cmd/pubsub-server/internal/config"
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
@@ -54,6 +53,7 @@ func main() {
 	log.Printf("   Database: %s (%s:%d)", cfg.Database.Driver, cfg.Database.Host, cfg.Database.Port)
 	log.Printf("   Worker batch size: %d", cfg.PubSub.BatchSize)
 	log.Printf("   Worker interval: %ds", cfg.PubSub.WorkerInterval)
+	log.Printf("   Delivery timeout: %ds (signed: %t)", cfg.PubSub.DeliveryTimeout, cfg.PubSub.SigningSecret != "")
 
 	// Connect to database
 	db, err := sql.Open(cfg.Database.Driver, cfg.Database.GetDSN())
@@ -84,72 +84,36 @@ func main() {
 	}
 	log.Println("✅ Repositories initialized (Relica adapters)")
 
-	// Create notification service
-	var notificationService pubsub.NotificationService
-	if cfg.PubSub.EnableNotifications {
-		notificationService = pubsub.NewLoggingNotificationService(logger)
-	} else {
-		notificationService = &pubsub.NoOpNotificationService{}
-	}
-
-	// Create Publisher service
-	publisher, err := pubsub.NewPublisher(
-		pubsub.WithPublisherRepositories(repos.Message, repos.Queue, repos.Subscription, repos.Topic),
-		pubsub.WithPublisherLogger(logger),
-	)
-	if err != nil {
-		log.Fatalf("Failed to create publisher: %v", err)
+	// Wire services: Publisher, SubscriptionManager and QueueWorker with webhook delivery
+	// Wake workers of all replicas on publish via LISTEN/NOTIFY (PostgreSQL only, checked by config.Load)
+	var wake wakeup
+	if cfg.PubSub.ListenNotify {
+		waker, err := postgres.NewWaker(db, cfg.Database.GetDSN(), postgres.DefaultChannel)
+		if err != nil {
+			log.Fatalf("Failed to listen for queue notifications: %v", err)
+		}
+		defer func() { _ = waker.Close() }()
+		wake = waker
+		log.Println("✅ Listening for queue notifications (LISTEN/NOTIFY)")
 	}
-	log.Println("✅ Publisher service created")
 
-	// Create SubscriptionManager service
-	subscriptionManager, err := pubsub.NewSubscriptionManager(
-		pubsub.WithSubscriptionManagerRepositories(repos.Subscription, repos.Subscriber, repos.Topic),
-		pubsub.WithSubscriptionManagerLogger(logger),
-	)
+	application, err := newApp(repos, cfg.PubSub, logger, wake)
 	if err != nil {
-		log.Fatalf("Failed to create subscription manager: %v", err)
+		log.Fatalf("Failed to create services: %v", err)
 	}
-	log.Println("✅ SubscriptionManager service created")
-
-	// Create QueueWorker
-	worker, err := pubsub.NewQueueWorker(
-		pubsub.WithRepositories(repos.Queue, repos.Message, repos.Subscription, repos.DLQ),
-		pubsub.WithDelivery(nil, nil), // TODO: implement delivery provider
-		pubsub.WithLogger(logger),
-		pubsub.WithBatchSize(cfg.PubSub.BatchSize),
-		pubsub.WithNotifications(notificationService),
-	)
-	if err != nil {
-		log.Fatalf("Failed to create worker: %v", err)
-	}
-	log.Println("✅ QueueWorker created")
+	log.Println("✅ Services created (Publisher, SubscriptionManager, QueueWorker)")
 
 	// Start worker in background
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	go func() {
-		log.Printf("🔄 Starting queue worker (interval: %ds)...", cfg.PubSub.WorkerInterval)
-		worker.Run(ctx, time.Duration(cfg.PubSub.WorkerInterval)*time.Second)
-	}()
-
-	// Create API handler
-	handler := api.NewHandler(publisher, subscriptionManager, logger)
-
-	// Setup HTTP routes
-	mux := http.NewServeMux()
-	mux.HandleFunc("/api/v1/publish", handler.HandlePublish)
-	mux.HandleFunc("/api/v1/subscribe", handler.HandleSubscribe)
-	mux.HandleFunc("/api/v1/subscriptions", handler.HandleListSubscriptions)
-	mux.HandleFunc("/api/v1/subscriptions/", handler.HandleUnsubscribe) // Note trailing slash for :id
-	mux.HandleFunc("/api/v1/health", handler.HandleHealth)
+	log.Printf("🔄 Starting queue worker (interval: %ds)...", cfg.PubSub.WorkerInterval)
+	if err := application.worker.Start(time.Duration(cfg.PubSub.WorkerInterval) * time.Second); err != nil {
+		log.Fatalf("Failed to start queue worker: %v", err)
+	}
 
 	// Create HTTP server
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	server := &http.Server{
 		Addr:         addr,
-		Handler:      loggingMiddleware(mux, logger),
+		Handler:      application.handler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -160,10 +124,16 @@ func main() {
 		log.Printf("🌐 HTTP server listening on %s", addr)
 		log.Println("📡 API Endpoints:")
 		log.Println("   POST   /api/v1/publish")
+		log.Println("   DELETE /api/v1/messages/:id")
 		log.Println("   POST   /api/v1/subscribe")
 		log.Println("   GET    /api/v1/subscriptions")
 		log.Println("   DELETE /api/v1/subscriptions/:id")
+		log.Println("   POST   /api/v1/subscriptions/:id/pause")
+		log.Println("   POST   /api/v1/subscriptions/:id/resume")
 		log.Println("   GET    /api/v1/health")
+		if cfg.PubSub.AdminToken != "" {
+			log.Println("   GET    /api/v1/admin/stats (bearer token)")
+		}
 		log.Println()
 		log.Println("✅ PubSub Server is ready!")
 
@@ -187,16 +157,13 @@ func main() {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
-	cancel() // Stop worker
-	log.Println("✅ Server stopped gracefully")
-}
+	// Drain in-flight deliveries; interrupted ones are rescheduled for other replicas
+	abandoned, err := application.worker.Stop(shutdownCtx)
+	if err != nil {
+		log.Printf("Queue worker forced to stop: %v (abandoned deliveries: %d)", err, abandoned)
+	} else {
+		log.Println("✅ Queue worker stopped")
+	}
 
-// loggingMiddleware logs HTTP requests.
-func loggingMiddleware(next http.Handler, logger pubsub.Logger) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		logger.Infof("%s %s", r.Method, r.URL.Path)
-		next.ServeHTTP(w, r)
-		logger.Debugf("%s %s - %v", r.Method, r.URL.Path, time.Since(start))
-	})
+	log.Println("✅ Server stopped gracefully")
 }
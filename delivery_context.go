@@ -0,0 +1,33 @@
+package pubsub
+
+import "context"
+
+// DeliveryInfo describes the queue item a MessageDeliveryGateway is currently delivering.
+//
+// QueueWorker attaches it to the context passed to DeliverMessage, so gateways can
+// apply per-subscriber behavior (signing secrets, headers, metrics) without changing
+// the MessageDeliveryGateway interface.
+type DeliveryInfo struct {
+	QueueID        int64 // Queue item being delivered
+	MessageID      int64 // Message being delivered
+	SubscriptionID int64 // Subscription the queue item belongs to
+	SubscriberID   int64 // Subscriber owning the subscription
+	Attempt        int   // 1-based number of the attempt in progress
+}
+
+// deliveryInfoKey is the context key for DeliveryInfo.
+type deliveryInfoKey struct{}
+
+// ContextWithDeliveryInfo returns a copy of ctx carrying the delivery info.
+// QueueWorker calls this before every delivery attempt.
+func ContextWithDeliveryInfo(ctx context.Context, info DeliveryInfo) context.Context {
+	return context.WithValue(ctx, deliveryInfoKey{}, info)
+}
+
+// DeliveryInfoFromContext returns the delivery info attached by QueueWorker.
+// The second return value is false when the context carries no delivery info,
+// e.g. when a gateway is called directly.
+func DeliveryInfoFromContext(ctx context.Context) (DeliveryInfo, bool) {
+	info, ok := ctx.Value(deliveryInfoKey{}).(DeliveryInfo)
+	return info, ok
+}
@@ -0,0 +1,106 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DeliveryError describes a failed delivery attempt in a way QueueWorker can act on.
+//
+// MessageDeliveryGateway implementations should return (or wrap) a *DeliveryError
+// so the worker can distinguish failures that will never succeed from temporary ones:
+//   - Retryable=false: the item is moved to the Dead Letter Queue immediately
+//   - Retryable=true: the item is retried with backoff until the DLQ threshold
+//
+// Errors that are not a *DeliveryError are treated as transient.
+//
+// Example:
+//
+//	if resp.StatusCode == http.StatusGone {
+//	    return pubsub.NewPermanentDeliveryError(resp.StatusCode, "endpoint gone", nil)
+//	}
+type DeliveryError struct {
+	// StatusCode is the HTTP (or protocol) status code returned by the subscriber, 0 if none.
+	StatusCode int
+
+	// Retryable reports whether a later attempt may succeed.
+	Retryable bool
+
+	// Reason is a short human-readable description, used as the DLQ failure reason.
+	Reason string
+
+	// RetryAfter is the delay requested by the subscriber before the next attempt
+	// (e.g. an HTTP Retry-After header). Only used when HasRetryAfter is true.
+	// QueueWorker uses it instead of the backoff schedule, capped at Strategy.MaxDelay.
+	RetryAfter time.Duration
+
+	// HasRetryAfter reports whether the subscriber supplied a retry delay.
+	HasRetryAfter bool
+
+	// Err is the underlying error (if any).
+	Err error
+}
+
+// NewPermanentDeliveryError creates a DeliveryError that must not be retried.
+func NewPermanentDeliveryError(statusCode int, reason string, cause error) *DeliveryError {
+	return &DeliveryError{
+		StatusCode: statusCode,
+		Retryable:  false,
+		Reason:     reason,
+		Err:        cause,
+	}
+}
+
+// NewTransientDeliveryError creates a DeliveryError that may succeed on retry.
+func NewTransientDeliveryError(statusCode int, reason string, cause error) *DeliveryError {
+	return &DeliveryError{
+		StatusCode: statusCode,
+		Retryable:  true,
+		Reason:     reason,
+		Err:        cause,
+	}
+}
+
+// Error implements the error interface.
+func (e *DeliveryError) Error() string {
+	kind := "transient"
+	if !e.Retryable {
+		kind = "permanent"
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%s delivery failure: %s: %v", kind, e.Reason, e.Err)
+	}
+	return fmt.Sprintf("%s delivery failure: %s", kind, e.Reason)
+}
+
+// Unwrap returns the underlying error.
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// WithRetryAfter sets the delay requested by the subscriber and returns the error.
+func (e *DeliveryError) WithRetryAfter(delay time.Duration) *DeliveryError {
+	e.RetryAfter = delay
+	e.HasRetryAfter = true
+	return e
+}
+
+// IsPermanentDeliveryError reports whether err is (or wraps) a non-retryable DeliveryError.
+func IsPermanentDeliveryError(err error) bool {
+	var deliveryErr *DeliveryError
+	if errors.As(err, &deliveryErr) {
+		return !deliveryErr.Retryable
+	}
+	return false
+}
+
+// RetryAfterFromError returns the retry delay requested by the subscriber, if err is
+// (or wraps) a retryable DeliveryError carrying one.
+func RetryAfterFromError(err error) (time.Duration, bool) {
+	var deliveryErr *DeliveryError
+	if errors.As(err, &deliveryErr) && deliveryErr.Retryable && deliveryErr.HasRetryAfter {
+		return deliveryErr.RetryAfter, true
+	}
+	return 0, false
+}
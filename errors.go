@@ -46,6 +46,9 @@ const (
 
 	// ErrCodeDelivery indicates message delivery failed.
 	ErrCodeDelivery = "DELIVERY_ERROR"
+
+	// ErrCodeQueueFull indicates a subscription's queue reached its maximum depth.
+	ErrCodeQueueFull = "QUEUE_FULL"
 )
 
 // Common errors.
@@ -62,6 +65,19 @@ var (
 		Code:    ErrCodeConfiguration,
 		Message: "invalid worker configuration",
 	}
+
+	// ErrQueueFull is returned (wrapped) by Publisher.Publish when a subscription's queue
+	// is full and the overflow policy is OverflowReject (see WithBackpressure).
+	ErrQueueFull = &Error{
+		Code:    ErrCodeQueueFull,
+		Message: "subscription queue is full",
+	}
+
+	// ErrWorkerAlreadyStarted is returned by QueueWorker.Start when the worker is already running.
+	ErrWorkerAlreadyStarted = &Error{
+		Code:    ErrCodeConfiguration,
+		Message: "queue worker already started",
+	}
 )
 
 // NewError creates a new Error with the given code and message.
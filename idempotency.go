@@ -0,0 +1,93 @@
+package pubsub
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/coregx/pubsub/model"
+)
+
+// DefaultIdempotencyWindow is how long an idempotency key deduplicates publishes
+// when WithIdempotencyWindow is not set.
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+// MaxIdempotencyKeyLength is the maximum length of PublishRequest.IdempotencyKey in bytes.
+const MaxIdempotencyKeyLength = 255
+
+// findDuplicate returns the result of the message published with the idempotency key on the
+// topic within the idempotency window, or nil if there is none. A message whose window has
+// passed gives up its key, so that the key can be published again.
+// Reads and writes go to tx through the outbox if tx is not nil.
+func (p *Publisher) findDuplicate(ctx context.Context, tx *sql.Tx, topicID int64, key string) (*PublishResult, error) {
+	message, err := p.findByIdempotencyKey(ctx, tx, topicID, key)
+	if IsNoData(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, NewErrorWithCause(ErrCodeDatabase, "failed to find message by idempotency key", err)
+	}
+
+	if time.Since(message.CreatedAt) >= p.idempotencyWindow {
+		if err := p.releaseIdempotencyKey(ctx, tx, message.ID); err != nil {
+			return nil, NewErrorWithCause(ErrCodeDatabase, "failed to release idempotency key", err)
+		}
+		p.logger.Debugf("Idempotency key %q of message %d expired", key, message.ID)
+		return nil, nil
+	}
+
+	subscriptionIDs, err := p.queuedSubscriptionIDs(ctx, tx, message.ID)
+	if err != nil {
+		return nil, NewErrorWithCause(ErrCodeDatabase, "failed to load queue items of duplicate message", err)
+	}
+
+	p.logger.Infof("Duplicate publish with idempotency key %q: returning message %d", key, message.ID)
+	return duplicateResult(message, subscriptionIDs), nil
+}
+
+// findByIdempotencyKey finds the message holding the key through the outbox if tx is not nil,
+// else through the repository.
+func (p *Publisher) findByIdempotencyKey(ctx context.Context, tx *sql.Tx, topicID int64, key string) (model.Message, error) {
+	if tx != nil {
+		return p.outbox.FindByIdempotencyKey(ctx, tx, topicID, key)
+	}
+	return p.messageRepo.FindByIdempotencyKey(ctx, topicID, key)
+}
+
+// queuedSubscriptionIDs returns the subscriptions a message was queued for through the outbox
+// if tx is not nil, else through the repository.
+func (p *Publisher) queuedSubscriptionIDs(ctx context.Context, tx *sql.Tx, messageID int64) ([]int64, error) {
+	if tx != nil {
+		return p.outbox.FindSubscriptionIDs(ctx, tx, messageID)
+	}
+
+	items, err := p.queueRepo.FindAllByMessageID(ctx, messageID)
+	if err != nil && !IsNoData(err) {
+		return nil, err
+	}
+	subscriptionIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		subscriptionIDs = append(subscriptionIDs, item.SubscriptionID)
+	}
+	return subscriptionIDs, nil
+}
+
+// releaseIdempotencyKey clears the key of a message through the outbox if tx is not nil,
+// else through the repository, so a rolled back PublishTx keeps the key in place.
+func (p *Publisher) releaseIdempotencyKey(ctx context.Context, tx *sql.Tx, messageID int64) error {
+	if tx != nil {
+		return p.outbox.ReleaseIdempotencyKey(ctx, tx, messageID)
+	}
+	return p.messageRepo.ReleaseIdempotencyKey(ctx, messageID)
+}
+
+// duplicateResult rebuilds the PublishResult of an earlier publish from the subscriptions
+// it was queued for. Items removed since (dropped overflow, purged messages) are no longer reported.
+func duplicateResult(message model.Message, subscriptionIDs []int64) *PublishResult {
+	return &PublishResult{
+		MessageID:         message.ID,
+		QueueItemsCreated: len(subscriptionIDs),
+		SubscriptionsIDs:  subscriptionIDs,
+		Duplicate:         true,
+	}
+}
@@ -0,0 +1,109 @@
+package pubsub_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/adapters/relica"
+	"github.com/coregx/pubsub/internal/sqlitetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expire moves a message out of the idempotency window.
+func (f *publisherFixture) expire(t *testing.T, messageID int64) {
+	t.Helper()
+	_, err := f.db.Exec("UPDATE pubsub_message SET created_at = ? WHERE id = ?",
+		time.Now().Add(-pubsub.DefaultIdempotencyWindow-time.Minute), messageID)
+	require.NoError(t, err)
+}
+
+// idempotencyKey returns the idempotency key of a message ("" if released).
+func (f *publisherFixture) idempotencyKey(t *testing.T, messageID int64) string {
+	t.Helper()
+	message, err := f.repos.Message.Load(context.Background(), messageID)
+	require.NoError(t, err)
+	return message.IdempotencyKey.String
+}
+
+func TestPublisher_IdempotencyWindowExpiry(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+	publisher := f.publisher(t)
+	req := f.request()
+	req.IdempotencyKey = "order-42"
+
+	original, err := publisher.Publish(ctx, req)
+	require.NoError(t, err)
+	duplicate, err := publisher.Publish(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, duplicate.Duplicate)
+	assert.Equal(t, original.MessageID, duplicate.MessageID)
+
+	// The unique (topic_id, idempotency_key) index only admits the new message once the key is released
+	f.expire(t, original.MessageID)
+	republished, err := publisher.Publish(ctx, req)
+	require.NoError(t, err)
+	assert.False(t, republished.Duplicate)
+	assert.NotEqual(t, original.MessageID, republished.MessageID)
+	assert.Empty(t, f.idempotencyKey(t, original.MessageID), "expired key released")
+	assert.Equal(t, "order-42", f.idempotencyKey(t, republished.MessageID))
+}
+
+func TestPublisher_IdempotencyWindowExpiryTx(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+	publisher := f.publisher(t, pubsub.WithOutbox(relica.NewOutboxRepository(sqlitetest.DriverName)))
+	req := f.request()
+	req.IdempotencyKey = "order-42"
+
+	original, err := publisher.Publish(ctx, req)
+	require.NoError(t, err)
+	f.expire(t, original.MessageID)
+
+	publishTx := func(commit bool) *pubsub.PublishResult {
+		tx, err := f.db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		result, err := publisher.PublishTx(ctx, tx, req)
+		require.NoError(t, err)
+		if commit {
+			require.NoError(t, tx.Commit())
+		} else {
+			require.NoError(t, tx.Rollback())
+		}
+		return result
+	}
+
+	// The key is released within the caller's transaction
+	publishTx(false)
+	assert.Equal(t, "order-42", f.idempotencyKey(t, original.MessageID), "rolled back with the publish")
+
+	republished := publishTx(true)
+	assert.False(t, republished.Duplicate)
+	assert.Empty(t, f.idempotencyKey(t, original.MessageID))
+	assert.Equal(t, "order-42", f.idempotencyKey(t, republished.MessageID))
+}
+
+func TestPublisher_IdempotencyKeyWithinTx(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+	publisher := f.publisher(t, pubsub.WithOutbox(relica.NewOutboxRepository(sqlitetest.DriverName)))
+	req := f.request()
+	req.IdempotencyKey = "order-42"
+
+	tx, err := f.db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	// The retry sees the uncommitted message of the first publish
+	original, err := publisher.PublishTx(ctx, tx, req)
+	require.NoError(t, err)
+	retried, err := publisher.PublishTx(ctx, tx, req)
+	require.NoError(t, err)
+	assert.True(t, retried.Duplicate)
+	assert.Equal(t, original.MessageID, retried.MessageID)
+	assert.Equal(t, original.SubscriptionsIDs, retried.SubscriptionsIDs)
+	assert.Equal(t, 1, retried.QueueItemsCreated)
+}
@@ -0,0 +1,123 @@
+package pubsub_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordInterceptor records its name on every delivery before calling next.
+func recordInterceptor(calls *[]string, name string) pubsub.DeliveryInterceptor {
+	return func(next pubsub.MessageDeliveryGateway) pubsub.MessageDeliveryGateway {
+		return pubsub.DeliveryGatewayFunc(func(ctx context.Context, callbackURL string, message *model.DataMessage) error {
+			*calls = append(*calls, name)
+			return next.DeliverMessage(ctx, callbackURL, message)
+		})
+	}
+}
+
+func TestQueueWorker_InterceptorOrder(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+
+	var calls []string
+	gateway := deliveryFunc(func(context.Context, string, *model.DataMessage) error {
+		calls = append(calls, "gateway")
+		return nil
+	})
+	worker := f.worker(t, gateway,
+		pubsub.WithInterceptors(recordInterceptor(&calls, "first"), recordInterceptor(&calls, "second")),
+		pubsub.WithInterceptors(recordInterceptor(&calls, "appended")),
+	)
+
+	_, err := f.publisher(t).Publish(ctx, f.request())
+	require.NoError(t, err)
+	processed, err := worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, []string{"first", "second", "appended", "gateway"}, calls)
+}
+
+func TestQueueWorker_InterceptDelivery(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+
+	delivered := 0
+	gateway := deliveryFunc(func(context.Context, string, *model.DataMessage) error {
+		delivered++
+		return nil
+	})
+	var deliveries []pubsub.Delivery
+	shortCircuit := false
+	worker := f.worker(t, gateway, pubsub.WithInterceptors(pubsub.InterceptDelivery(
+		func(ctx context.Context, d pubsub.Delivery, next pubsub.MessageDeliveryGateway) error {
+			deliveries = append(deliveries, d)
+			if shortCircuit {
+				return nil
+			}
+			return next.DeliverMessage(ctx, d.CallbackURL, d.Message)
+		})))
+	publisher := f.publisher(t)
+
+	published, err := publisher.Publish(ctx, f.request())
+	require.NoError(t, err)
+	_, err = worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+
+	// The hook sees the worker's queue item and subscription
+	require.Len(t, deliveries, 1)
+	d := deliveries[0]
+	require.NotNil(t, d.Item)
+	assert.Equal(t, published.MessageID, d.Item.MessageID)
+	require.NotNil(t, d.Subscription)
+	assert.Equal(t, f.subscription.Identifier, d.Subscription.Identifier)
+	assert.Equal(t, "http://127.0.0.1:1/unused", d.CallbackURL)
+	assert.Equal(t, 1, delivered)
+
+	// Short-circuited deliveries succeed without reaching the gateway
+	shortCircuit = true
+	published, err = publisher.Publish(ctx, f.request())
+	require.NoError(t, err)
+	processed, err := worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, 1, delivered)
+	item := f.queueItem(t, published.MessageID)
+	assert.Equal(t, model.QueueStatusSent, item.Status)
+}
+
+func TestInterceptDelivery_OutsideWorker(t *testing.T) {
+	var delivery pubsub.Delivery
+	gateway := pubsub.InterceptDelivery(func(_ context.Context, d pubsub.Delivery, _ pubsub.MessageDeliveryGateway) error {
+		delivery = d
+		return nil
+	})(deliveryFunc(func(context.Context, string, *model.DataMessage) error { return nil }))
+
+	message := &model.DataMessage{MessageID: "1"}
+	require.NoError(t, gateway.DeliverMessage(context.Background(), "http://example.com/hook", message))
+	assert.Nil(t, delivery.Item)
+	assert.Nil(t, delivery.Subscription)
+	assert.Equal(t, "http://example.com/hook", delivery.CallbackURL)
+	assert.Same(t, message, delivery.Message)
+}
+
+func TestWithInterceptors_Validation(t *testing.T) {
+	f := newPublisherFixture(t)
+	gateway := deliveryFunc(func(context.Context, string, *model.DataMessage) error { return nil })
+	newWorker := func(interceptor pubsub.DeliveryInterceptor) error {
+		_, err := pubsub.NewQueueWorker(
+			pubsub.WithRepositories(f.repos.Queue, f.repos.Message, f.repos.Subscription, f.repos.DLQ),
+			pubsub.WithDelivery(pubsub.NewSubscriberTransmitterProvider(f.repos.Subscriber), gateway),
+			pubsub.WithInterceptors(interceptor),
+		)
+		return err
+	}
+
+	assert.Error(t, newWorker(nil), "nil interceptor")
+	assert.Error(t, newWorker(func(pubsub.MessageDeliveryGateway) pubsub.MessageDeliveryGateway { return nil }),
+		"nil gateway")
+}
@@ -0,0 +1,156 @@
+-- SQLite equivalent of migrations/001-018 (final state, default "pubsub_" prefix).
+-- Keep in sync when adding a migration: TestSchemaMatchesMigrations checks the version below.
+-- Migration: 018
+
+CREATE TABLE pubsub_publisher (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  code VARCHAR(100) NOT NULL,
+  name VARCHAR(50) NOT NULL,
+  description VARCHAR(255) NOT NULL,
+  is_active INTEGER NOT NULL DEFAULT 1,
+  access_key VARCHAR(50) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE pubsub_subscriber (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  client_id INTEGER NOT NULL,
+  name VARCHAR(150) NOT NULL,
+  webhook_url VARCHAR(2048) NOT NULL DEFAULT '',
+  is_active INTEGER NOT NULL DEFAULT 1,
+  rate_limit REAL NOT NULL DEFAULT 0,
+  rate_burst INTEGER NOT NULL DEFAULT 0,
+  email VARCHAR(100) NOT NULL DEFAULT '',
+  phone VARCHAR(25) NOT NULL DEFAULT '',
+  is_empty_possible INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE pubsub_topic (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  publisher_id INTEGER NULL DEFAULT NULL REFERENCES pubsub_publisher (id),
+  code VARCHAR(100) NOT NULL,
+  name VARCHAR(50) NOT NULL,
+  description VARCHAR(255) NOT NULL,
+  is_active INTEGER NOT NULL DEFAULT 1,
+  message_ttl_seconds INTEGER NOT NULL DEFAULT 0,
+  drop_expired INTEGER NOT NULL DEFAULT 0,
+  retention_days INTEGER NOT NULL DEFAULT 0,
+  message_count INTEGER NOT NULL DEFAULT 0,
+  last_publish_at TIMESTAMP NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE pubsub_transmitter (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  subscriber_id INTEGER NOT NULL REFERENCES pubsub_subscriber (id),
+  callback_url VARCHAR(255) NOT NULL,
+  tried_at TIMESTAMP NULL DEFAULT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  "interval" INTEGER NOT NULL,
+  is_default INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE pubsub_subscription (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  subscriber_id INTEGER NOT NULL REFERENCES pubsub_subscriber (id),
+  topic_id INTEGER NOT NULL REFERENCES pubsub_topic (id),
+  identifier VARCHAR(20) NOT NULL,
+  is_active INTEGER NOT NULL,
+  is_paused INTEGER NOT NULL DEFAULT 0,
+  resume_at DATETIME NULL DEFAULT NULL,
+  max_queue_depth INTEGER NOT NULL DEFAULT 0,
+  transmitter_id INTEGER NULL DEFAULT NULL REFERENCES pubsub_transmitter (id),
+  created_at TIMESTAMP NOT NULL,
+  deleted_at TIMESTAMP NULL DEFAULT NULL
+);
+
+CREATE TABLE pubsub_message (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  topic_id INTEGER NOT NULL REFERENCES pubsub_topic (id),
+  identifier VARCHAR(20) NOT NULL,
+  version VARCHAR(10) NOT NULL DEFAULT '1.0',
+  data TEXT NOT NULL,
+  ordering_key VARCHAR(255) NOT NULL DEFAULT '',
+  priority INTEGER NOT NULL DEFAULT 0,
+  idempotency_key VARCHAR(255) NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_topic_created ON pubsub_message (topic_id, created_at);
+CREATE UNIQUE INDEX idx_topic_idempotency_key ON pubsub_message (topic_id, idempotency_key);
+
+CREATE TABLE pubsub_queue (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  subscription_id INTEGER NOT NULL REFERENCES pubsub_subscription (id),
+  message_id INTEGER NOT NULL REFERENCES pubsub_message (id),
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+  attempt_count INTEGER NOT NULL DEFAULT 0,
+  last_attempt_at TIMESTAMP NULL,
+  next_retry_at TIMESTAMP NULL,
+  retry_after_seconds INTEGER NULL,
+  lease_owner VARCHAR(255) NULL,
+  lease_expires_at TIMESTAMP NULL,
+  last_error TEXT NULL,
+  expires_at TIMESTAMP NOT NULL DEFAULT (datetime('now', '+24 hours')),
+  sequence_number INTEGER NOT NULL DEFAULT 0,
+  ordering_key VARCHAR(255) NOT NULL DEFAULT '',
+  priority INTEGER NOT NULL DEFAULT 0,
+  priority_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  operation_timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  retry_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  is_complete INTEGER NOT NULL DEFAULT 0,
+  completed_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_claim ON pubsub_queue (status, next_retry_at, lease_expires_at);
+CREATE INDEX idx_ordering_key ON pubsub_queue (subscription_id, ordering_key, sequence_number);
+CREATE INDEX idx_priority ON pubsub_queue (status, priority_at);
+CREATE INDEX idx_expires ON pubsub_queue (expires_at, status);
+
+CREATE TABLE pubsub_notification_log (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  subscription_id INTEGER NOT NULL REFERENCES pubsub_subscription (id) ON DELETE CASCADE,
+  message_id INTEGER NOT NULL REFERENCES pubsub_message (id) ON DELETE CASCADE,
+  identifier VARCHAR(100) NOT NULL,
+  topic_code VARCHAR(50) NOT NULL,
+  subscriber_type TEXT NOT NULL DEFAULT 'client',
+  subscriber_id INTEGER NOT NULL,
+  delivery_method TEXT NOT NULL DEFAULT 'webhook',
+  status TEXT NOT NULL DEFAULT 'pending',
+  skipped_reason VARCHAR(255) NULL,
+  sent_at TIMESTAMP NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE pubsub_dlq (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  subscription_id INTEGER NOT NULL,
+  message_id INTEGER NOT NULL,
+  original_queue_id INTEGER NOT NULL,
+  attempt_count INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT,
+  failure_reason VARCHAR(500) NOT NULL,
+  first_attempt_at TIMESTAMP NOT NULL,
+  last_attempt_at TIMESTAMP NOT NULL,
+  moved_to_dlq_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  message_data TEXT NOT NULL,
+  callback_url VARCHAR(500) NOT NULL,
+  is_resolved BOOLEAN NOT NULL DEFAULT FALSE,
+  resolved_at TIMESTAMP NULL,
+  resolved_by VARCHAR(255) DEFAULT NULL,
+  resolution_note TEXT DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_dlq_message_id ON pubsub_dlq (message_id);
+
+CREATE TABLE pubsub_worker_heartbeat (
+  worker_id VARCHAR(255) NOT NULL PRIMARY KEY,
+  heartbeat_at DATETIME NOT NULL
+);
+
+CREATE TABLE pubsub_lock (
+  name VARCHAR(100) NOT NULL PRIMARY KEY,
+  holder VARCHAR(255) NOT NULL,
+  expires_at DATETIME NOT NULL
+);
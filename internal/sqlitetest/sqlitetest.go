@@ -0,0 +1,46 @@
+// Package sqlitetest provides SQLite databases with the PubSub schema for tests
+// of the Relica adapters and the standalone server.
+//
+// The schema mirrors the MySQL migrations in migrations/ (see schema.sql), so
+// repository code is exercised against the same tables and columns as in production.
+package sqlitetest
+
+import (
+	"database/sql"
+	_ "embed"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+)
+
+// DriverName is the database/sql driver name of the databases returned by Open.
+const DriverName = "sqlite3"
+
+// Schema is the SQLite equivalent of the migrations.
+//
+//go:embed schema.sql
+var Schema string
+
+// Open creates a file-backed SQLite database with the PubSub schema in a temporary directory.
+// The database is closed when the test ends.
+//
+// A file (rather than :memory:) lets every pooled connection see the same data.
+// WAL mode, a busy timeout and immediate transactions let concurrent workers wait
+// for the write lock instead of failing; foreign keys are enforced as in MySQL.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "pubsub.db") +
+		"?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on&_txlock=immediate"
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if _, err := db.Exec(Schema); err != nil {
+		t.Fatalf("apply schema: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,34 @@
+package sqlitetest
+
+import (
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/coregx/pubsub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSchemaMatchesMigrations fails when a migration is added without updating schema.sql.
+func TestSchemaMatchesMigrations(t *testing.T) {
+	files, err := fs.Glob(pubsub.MigrationFiles, "migrations/*.sql")
+	require.NoError(t, err)
+	slices.Sort(files)
+	latest := strings.SplitN(strings.TrimPrefix(files[len(files)-1], "migrations/"), "_", 2)[0]
+
+	version := regexp.MustCompile(`(?m)^-- Migration: (\d+)$`).FindStringSubmatch(Schema)
+	require.NotNil(t, version, "schema.sql has no '-- Migration:' version line")
+	assert.Equal(t, latest, version[1], "schema.sql does not mirror the latest migration")
+}
+
+func TestOpen(t *testing.T) {
+	db := Open(t)
+
+	var tables int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name LIKE 'pubsub_%'").Scan(&tables)
+	require.NoError(t, err)
+	assert.Equal(t, 11, tables)
+}
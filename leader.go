@@ -0,0 +1,160 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MaintenanceLockName is the lock QueueWorker's leader election competes for.
+const MaintenanceLockName = "queue-maintenance"
+
+// LeaderElector elects a single leader among worker instances through a LockRepository.
+//
+// Each instance campaigns periodically: the leader renews its lock, the others take over
+// once it has expired. Campaign at least every third of the lease duration, so the leader
+// renews in time. Clocks of instances must be roughly in sync, since lock expiry is
+// compared with the local time.
+//
+// Thread safety: Safe for concurrent use.
+type LeaderElector struct {
+	locks         LockRepository
+	name          string
+	holder        string
+	leaseDuration time.Duration
+
+	mu          sync.Mutex
+	isLeader    bool
+	leaderSince time.Time
+	expiresAt   time.Time // End of the current lease (zero = not leader)
+}
+
+// NewLeaderElector creates an elector competing for the named lock as holder.
+// The lock is held for leaseDuration after each successful Campaign.
+//
+// Example:
+//
+//	elector, err := pubsub.NewLeaderElector(lockRepo, "nightly-report", hostname, 30*time.Second)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if leader, _, _ := elector.Campaign(ctx); leader {
+//	    runReport(ctx)
+//	}
+func NewLeaderElector(locks LockRepository, name, holder string, leaseDuration time.Duration) (*LeaderElector, error) {
+	if locks == nil {
+		return nil, fmt.Errorf("lock repository cannot be nil")
+	}
+	if name == "" || holder == "" {
+		return nil, fmt.Errorf("lock name and holder cannot be empty")
+	}
+	if leaseDuration <= 0 {
+		return nil, fmt.Errorf("leader lease duration must be > 0, got %v", leaseDuration)
+	}
+
+	return &LeaderElector{
+		locks:         locks,
+		name:          name,
+		holder:        holder,
+		leaseDuration: leaseDuration,
+	}, nil
+}
+
+// Campaign tries to acquire or renew leadership.
+// Returns whether this instance is the leader and whether that changed with this call.
+// On error the instance is only considered the leader until its current lease runs out.
+func (e *LeaderElector) Campaign(ctx context.Context) (leader, changed bool, err error) {
+	now := time.Now()
+	expiresAt := now.Add(e.leaseDuration)
+	acquired, err := e.locks.Acquire(ctx, e.name, e.holder, expiresAt)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	wasLeader := e.isLeader && now.Before(e.expiresAt)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("failed to acquire lock %q: %w", e.name, err)
+		acquired = wasLeader
+		expiresAt = e.expiresAt
+	case !acquired:
+		expiresAt = time.Time{}
+	}
+
+	if acquired && !wasLeader {
+		e.leaderSince = now
+	}
+	e.isLeader = acquired
+	e.expiresAt = expiresAt
+	return acquired, acquired != wasLeader, err
+}
+
+// Resign releases leadership, so another instance can take over right away.
+func (e *LeaderElector) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	wasLeader := e.isLeader
+	e.isLeader = false
+	e.expiresAt = time.Time{}
+	e.mu.Unlock()
+
+	if !wasLeader {
+		return nil
+	}
+	if err := e.locks.Release(ctx, e.name, e.holder); err != nil {
+		return fmt.Errorf("failed to release lock %q: %w", e.name, err)
+	}
+	return nil
+}
+
+// IsLeader reports whether this instance currently holds leadership.
+func (e *LeaderElector) IsLeader() bool {
+	leader, _ := e.Leadership()
+	return leader
+}
+
+// Leadership reports whether this instance is the leader and since when.
+func (e *LeaderElector) Leadership() (bool, time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.isLeader || !time.Now().Before(e.expiresAt) {
+		return false, time.Time{}
+	}
+	return true, e.leaderSince
+}
+
+// campaign keeps campaigning for leadership of maintenance tasks until ctx is canceled,
+// then resigns so another worker takes over right away.
+func (w *QueueWorker) campaign(ctx context.Context) {
+	e := w.leader
+	ticker := time.NewTicker(max(e.leaseDuration/3, time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		leader, changed, err := e.Campaign(ctx)
+		if err != nil && ctx.Err() == nil {
+			w.logger.Warnf("Leader election failed: %v", err)
+		}
+		if changed {
+			if leader {
+				w.logger.Infof("Worker %s became leader for maintenance tasks", w.workerID)
+			} else {
+				w.logger.Infof("Worker %s lost leadership for maintenance tasks", w.workerID)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			wasLeader := e.IsLeader()
+			resignCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), e.leaseDuration)
+			if err := e.Resign(resignCtx); err != nil {
+				w.logger.Warnf("Failed to resign leadership: %v", err)
+			} else if wasLeader {
+				w.logger.Infof("Worker %s resigned leadership for maintenance tasks", w.workerID)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+		}
+	}
+}
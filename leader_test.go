@@ -0,0 +1,242 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLock is a named lock in testLocks.
+type testLock struct {
+	holder    string
+	expiresAt time.Time
+}
+
+// testLocks is an in-memory LockRepository.
+type testLocks struct {
+	mu    sync.Mutex
+	locks map[string]*testLock
+	err   error
+}
+
+func newTestLocks() *testLocks {
+	return &testLocks{locks: make(map[string]*testLock)}
+}
+
+func (l *testLocks) Acquire(_ context.Context, name, holder string, expiresAt time.Time) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return false, l.err
+	}
+
+	lock, ok := l.locks[name]
+	if ok && lock.holder != holder && time.Now().Before(lock.expiresAt) {
+		return false, nil
+	}
+	l.locks[name] = &testLock{holder: holder, expiresAt: expiresAt}
+	return true, nil
+}
+
+func (l *testLocks) Release(_ context.Context, name, holder string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+
+	if lock, ok := l.locks[name]; ok && lock.holder == holder {
+		delete(l.locks, name)
+	}
+	return nil
+}
+
+// expire makes the named lock look abandoned by its holder.
+func (l *testLocks) expire(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.locks[name].expiresAt = time.Now().Add(-time.Second)
+}
+
+func TestNewLeaderElector_Validation(t *testing.T) {
+	locks := newTestLocks()
+
+	tests := []struct {
+		name   string
+		locks  LockRepository
+		lock   string
+		holder string
+		lease  time.Duration
+	}{
+		{"nil repository", nil, "lock", "a", time.Second},
+		{"empty name", locks, "", "a", time.Second},
+		{"empty holder", locks, "lock", "", time.Second},
+		{"zero lease", locks, "lock", "a", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLeaderElector(tt.locks, tt.lock, tt.holder, tt.lease)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// campaignStep is one Campaign call of an elector and its expected outcome.
+type campaignStep struct {
+	elector     string // "a" or "b"
+	expire      bool   // Expire the lock before the call (its holder died)
+	wantLeader  bool
+	wantChanged bool
+}
+
+func TestLeaderElector_Campaign(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []campaignStep
+	}{
+		{
+			name: "first campaigner wins, the other stays standby",
+			steps: []campaignStep{
+				{elector: "a", wantLeader: true, wantChanged: true},
+				{elector: "b", wantLeader: false, wantChanged: false},
+			},
+		},
+		{
+			name: "leader renews without a change",
+			steps: []campaignStep{
+				{elector: "a", wantLeader: true, wantChanged: true},
+				{elector: "a", wantLeader: true, wantChanged: false},
+				{elector: "b", wantLeader: false, wantChanged: false},
+			},
+		},
+		{
+			name: "standby takes over an expired lease",
+			steps: []campaignStep{
+				{elector: "a", wantLeader: true, wantChanged: true},
+				{elector: "b", expire: true, wantLeader: true, wantChanged: true},
+				{elector: "a", wantLeader: false, wantChanged: true},
+				{elector: "a", wantLeader: false, wantChanged: false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			locks := newTestLocks()
+			electors := make(map[string]*LeaderElector)
+			for _, holder := range []string{"a", "b"} {
+				e, err := NewLeaderElector(locks, "maintenance", holder, time.Minute)
+				require.NoError(t, err)
+				electors[holder] = e
+			}
+
+			for i, step := range tt.steps {
+				if step.expire {
+					locks.expire("maintenance")
+				}
+				leader, changed, err := electors[step.elector].Campaign(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, step.wantLeader, leader, "step %d: leader", i)
+				assert.Equal(t, step.wantChanged, changed, "step %d: changed", i)
+				assert.Equal(t, step.wantLeader, electors[step.elector].IsLeader(), "step %d: IsLeader", i)
+			}
+		})
+	}
+}
+
+func TestLeaderElector_LeaderSince(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewLeaderElector(newTestLocks(), "maintenance", "a", time.Minute)
+	require.NoError(t, err)
+
+	leader, since := e.Leadership()
+	assert.False(t, leader)
+	assert.True(t, since.IsZero())
+
+	_, _, err = e.Campaign(ctx)
+	require.NoError(t, err)
+	_, since = e.Leadership()
+	assert.False(t, since.IsZero())
+
+	// Renewing keeps the original start
+	_, _, err = e.Campaign(ctx)
+	require.NoError(t, err)
+	_, renewedSince := e.Leadership()
+	assert.Equal(t, since, renewedSince)
+}
+
+func TestLeaderElector_ErrorKeepsLeadershipUntilLeaseEnds(t *testing.T) {
+	ctx := context.Background()
+	locks := newTestLocks()
+	e, err := NewLeaderElector(locks, "maintenance", "a", 50*time.Millisecond)
+	require.NoError(t, err)
+
+	leader, _, err := e.Campaign(ctx)
+	require.NoError(t, err)
+	require.True(t, leader)
+
+	locks.err = errors.New("db down")
+	leader, changed, err := e.Campaign(ctx)
+	assert.ErrorContains(t, err, "db down")
+	assert.True(t, leader, "still within the lease")
+	assert.False(t, changed)
+
+	time.Sleep(60 * time.Millisecond)
+	assert.False(t, e.IsLeader(), "lease ran out")
+	leader, changed, err = e.Campaign(ctx)
+	assert.Error(t, err)
+	assert.False(t, leader)
+	assert.False(t, changed, "leadership already lapsed")
+}
+
+func TestLeaderElector_Resign(t *testing.T) {
+	ctx := context.Background()
+	locks := newTestLocks()
+	a, err := NewLeaderElector(locks, "maintenance", "a", time.Minute)
+	require.NoError(t, err)
+	b, err := NewLeaderElector(locks, "maintenance", "b", time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Resign(ctx), "resigning without leadership is a no-op")
+
+	_, _, err = a.Campaign(ctx)
+	require.NoError(t, err)
+	require.NoError(t, a.Resign(ctx))
+	assert.False(t, a.IsLeader())
+
+	// The standby takes over right away, without waiting for the lease to expire
+	leader, changed, err := b.Campaign(ctx)
+	require.NoError(t, err)
+	assert.True(t, leader)
+	assert.True(t, changed)
+}
+
+func TestQueueWorker_CampaignResignsOnCancel(t *testing.T) {
+	locks := newTestLocks()
+	e, err := NewLeaderElector(locks, MaintenanceLockName, "worker-a", time.Minute)
+	require.NoError(t, err)
+	w := &QueueWorker{leader: e, workerID: "worker-a", logger: &NoopLogger{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.campaign(ctx)
+	}()
+
+	require.Eventually(t, e.IsLeader, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	assert.False(t, e.IsLeader())
+	locks.mu.Lock()
+	defer locks.mu.Unlock()
+	assert.NotContains(t, locks.locks, MaintenanceLockName, "lock released")
+}
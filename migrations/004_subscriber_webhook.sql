@@ -0,0 +1,11 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Subscriber webhook delivery fields
+-- Date: 2026-10-16
+
+ALTER TABLE pubsub_subscriber
+ADD COLUMN webhook_url VARCHAR(2048) NOT NULL DEFAULT '' AFTER name,
+ADD COLUMN is_active TINYINT(1) NOT NULL DEFAULT 1 AFTER webhook_url;
+
+-- +goose Down
+ALTER TABLE pubsub_subscriber DROP COLUMN IF EXISTS is_active, DROP COLUMN IF EXISTS webhook_url;
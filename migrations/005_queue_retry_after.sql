@@ -0,0 +1,10 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Record subscriber-requested retry delay (Retry-After)
+-- Date: 2026-10-16
+
+ALTER TABLE pubsub_queue
+ADD COLUMN retry_after_seconds INT UNSIGNED NULL AFTER next_retry_at;
+
+-- +goose Down
+ALTER TABLE pubsub_queue DROP COLUMN IF EXISTS retry_after_seconds;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Per-subscriber delivery rate limit
+-- Date: 2026-10-16
+
+ALTER TABLE pubsub_subscriber
+ADD COLUMN rate_limit DECIMAL(10,3) NOT NULL DEFAULT 0 COMMENT 'max deliveries per second, 0 = unlimited' AFTER is_active,
+ADD COLUMN rate_burst INT UNSIGNED NOT NULL DEFAULT 0 COMMENT 'deliveries allowed at once, 0 = derived' AFTER rate_limit;
+
+-- +goose Down
+ALTER TABLE pubsub_subscriber DROP COLUMN IF EXISTS rate_burst, DROP COLUMN IF EXISTS rate_limit;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Queue item leases for multi-instance workers
+-- Date: 2026-10-16
+
+ALTER TABLE pubsub_queue
+ADD COLUMN lease_owner VARCHAR(255) NULL AFTER retry_after_seconds,
+ADD COLUMN lease_expires_at TIMESTAMP NULL AFTER lease_owner,
+ADD INDEX idx_claim (status, next_retry_at, lease_expires_at);
+
+-- +goose Down
+ALTER TABLE pubsub_queue DROP INDEX IF EXISTS idx_claim, DROP COLUMN IF EXISTS lease_expires_at, DROP COLUMN IF EXISTS lease_owner;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Ordering keys for per-key ordered delivery
+-- Date: 2026-10-16
+
+ALTER TABLE pubsub_message
+ADD COLUMN ordering_key VARCHAR(255) NOT NULL DEFAULT '' AFTER data;
+
+ALTER TABLE pubsub_queue
+ADD COLUMN ordering_key VARCHAR(255) NOT NULL DEFAULT '' AFTER sequence_number,
+ADD INDEX idx_ordering_key (subscription_id, ordering_key, sequence_number);
+
+-- +goose Down
+ALTER TABLE pubsub_queue DROP INDEX IF EXISTS idx_ordering_key, DROP COLUMN IF EXISTS ordering_key;
+ALTER TABLE pubsub_message DROP COLUMN IF EXISTS ordering_key;
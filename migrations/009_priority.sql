@@ -0,0 +1,22 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Message priorities with aging
+-- Date: 2026-10-16
+
+ALTER TABLE pubsub_message
+ADD COLUMN priority TINYINT NOT NULL DEFAULT 0 AFTER ordering_key;
+
+ALTER TABLE pubsub_queue
+ADD COLUMN priority TINYINT NOT NULL DEFAULT 0 AFTER ordering_key,
+ADD COLUMN priority_at TIMESTAMP NULL AFTER priority;
+
+-- Existing items keep their FIFO order
+UPDATE pubsub_queue SET priority_at = created_at WHERE priority_at IS NULL;
+
+ALTER TABLE pubsub_queue
+MODIFY COLUMN priority_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ADD INDEX idx_priority (status, priority_at);
+
+-- +goose Down
+ALTER TABLE pubsub_queue DROP INDEX IF EXISTS idx_priority, DROP COLUMN IF EXISTS priority_at, DROP COLUMN IF EXISTS priority;
+ALTER TABLE pubsub_message DROP COLUMN IF EXISTS priority;
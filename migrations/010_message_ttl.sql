@@ -0,0 +1,10 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Message TTL per topic
+-- Date: 2026-10-16
+
+ALTER TABLE pubsub_topic
+ADD COLUMN message_ttl_seconds INT UNSIGNED NOT NULL DEFAULT 0 AFTER description;
+
+-- +goose Down
+ALTER TABLE pubsub_topic DROP COLUMN IF EXISTS message_ttl_seconds;
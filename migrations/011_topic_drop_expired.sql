@@ -0,0 +1,10 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Per-topic policy for expired messages
+-- Date: 2026-10-16
+
+ALTER TABLE pubsub_topic
+ADD COLUMN drop_expired TINYINT(1) NOT NULL DEFAULT 0 AFTER message_ttl_seconds;
+
+-- +goose Down
+ALTER TABLE pubsub_topic DROP COLUMN IF EXISTS drop_expired;
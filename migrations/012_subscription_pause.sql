@@ -0,0 +1,13 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Pause and resume delivery per subscription
+-- Date: 2026-10-16
+
+ALTER TABLE pubsub_subscription
+ADD COLUMN is_paused TINYINT(1) NOT NULL DEFAULT 0 AFTER is_active,
+ADD COLUMN resume_at DATETIME NULL DEFAULT NULL AFTER is_paused;
+
+-- +goose Down
+ALTER TABLE pubsub_subscription
+DROP COLUMN IF EXISTS resume_at,
+DROP COLUMN IF EXISTS is_paused;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Worker heartbeats for partitioned queue processing
+-- Date: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS pubsub_worker_heartbeat (
+  worker_id VARCHAR(255) NOT NULL,
+  heartbeat_at DATETIME NOT NULL,
+  PRIMARY KEY (worker_id),
+  INDEX idx_heartbeat_at (heartbeat_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='Live queue workers (partition assignment)';
+
+-- +goose Down
+DROP TABLE IF EXISTS pubsub_worker_heartbeat;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Locks for leader election of maintenance tasks
+-- Date: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS pubsub_lock (
+  name VARCHAR(100) NOT NULL,
+  holder VARCHAR(255) NOT NULL,
+  expires_at DATETIME NOT NULL,
+  PRIMARY KEY (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='Named expiring locks (leader election)';
+
+-- +goose Down
+DROP TABLE IF EXISTS pubsub_lock;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Per-topic message retention
+-- Date: 2026-10-16
+
+ALTER TABLE pubsub_topic
+ADD COLUMN retention_days INT NOT NULL DEFAULT 0 AFTER drop_expired;
+
+ALTER TABLE pubsub_message
+ADD INDEX idx_topic_created (topic_id, created_at);
+
+-- +goose Down
+ALTER TABLE pubsub_message DROP INDEX IF EXISTS idx_topic_created;
+ALTER TABLE pubsub_topic DROP COLUMN IF EXISTS retention_days;
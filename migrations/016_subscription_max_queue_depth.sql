@@ -0,0 +1,10 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Max queue depth per subscription (backpressure)
+-- Date: 2026-10-16
+
+ALTER TABLE pubsub_subscription
+ADD COLUMN max_queue_depth INT NOT NULL DEFAULT 0 AFTER resume_at;
+
+-- +goose Down
+ALTER TABLE pubsub_subscription DROP COLUMN IF EXISTS max_queue_depth;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Idempotency keys for deduplicated publishing
+-- Date: 2026-10-16
+
+-- NULL keys are not unique, so messages without a key never conflict
+ALTER TABLE pubsub_message
+ADD COLUMN idempotency_key VARCHAR(255) NULL DEFAULT NULL AFTER priority,
+ADD UNIQUE INDEX idx_topic_idempotency_key (topic_id, idempotency_key);
+
+-- +goose Down
+ALTER TABLE pubsub_message DROP INDEX IF EXISTS idx_topic_idempotency_key, DROP COLUMN IF EXISTS idempotency_key;
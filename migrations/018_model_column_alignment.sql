@@ -0,0 +1,29 @@
+-- +goose Up
+-- Service: PubSub
+-- Migration: Align legacy columns with the models
+-- Date: 2026-10-16
+
+-- Topics and publishers can be deactivated (model IsActive)
+ALTER TABLE pubsub_topic
+ADD COLUMN is_active TINYINT(1) NOT NULL DEFAULT 1 AFTER description;
+
+ALTER TABLE pubsub_publisher
+ADD COLUMN is_active TINYINT(1) NOT NULL DEFAULT 1 AFTER description;
+
+-- Legacy columns the models do not write: inserts must succeed without them
+ALTER TABLE pubsub_topic
+MODIFY COLUMN publisher_id INT UNSIGNED NULL DEFAULT NULL;
+
+ALTER TABLE pubsub_subscriber
+MODIFY COLUMN email VARCHAR(100) NOT NULL DEFAULT '',
+MODIFY COLUMN phone VARCHAR(25) NOT NULL DEFAULT '';
+
+ALTER TABLE pubsub_subscription
+MODIFY COLUMN transmitter_id INT UNSIGNED NULL DEFAULT NULL;
+
+-- +goose Down
+ALTER TABLE pubsub_subscription MODIFY COLUMN transmitter_id INT UNSIGNED NOT NULL;
+ALTER TABLE pubsub_subscriber MODIFY COLUMN email VARCHAR(100) NOT NULL, MODIFY COLUMN phone VARCHAR(25) NOT NULL;
+ALTER TABLE pubsub_topic MODIFY COLUMN publisher_id INT UNSIGNED NOT NULL DEFAULT '1';
+ALTER TABLE pubsub_publisher DROP COLUMN IF EXISTS is_active;
+ALTER TABLE pubsub_topic DROP COLUMN IF EXISTS is_active;
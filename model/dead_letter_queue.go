@@ -19,7 +19,7 @@ import (
 //
 // Items remain in DLQ until manually resolved or deleted.
 type DeadLetterQueue struct {
-	ID              int64 `json:"id"`
+	ID              int64 `json:"id" db:"id"`
 	SubscriptionID  int64 `json:"subscriptionID" db:"subscription_id"`
 	MessageID       int64 `json:"messageID" db:"message_id"`
 	OriginalQueueID int64 `json:"originalQueueId" db:"original_queue_id"` // Reference to original queue item
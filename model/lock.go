@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Lock is a named, expiring lock held by one worker instance.
+// It backs leader election: the holder of a lock runs singleton maintenance tasks
+// and must renew the lock before it expires.
+type Lock struct {
+	Name      string    `json:"name" db:"name"`            // Lock name (e.g. "queue-maintenance")
+	Holder    string    `json:"holder" db:"holder"`        // Worker instance holding the lock
+	ExpiresAt time.Time `json:"expiresAt" db:"expires_at"` // The lock is free after this time
+}
+
+// TableName returns the database table name for Lock.
+func (m Lock) TableName() string {
+	return tablePrefix + "lock"
+}
+
+// IsHeldBy reports whether holder holds the lock at the given time.
+func (m Lock) IsHeldBy(holder string, now time.Time) bool {
+	return m.Holder == holder && now.Before(m.ExpiresAt)
+}
+
+// IsFree reports whether the lock can be taken at the given time.
+func (m Lock) IsFree(now time.Time) bool {
+	return !now.Before(m.ExpiresAt)
+}
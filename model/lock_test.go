@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock_TableName(t *testing.T) {
+	assert.Equal(t, "pubsub_lock", Lock{}.TableName())
+}
+
+func TestLock_IsHeldBy(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		lock   Lock
+		held   bool
+		isFree bool
+	}{
+		{"held", Lock{Holder: "worker-1", ExpiresAt: now.Add(time.Second)}, true, false},
+		{"held by other", Lock{Holder: "worker-2", ExpiresAt: now.Add(time.Second)}, false, false},
+		{"expired", Lock{Holder: "worker-1", ExpiresAt: now}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.held, tt.lock.IsHeldBy("worker-1", now))
+			assert.Equal(t, tt.isFree, tt.lock.IsFree(now))
+		})
+	}
+}
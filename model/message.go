@@ -1,6 +1,9 @@
 package model
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 // Message represents a published message in the pub/sub system.
 // Messages are immutable once created and contain the actual payload to be delivered.
@@ -8,11 +11,16 @@ import "time"
 // Each published message creates queue items for all active subscriptions to its topic.
 // Messages are retained for archival/audit purposes even after successful delivery.
 type Message struct {
-	ID         int64     `json:"id"`         // Unique message ID
-	TopicID    int64     `json:"topicID"`    // Topic this message belongs to
-	Identifier string    `json:"identifier"` // Event identifier (e.g., "user-123")
-	Data       string    `json:"data"`       // Message payload (JSON or string)
-	CreatedAt  time.Time `json:"createdAt"`  // Publication timestamp
+	ID         int64     `json:"id" db:"id"`                 // Unique message ID
+	TopicID    int64     `json:"topicID" db:"topic_id"`      // Topic this message belongs to
+	Identifier string    `json:"identifier" db:"identifier"` // Event identifier (e.g., "user-123")
+	Data       string    `json:"data" db:"data"`             // Message payload (JSON or string)
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`  // Publication timestamp
+
+	OrderingKey string `json:"orderingKey" db:"ordering_key"` // Messages with the same key are delivered in publish order ("" = unordered)
+	Priority    int    `json:"priority" db:"priority"`        // 0 (default) to MaxPriority, higher is delivered first
+
+	IdempotencyKey sql.NullString `json:"idempotencyKey" db:"idempotency_key"` // Deduplicates retried publishes, unique per topic (NULL = none)
 }
 
 // TableName returns the database table name for Message.
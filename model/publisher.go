@@ -8,12 +8,12 @@ import "time"
 // Each publisher is identified by a unique code and can be activated/deactivated.
 // Inactive publishers cannot publish new messages.
 type Publisher struct {
-	ID          int64     `json:"id"`                        // Unique publisher ID
-	Code        string    `json:"code" db:"publisher_code"`  // Unique publisher code (e.g., "user-service")
-	Name        string    `json:"name"`                      // Human-readable publisher name
-	Description string    `json:"description"`               // Publisher description
-	IsActive    bool      `json:"isActive" db:"is_active"`   // Only active publishers can publish
-	CreatedAt   time.Time `json:"createdAt" db:"created_at"` // Publisher registration time
+	ID          int64     `json:"id" db:"id"`                   // Unique publisher ID
+	Code        string    `json:"code" db:"code"`               // Unique publisher code (e.g., "user-service")
+	Name        string    `json:"name" db:"name"`               // Human-readable publisher name
+	Description string    `json:"description" db:"description"` // Publisher description
+	IsActive    bool      `json:"isActive" db:"is_active"`      // Only active publishers can publish
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`    // Publisher registration time
 }
 
 // TableName returns the database table name for Publisher.
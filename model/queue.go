@@ -5,6 +5,12 @@ import (
 	"time"
 )
 
+// DefaultQueueTTL is how long a queue item stays deliverable when no TTL is configured.
+const DefaultQueueTTL = 24 * time.Hour
+
+// MaxPriority is the highest message priority. Priorities range from 0 (default) to MaxPriority.
+const MaxPriority = 9
+
 // QueueStatus represents the lifecycle state of a queue item.
 type QueueStatus string
 
@@ -33,24 +39,34 @@ const (
 //   - CanAttemptDelivery: Check if delivery can be attempted
 //   - ShouldRetry: Check if item is ready for retry
 //   - ShouldMoveToDLQ: Check if exhausted retries
+//   - HasLease/ReleaseLease: Check and release a worker's claim on the item
+//   - Prioritize: Set the priority and serving order
+//   - Schedule/IsScheduled: Delay the first delivery to a future time
+//   - SetTTL/ExtendExpiry: Set how long the item stays deliverable
 //
 // This model implements Domain-Driven Design with rich business logic.
 type Queue struct {
-	ID                 int64          `json:"id"`
-	SubscriptionID     int64          `json:"subscriptionID"`
-	MessageID          int64          `json:"messageID"`
+	ID                 int64          `json:"id" db:"id"`
+	SubscriptionID     int64          `json:"subscriptionID" db:"subscription_id"`
+	MessageID          int64          `json:"messageID" db:"message_id"`
 	Status             QueueStatus    `json:"status" db:"status"`                          // NEW: from 00019
 	AttemptCount       int            `json:"attemptCount" db:"attempt_count"`             // NEW: from 00019
 	LastAttemptAt      sql.NullTime   `json:"lastAttemptAt" db:"last_attempt_at"`          // NEW: from 00019
 	NextRetryAt        sql.NullTime   `json:"nextRetryAt" db:"next_retry_at"`              // NEW: from 00019
 	LastError          sql.NullString `json:"lastError" db:"last_error"`                   // NEW: from 00019
 	ExpiresAt          time.Time      `json:"expiresAt" db:"expires_at"`                   // NEW: from 00019
-	SequenceNumber     int64          `json:"sequenceNumber" db:"sequence_number"`         // NEW: from 00019
+	SequenceNumber     int64          `json:"sequenceNumber" db:"sequence_number"`         // Publish order (the message ID)
+	OrderingKey        string         `json:"orderingKey" db:"ordering_key"`               // Items with the same key and subscription are delivered in sequence
+	Priority           int            `json:"priority" db:"priority"`                      // 0 (default) to MaxPriority, higher is served first
+	PriorityAt         time.Time      `json:"priorityAt" db:"priority_at"`                 // Serving order: due time moved earlier by priority (see Prioritize)
 	OperationTimestamp time.Time      `json:"operationTimestamp" db:"operation_timestamp"` // NEW: from 00019
-	RetryAt            sql.NullTime   `json:"retryAt"`                                     // LEGACY: keep for backward compatibility
-	IsComplete         bool           `json:"isComplete"`                                  // LEGACY: deprecated, use Status
-	CompletedAt        sql.NullTime   `json:"completedAt"`
-	CreatedAt          time.Time      `json:"createdAt"`
+	RetryAfterSeconds  sql.NullInt64  `json:"retryAfterSeconds" db:"retry_after_seconds"`  // Subscriber-requested retry delay
+	LeaseOwner         sql.NullString `json:"leaseOwner" db:"lease_owner"`                 // Worker that claimed the item
+	LeaseExpiresAt     sql.NullTime   `json:"leaseExpiresAt" db:"lease_expires_at"`        // Claim is void after this time
+	RetryAt            sql.NullTime   `json:"retryAt" db:"retry_at"`                       // LEGACY: keep for backward compatibility
+	IsComplete         bool           `json:"isComplete" db:"is_complete"`                 // LEGACY: deprecated, use Status
+	CompletedAt        sql.NullTime   `json:"completedAt" db:"completed_at"`
+	CreatedAt          time.Time      `json:"createdAt" db:"created_at"`
 }
 
 // TableName returns the database table name for Queue.
@@ -60,10 +76,11 @@ func (t *Queue) TableName() string {
 
 // NewQueue creates a new queue item for message delivery.
 // Initial state: PENDING, AttemptCount=0, NextRetryAt=now (ready immediately).
-// Default expiry: 24 hours from creation.
+// Default expiry: DefaultQueueTTL from creation (see SetTTL). SequenceNumber is the message ID, which
+// orders items of the same OrderingKey by publish time.
 func NewQueue(subscriptionID, messageID int64) Queue {
 	now := time.Now()
-	expiresAt := now.Add(24 * time.Hour) // Default 24h expiry
+	expiresAt := now.Add(DefaultQueueTTL)
 
 	return Queue{
 		ID:                 0,
@@ -75,7 +92,8 @@ func NewQueue(subscriptionID, messageID int64) Queue {
 		NextRetryAt:        sql.NullTime{Time: now, Valid: true}, // Ready to send immediately
 		LastError:          sql.NullString{},
 		ExpiresAt:          expiresAt,
-		SequenceNumber:     0, // Will be set by repository
+		SequenceNumber:     messageID, // Message IDs are assigned in publish order
+		PriorityAt:         now,
 		OperationTimestamp: now,
 		RetryAt:            sql.NullTime{Time: now, Valid: true}, // LEGACY
 		IsComplete:         false,                                // LEGACY
@@ -84,6 +102,67 @@ func NewQueue(subscriptionID, messageID int64) Queue {
 	}
 }
 
+// Prioritize sets the item's priority and its serving order.
+//
+// Due items are served in PriorityAt order, which is the time the item became due
+// (CreatedAt, or the scheduled time, see Schedule) moved earlier by priority × aging.
+// An item of priority p is therefore served before lower-priority items that became due
+// up to p × aging before it, but never before items that have waited longer than that:
+// waiting items age into the front, so low priorities are not starved.
+// Priority is clamped to [0, MaxPriority].
+func (t *Queue) Prioritize(priority int, aging time.Duration) {
+	t.Priority = min(max(priority, 0), MaxPriority)
+	t.PriorityAt = t.dueAt().Add(-time.Duration(t.Priority) * aging)
+}
+
+// Schedule delays the first delivery of a new queue item until at.
+// ExpiresAt is moved by the same delay, so the item keeps its full delivery window,
+// and the serving order (PriorityAt) starts counting at the scheduled time.
+// Times in the past are ignored.
+func (t *Queue) Schedule(at time.Time) {
+	delay := at.Sub(t.dueAt())
+	if delay <= 0 {
+		return
+	}
+
+	t.NextRetryAt = sql.NullTime{Time: at, Valid: true}
+	t.RetryAt = sql.NullTime{Time: at, Valid: true} // LEGACY
+	t.ExpiresAt = t.ExpiresAt.Add(delay)
+	t.PriorityAt = t.PriorityAt.Add(delay)
+}
+
+// SetTTL sets how long the item stays deliverable, counted from when it becomes due.
+// A ttl <= 0 is ignored (the item keeps its expiry).
+func (t *Queue) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	t.ExpiresAt = t.dueAt().Add(ttl)
+}
+
+// ExtendExpiry moves ExpiresAt to until if that is later.
+// Used when scheduling a retry, so an item never expires before its next attempt.
+func (t *Queue) ExtendExpiry(until time.Time) {
+	if until.After(t.ExpiresAt) {
+		t.ExpiresAt = until
+	}
+}
+
+// IsScheduled reports whether the item is pending and its first delivery is in the future.
+// Scheduled items can be canceled (see QueueRepository.DeleteScheduled).
+func (t *Queue) IsScheduled() bool {
+	return t.Status == QueueStatusPending && t.AttemptCount == 0 &&
+		t.NextRetryAt.Valid && t.NextRetryAt.Time.After(time.Now())
+}
+
+// dueAt returns when the item becomes due for its first delivery.
+func (t *Queue) dueAt() time.Time {
+	if t.NextRetryAt.Valid {
+		return t.NextRetryAt.Time
+	}
+	return t.CreatedAt
+}
+
 // SetComplete marks the queue item as complete (deprecated, use MarkSent instead).
 func (t *Queue) SetComplete() {
 	t.CompletedAt = sql.NullTime{Time: time.Now(), Valid: true}
@@ -103,17 +182,52 @@ func (t *Queue) MarkFailed(err error, retryAfter time.Duration) {
 	t.AttemptCount++
 	t.LastAttemptAt = sql.NullTime{Time: now, Valid: true}
 	t.NextRetryAt = sql.NullTime{Time: now.Add(retryAfter), Valid: true}
+	t.RetryAfterSeconds = sql.NullInt64{}
+	t.ReleaseLease()
 	if err != nil {
 		t.LastError = sql.NullString{String: err.Error(), Valid: true}
 	}
 }
 
+// MarkFailedWithRetryAfter marks the queue item as failed and schedules the retry
+// using a delay requested by the subscriber (e.g. an HTTP Retry-After header)
+// instead of the backoff schedule.
+// The delay is recorded in RetryAfterSeconds so operators can see why the retry was deferred.
+func (t *Queue) MarkFailedWithRetryAfter(err error, retryAfter time.Duration) {
+	t.MarkFailed(err, retryAfter)
+	seconds := int64((retryAfter + time.Second - 1) / time.Second) // Round up
+	t.RetryAfterSeconds = sql.NullInt64{Int64: seconds, Valid: true}
+}
+
+// Defer reschedules the queue item to until without counting a delivery attempt.
+// Used when delivery is skipped rather than failed (e.g. the subscriber's circuit is open).
+// Status, attempt count and last error are left unchanged.
+func (t *Queue) Defer(until time.Time) {
+	t.NextRetryAt = sql.NullTime{Time: until, Valid: true}
+	t.ReleaseLease()
+}
+
+// HasLease reports whether owner holds an unexpired lease on the queue item.
+// Workers only deliver items they hold a lease on (see QueueRepository.ClaimItems).
+func (t *Queue) HasLease(owner string) bool {
+	return t.LeaseOwner.Valid && t.LeaseOwner.String == owner &&
+		t.LeaseExpiresAt.Valid && time.Now().Before(t.LeaseExpiresAt.Time)
+}
+
+// ReleaseLease clears the worker's claim so the item can be claimed again.
+// Called by MarkSent, MarkFailed and Defer.
+func (t *Queue) ReleaseLease() {
+	t.LeaseOwner = sql.NullString{}
+	t.LeaseExpiresAt = sql.NullTime{}
+}
+
 // MarkSent marks the queue item as successfully delivered.
 // Sets status to SENT and updates timing fields.
 func (t *Queue) MarkSent() {
 	now := time.Now()
 	t.Status = QueueStatusSent
 	t.LastAttemptAt = sql.NullTime{Time: now, Valid: true}
+	t.ReleaseLease()
 	t.SetComplete() // Also set legacy fields
 }
 
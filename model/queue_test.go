@@ -20,6 +20,8 @@ func TestNewQueue(t *testing.T) {
 	// Check IDs
 	assert.Equal(t, subscriptionID, queue.SubscriptionID)
 	assert.Equal(t, messageID, queue.MessageID)
+	assert.Equal(t, messageID, queue.SequenceNumber)
+	assert.Empty(t, queue.OrderingKey)
 
 	// Check status fields
 	assert.Equal(t, QueueStatusPending, queue.Status)
@@ -109,6 +111,214 @@ func TestQueue_MarkFailed(t *testing.T) {
 	}
 }
 
+func TestQueue_MarkFailedWithRetryAfter(t *testing.T) {
+	queue := NewQueue(1, 1)
+
+	beforeMark := time.Now()
+	queue.MarkFailedWithRetryAfter(errors.New("HTTP 429"), 90*time.Second)
+
+	assert.Equal(t, QueueStatusFailed, queue.Status)
+	assert.Equal(t, 1, queue.AttemptCount)
+	assert.WithinDuration(t, beforeMark.Add(90*time.Second), queue.NextRetryAt.Time, 1*time.Second)
+	assert.True(t, queue.RetryAfterSeconds.Valid)
+	assert.Equal(t, int64(90), queue.RetryAfterSeconds.Int64)
+
+	// Sub-second delays are rounded up
+	queue.MarkFailedWithRetryAfter(nil, 1500*time.Millisecond)
+	assert.Equal(t, int64(2), queue.RetryAfterSeconds.Int64)
+
+	// A regular failure clears the recorded hint
+	queue.MarkFailed(errors.New("timeout"), 30*time.Second)
+	assert.False(t, queue.RetryAfterSeconds.Valid)
+	assert.Equal(t, 3, queue.AttemptCount)
+}
+
+func TestQueue_Defer(t *testing.T) {
+	queue := NewQueue(1, 1)
+	queue.MarkFailed(errors.New("timeout"), 30*time.Second)
+
+	until := time.Now().Add(5 * time.Minute)
+	queue.Defer(until)
+
+	assert.Equal(t, QueueStatusFailed, queue.Status)
+	assert.Equal(t, 1, queue.AttemptCount)
+	assert.Equal(t, "timeout", queue.LastError.String)
+	assert.True(t, queue.NextRetryAt.Valid)
+	assert.Equal(t, until, queue.NextRetryAt.Time)
+}
+
+func TestQueue_Prioritize(t *testing.T) {
+	tests := []struct {
+		name         string
+		priority     int
+		wantPriority int
+	}{
+		{name: "default", priority: 0, wantPriority: 0},
+		{name: "high", priority: 5, wantPriority: 5},
+		{name: "above max is clamped", priority: 42, wantPriority: MaxPriority},
+		{name: "negative is clamped", priority: -1, wantPriority: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := NewQueue(1, 1)
+			queue.Prioritize(tt.priority, time.Minute)
+
+			assert.Equal(t, tt.wantPriority, queue.Priority)
+			assert.Equal(t, queue.CreatedAt.Add(-time.Duration(tt.wantPriority)*time.Minute), queue.PriorityAt)
+		})
+	}
+}
+
+func TestQueue_PrioritizeAging(t *testing.T) {
+	now := time.Now()
+	queueDueAt := func(dueAt time.Time, priority int) Queue {
+		queue := NewQueue(1, 1)
+		queue.CreatedAt = dueAt
+		queue.NextRetryAt = sql.NullTime{Time: dueAt, Valid: true}
+		queue.Prioritize(priority, time.Minute)
+		return queue
+	}
+
+	urgent := queueDueAt(now, 3)
+	recent := queueDueAt(now.Add(-2*time.Minute), 0)
+	starving := queueDueAt(now.Add(-4*time.Minute), 0)
+
+	assert.True(t, urgent.PriorityAt.Before(recent.PriorityAt), "higher priority is served first")
+	assert.True(t, starving.PriorityAt.Before(urgent.PriorityAt), "items waiting longer than priority × aging are served first")
+}
+
+func TestQueue_Schedule(t *testing.T) {
+	queue := NewQueue(1, 1)
+	window := queue.ExpiresAt.Sub(queue.CreatedAt)
+	deliverAt := time.Now().Add(24 * time.Hour)
+
+	queue.Schedule(deliverAt)
+
+	assert.True(t, queue.IsScheduled())
+	assert.Equal(t, deliverAt, queue.NextRetryAt.Time)
+	assert.Equal(t, window, queue.ExpiresAt.Sub(deliverAt), "delivery window starts at the scheduled time")
+
+	// Priority counts from the scheduled time, regardless of call order
+	queue.Prioritize(2, time.Minute)
+	assert.Equal(t, deliverAt.Add(-2*time.Minute), queue.PriorityAt)
+}
+
+func TestQueue_SchedulePastIsIgnored(t *testing.T) {
+	queue := NewQueue(1, 1)
+	before := queue
+
+	queue.Schedule(time.Now().Add(-time.Hour))
+
+	assert.Equal(t, before, queue)
+	assert.False(t, queue.IsScheduled())
+}
+
+func TestQueue_SetTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule time.Duration
+		ttl      time.Duration
+	}{
+		{name: "immediate", ttl: 30 * time.Second},
+		{name: "scheduled counts from delivery time", schedule: time.Hour, ttl: 7 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := NewQueue(1, 1)
+			if tt.schedule > 0 {
+				queue.Schedule(queue.CreatedAt.Add(tt.schedule))
+			}
+
+			queue.SetTTL(tt.ttl)
+
+			assert.Equal(t, queue.NextRetryAt.Time.Add(tt.ttl), queue.ExpiresAt)
+		})
+	}
+
+	t.Run("zero keeps default", func(t *testing.T) {
+		queue := NewQueue(1, 1)
+		queue.SetTTL(0)
+		assert.Equal(t, queue.CreatedAt.Add(DefaultQueueTTL), queue.ExpiresAt)
+	})
+}
+
+func TestQueue_ExtendExpiry(t *testing.T) {
+	queue := NewQueue(1, 1)
+	queue.SetTTL(time.Minute)
+	expiresAt := queue.ExpiresAt
+
+	queue.ExtendExpiry(expiresAt.Add(-time.Second))
+	assert.Equal(t, expiresAt, queue.ExpiresAt, "never shortened")
+
+	queue.ExtendExpiry(expiresAt.Add(time.Hour))
+	assert.Equal(t, expiresAt.Add(time.Hour), queue.ExpiresAt)
+}
+
+func TestQueue_HasLease(t *testing.T) {
+	tests := []struct {
+		name      string
+		owner     sql.NullString
+		expiresAt sql.NullTime
+		expected  bool
+	}{
+		{
+			name:      "Held by owner",
+			owner:     sql.NullString{String: "worker-1", Valid: true},
+			expiresAt: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+			expected:  true,
+		},
+		{
+			name:      "Held by another worker",
+			owner:     sql.NullString{String: "worker-2", Valid: true},
+			expiresAt: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+			expected:  false,
+		},
+		{
+			name:      "Expired",
+			owner:     sql.NullString{String: "worker-1", Valid: true},
+			expiresAt: sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true},
+			expected:  false,
+		},
+		{
+			name:     "Not leased",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := NewQueue(1, 1)
+			queue.LeaseOwner = tt.owner
+			queue.LeaseExpiresAt = tt.expiresAt
+
+			assert.Equal(t, tt.expected, queue.HasLease("worker-1"))
+		})
+	}
+}
+
+func TestQueue_StatusChangesReleaseLease(t *testing.T) {
+	lease := func() Queue {
+		queue := NewQueue(1, 1)
+		queue.LeaseOwner = sql.NullString{String: "worker-1", Valid: true}
+		queue.LeaseExpiresAt = sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}
+		return queue
+	}
+
+	sent := lease()
+	sent.MarkSent()
+	failed := lease()
+	failed.MarkFailed(errors.New("timeout"), time.Minute)
+	deferred := lease()
+	deferred.Defer(time.Now().Add(time.Minute))
+
+	for _, queue := range []Queue{sent, failed, deferred} {
+		assert.False(t, queue.LeaseOwner.Valid)
+		assert.False(t, queue.LeaseExpiresAt.Valid)
+	}
+}
+
 func TestQueue_MarkSent(t *testing.T) {
 	queue := NewQueue(1, 1)
 	queue.AttemptCount = 3 // Had some retries before success
@@ -10,12 +10,15 @@ import "time"
 //   - Is associated with a client (tenant/organization)
 //   - Can have multiple subscriptions to different topics
 //   - Can be activated/deactivated
+//   - Can limit its delivery rate (token bucket: RateLimit per second, RateBurst at once)
 type Subscriber struct {
-	ID         int64     `json:"id"`                          // Unique subscriber ID
+	ID         int64     `json:"id" db:"id"`                  // Unique subscriber ID
 	ClientID   int64     `json:"clientID" db:"client_id"`     // Associated client/tenant ID
-	Name       string    `json:"name"`                        // Subscriber name
+	Name       string    `json:"name" db:"name"`              // Subscriber name
 	WebhookURL string    `json:"webhookURL" db:"webhook_url"` // HTTP endpoint for message delivery
 	IsActive   bool      `json:"isActive" db:"is_active"`     // Only active subscribers receive messages
+	RateLimit  float64   `json:"rateLimit" db:"rate_limit"`   // Max deliveries per second (0 = unlimited)
+	RateBurst  int       `json:"rateBurst" db:"rate_burst"`   // Deliveries allowed at once (0 = derived from RateLimit)
 	CreatedAt  time.Time `json:"createdAt" db:"created_at"`   // Subscriber registration time
 }
 
@@ -12,17 +12,22 @@ import (
 //   - Links a subscriber to a topic
 //   - Filters messages by identifier (e.g., "user-123")
 //   - Can be activated/deactivated (soft delete)
+//   - Can be paused to hold delivery while messages keep being queued
 //   - Creates queue items when matching messages are published
 //
 // Lifecycle: Active subscriptions receive new messages, inactive ones don't.
+// Paused subscriptions keep receiving queue items, but they are not delivered until resumed.
 type Subscription struct {
-	ID           int64        `json:"id"`           // Unique subscription ID
-	SubscriberID int64        `json:"subscriberID"` // Subscriber who owns this subscription
-	TopicID      int64        `json:"topicID"`      // Topic being subscribed to
-	Identifier   string       `json:"identifier"`   // Event identifier filter
-	IsActive     bool         `json:"isActive"`     // Active subscriptions receive messages
-	CreatedAt    time.Time    `json:"createdAt"`    // Subscription creation time
-	DeletedAt    sql.NullTime `json:"deletedAt"`    // Soft delete timestamp
+	ID            int64        `json:"id" db:"id"`                         // Unique subscription ID
+	SubscriberID  int64        `json:"subscriberID" db:"subscriber_id"`    // Subscriber who owns this subscription
+	TopicID       int64        `json:"topicID" db:"topic_id"`              // Topic being subscribed to
+	Identifier    string       `json:"identifier" db:"identifier"`         // Event identifier filter
+	IsActive      bool         `json:"isActive" db:"is_active"`            // Active subscriptions receive messages
+	IsPaused      bool         `json:"isPaused" db:"is_paused"`            // Delivery is held (see IsPausedAt)
+	ResumeAt      sql.NullTime `json:"resumeAt" db:"resume_at"`            // Automatic resume time (NULL = until resumed)
+	MaxQueueDepth int          `json:"maxQueueDepth" db:"max_queue_depth"` // Max undelivered queue items (0 = publisher default)
+	CreatedAt     time.Time    `json:"createdAt" db:"created_at"`          // Subscription creation time
+	DeletedAt     sql.NullTime `json:"deletedAt" db:"deleted_at"`          // Soft delete timestamp
 }
 
 // TableName returns the database table name for Subscription.
@@ -57,6 +62,25 @@ func (m *Subscription) Deactivate() {
 	m.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
 }
 
+// Pause holds delivery for the subscription until resumeAt.
+// A zero resumeAt pauses until Resume is called.
+func (m *Subscription) Pause(resumeAt time.Time) {
+	m.IsPaused = true
+	m.ResumeAt = sql.NullTime{Time: resumeAt, Valid: !resumeAt.IsZero()}
+}
+
+// Resume releases held deliveries.
+func (m *Subscription) Resume() {
+	m.IsPaused = false
+	m.ResumeAt = sql.NullTime{}
+}
+
+// IsPausedAt reports whether delivery is held at the given time.
+// A pause with a resume time ends automatically once that time has passed.
+func (m *Subscription) IsPausedAt(now time.Time) bool {
+	return m.IsPaused && (!m.ResumeAt.Valid || now.Before(m.ResumeAt.Time))
+}
+
 // SubscriptionFull is an extended subscription view with denormalized fields.
 // Used by queries that need subscription details along with statistics and webhook URLs.
 type SubscriptionFull struct {
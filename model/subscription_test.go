@@ -56,6 +56,38 @@ func TestSubscription_DeactivateIdempotent(t *testing.T) {
 	assert.True(t, sub.DeletedAt.Valid)
 }
 
+func TestSubscription_PauseResume(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		resumeAt time.Time
+		at       time.Time
+		paused   bool
+	}{
+		{"until resumed", time.Time{}, now.Add(24 * time.Hour), true},
+		{"before resume time", now.Add(time.Hour), now, true},
+		{"after resume time", now.Add(time.Hour), now.Add(2 * time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := NewSubscription(100, 200, "test.event", "")
+			assert.False(t, sub.IsPausedAt(now))
+
+			sub.Pause(tt.resumeAt)
+			assert.True(t, sub.IsPaused)
+			assert.Equal(t, !tt.resumeAt.IsZero(), sub.ResumeAt.Valid)
+			assert.Equal(t, tt.paused, sub.IsPausedAt(tt.at))
+
+			sub.Resume()
+			assert.False(t, sub.IsPaused)
+			assert.False(t, sub.ResumeAt.Valid)
+			assert.False(t, sub.IsPausedAt(tt.at))
+		})
+	}
+}
+
 func TestSubscriptionFull_TableName(t *testing.T) {
 	sf := SubscriptionFull{}
 	assert.Equal(t, "pubsub_subscription", sf.TableName())
@@ -10,12 +10,16 @@ import "time"
 //
 // Topics can be hierarchical using dot notation (e.g., "user.created", "order.payment.completed").
 type Topic struct {
-	ID          int64     `json:"id"`                        // Unique topic ID
-	Code        string    `json:"code" db:"topic_code"`      // Unique topic code (e.g., "user.signup")
-	Name        string    `json:"name"`                      // Human-readable topic name
-	Description string    `json:"description"`               // Topic purpose and details
-	IsActive    bool      `json:"isActive" db:"is_active"`   // Only active topics accept new messages
-	CreatedAt   time.Time `json:"createdAt" db:"created_at"` // Topic creation time
+	ID          int64     `json:"id" db:"id"`                   // Unique topic ID
+	Code        string    `json:"code" db:"code"`               // Unique topic code (e.g., "user.signup")
+	Name        string    `json:"name" db:"name"`               // Human-readable topic name
+	Description string    `json:"description" db:"description"` // Topic purpose and details
+	IsActive    bool      `json:"isActive" db:"is_active"`      // Only active topics accept new messages
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`    // Topic creation time
+
+	MessageTTLSeconds int  `json:"messageTTLSeconds" db:"message_ttl_seconds"` // How long messages stay deliverable (0 = DefaultQueueTTL)
+	DropExpired       bool `json:"dropExpired" db:"drop_expired"`              // Delete expired undelivered messages instead of dead-lettering them
+	RetentionDays     int  `json:"retentionDays" db:"retention_days"`          // Purge messages older than this once fully handled (0 = keep forever)
 }
 
 // TableName returns the database table name for Topic.
@@ -23,6 +27,24 @@ func (t Topic) TableName() string {
 	return tablePrefix + "topic"
 }
 
+// MessageTTL returns how long messages of the topic stay deliverable.
+// Returns 0 if the topic has no TTL (DefaultQueueTTL applies).
+func (t Topic) MessageTTL() time.Duration {
+	if t.MessageTTLSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(t.MessageTTLSeconds) * time.Second
+}
+
+// Retention returns how long messages of the topic are kept.
+// Returns 0 if messages are kept forever.
+func (t Topic) Retention() time.Duration {
+	if t.RetentionDays <= 0 {
+		return 0
+	}
+	return time.Duration(t.RetentionDays) * 24 * time.Hour
+}
+
 // NewTopic creates a new active topic.
 //
 // Parameters:
@@ -25,3 +25,19 @@ func TestNewTopic(t *testing.T) {
 	assert.Equal(t, description, topic.Description)
 	assert.WithinDuration(t, time.Now(), topic.CreatedAt, time.Second)
 }
+
+func TestTopic_MessageTTL(t *testing.T) {
+	topic := NewTopic("presence", "Presence", "")
+	assert.Zero(t, topic.MessageTTL(), "unset uses the default")
+
+	topic.MessageTTLSeconds = 30
+	assert.Equal(t, 30*time.Second, topic.MessageTTL())
+}
+
+func TestTopic_Retention(t *testing.T) {
+	topic := NewTopic("audit", "Audit", "")
+	assert.Zero(t, topic.Retention(), "kept forever by default")
+
+	topic.RetentionDays = 7
+	assert.Equal(t, 7*24*time.Hour, topic.Retention())
+}
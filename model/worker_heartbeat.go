@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// WorkerHeartbeat records that a queue worker instance is alive.
+// Partitioned workers refresh their heartbeat periodically and split the queue
+// partitions among all workers with a recent heartbeat.
+type WorkerHeartbeat struct {
+	WorkerID    string    `json:"workerID" db:"worker_id"`       // Worker instance (lease owner name)
+	HeartbeatAt time.Time `json:"heartbeatAt" db:"heartbeat_at"` // Last time the worker reported alive
+}
+
+// TableName returns the database table name for WorkerHeartbeat.
+func (m WorkerHeartbeat) TableName() string {
+	return tablePrefix + "worker_heartbeat"
+}
+
+// IsAlive reports whether the heartbeat is at or after since.
+func (m WorkerHeartbeat) IsAlive(since time.Time) bool {
+	return !m.HeartbeatAt.Before(since)
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHeartbeat_TableName(t *testing.T) {
+	assert.Equal(t, "pubsub_worker_heartbeat", WorkerHeartbeat{}.TableName())
+}
+
+func TestWorkerHeartbeat_IsAlive(t *testing.T) {
+	now := time.Now()
+	heartbeat := WorkerHeartbeat{WorkerID: "worker-1", HeartbeatAt: now}
+
+	assert.True(t, heartbeat.IsAlive(now.Add(-time.Second)))
+	assert.True(t, heartbeat.IsAlive(now))
+	assert.False(t, heartbeat.IsAlive(now.Add(time.Second)))
+}
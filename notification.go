@@ -26,6 +26,21 @@ type NotificationService interface {
 	NotifySubscriptionDeactivated(ctx context.Context, subscription model.Subscription) error
 }
 
+// CircuitStateNotifier is an optional interface a NotificationService can implement
+// to be told about circuit breaker state changes (see WithCircuitBreaker).
+type CircuitStateNotifier interface {
+	// NotifyCircuitStateChanged is called when a subscriber's circuit breaker changes state.
+	NotifyCircuitStateChanged(ctx context.Context, change CircuitStateChange) error
+}
+
+// BacklogNotifier is an optional interface a NotificationService can implement
+// to be told about growing subscription backlogs (see WithBackpressure).
+type BacklogNotifier interface {
+	// NotifyBacklogHighWater is called when a publish makes a subscription's backlog cross
+	// its high-water mark.
+	NotifyBacklogHighWater(ctx context.Context, alert BacklogAlert) error
+}
+
 // NoOpNotificationService is a no-op implementation of NotificationService.
 // Use this when notifications are not needed.
 type NoOpNotificationService struct{}
@@ -50,6 +65,16 @@ func (n *NoOpNotificationService) NotifySubscriptionDeactivated(_ context.Contex
 	return nil
 }
 
+// NotifyCircuitStateChanged does nothing.
+func (n *NoOpNotificationService) NotifyCircuitStateChanged(_ context.Context, _ CircuitStateChange) error {
+	return nil
+}
+
+// NotifyBacklogHighWater does nothing.
+func (n *NoOpNotificationService) NotifyBacklogHighWater(_ context.Context, _ BacklogAlert) error {
+	return nil
+}
+
 // LoggingNotificationService is a simple implementation that logs notifications.
 type LoggingNotificationService struct {
 	logger Logger
@@ -87,3 +112,22 @@ func (n *LoggingNotificationService) NotifySubscriptionDeactivated(_ context.Con
 		subscription.ID, subscription.SubscriberID)
 	return nil
 }
+
+// NotifyCircuitStateChanged logs circuit breaker state transitions.
+func (n *LoggingNotificationService) NotifyCircuitStateChanged(_ context.Context, change CircuitStateChange) error {
+	if change.To == CircuitOpen {
+		n.logger.Warnf("🔌 Circuit opened: subscriber_id=%d, failures=%d",
+			change.SubscriberID, change.Failures)
+		return nil
+	}
+	n.logger.Infof("🔌 Circuit %s → %s: subscriber_id=%d",
+		change.From, change.To, change.SubscriberID)
+	return nil
+}
+
+// NotifyBacklogHighWater logs a subscription backlog crossing its high-water mark.
+func (n *LoggingNotificationService) NotifyBacklogHighWater(_ context.Context, alert BacklogAlert) error {
+	n.logger.Warnf("📈 Backlog high-water mark reached: subscription_id=%d, depth=%d, high_water=%d, max=%d",
+		alert.SubscriptionID, alert.Depth, alert.HighWaterMark, alert.MaxDepth)
+	return nil
+}
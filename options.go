@@ -2,6 +2,7 @@ package pubsub
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/coregx/pubsub/retry"
 )
@@ -83,6 +84,37 @@ func WithDelivery(
 	}
 }
 
+// WithInterceptors wraps the delivery gateway with interceptors.
+// This is an optional configuration - by default deliveries go straight to the gateway.
+//
+// Interceptors are applied in order: the first one is the outermost and sees each
+// delivery first. Repeated calls append to the chain. Use InterceptDelivery for
+// interceptors that need the queue item or subscription.
+func WithInterceptors(interceptors ...DeliveryInterceptor) Option {
+	return func(w *QueueWorker) error {
+		for i, interceptor := range interceptors {
+			if interceptor == nil {
+				return fmt.Errorf("interceptor %d cannot be nil", i)
+			}
+		}
+		w.interceptors = append(w.interceptors, interceptors...)
+		return nil
+	}
+}
+
+// WithTopicRepository sets the topic repository used for per-topic settings.
+// This is an optional configuration - without it, topic settings such as
+// model.Topic.DropExpired are not applied (expired items are always dead-lettered).
+func WithTopicRepository(repo TopicRepository) Option {
+	return func(w *QueueWorker) error {
+		if repo == nil {
+			return fmt.Errorf("topic repository cannot be nil")
+		}
+		w.tr = repo
+		return nil
+	}
+}
+
 // WithLogger sets the logger instance for the queue worker.
 // Logger is required and must not be nil.
 //
@@ -136,6 +168,141 @@ func WithBatchSize(size int) Option {
 	}
 }
 
+// WithConcurrency sets the number of queue items delivered in parallel within a batch.
+// This is an optional configuration - default is 1 (items are delivered one by one).
+//
+// Must be > 0. Higher values increase throughput when webhooks are slow, since batch
+// duration is no longer the sum of all delivery latencies. The gateway, logger and
+// notification service must be safe for concurrent use when n > 1.
+func WithConcurrency(n int) Option {
+	return func(w *QueueWorker) error {
+		if n <= 0 {
+			return fmt.Errorf("concurrency must be > 0, got %d", n)
+		}
+		w.concurrency = n
+		return nil
+	}
+}
+
+// WithWorkerID sets the name this worker uses as lease owner when claiming queue items.
+// This is an optional configuration - default is derived from hostname and process ID
+// plus a random suffix, which is unique per process.
+//
+// Set it to a stable identifier (e.g. the pod name) to see in the queue table which
+// replica holds an item.
+func WithWorkerID(id string) Option {
+	return func(w *QueueWorker) error {
+		if id == "" {
+			return fmt.Errorf("worker ID cannot be empty")
+		}
+		w.workerID = id
+		return nil
+	}
+}
+
+// WithPartitioning makes the worker claim only items of subscriptions in its partitions.
+// This is an optional configuration - by default every worker claims from the whole queue.
+//
+// Use it to scale out with per-subscription locality: all deliveries of a subscription are
+// handled by the instance owning its partition. With cfg.Heartbeats the partitions are
+// rebalanced automatically as instances join or leave (see PartitionConfig); give each
+// instance a stable WithWorkerID so restarts keep their partitions.
+func WithPartitioning(cfg PartitionConfig) Option {
+	return func(w *QueueWorker) error {
+		if err := cfg.validate(); err != nil {
+			return err
+		}
+		w.partitionConfig = &cfg
+		return nil
+	}
+}
+
+// WithLeaderElection runs singleton maintenance tasks (expired item cleanup, retention) only on the
+// elected leader among workers sharing the lock table. Delivery stays spread across all workers.
+// This is an optional configuration - by default every worker runs maintenance.
+//
+// Leadership is a lock held for leaseDuration and renewed every third of it by Run; when the
+// leader stops, another worker takes over right away, when it crashes, after leaseDuration.
+// Give each instance a unique WithWorkerID (the default is unique per process).
+func WithLeaderElection(locks LockRepository, leaseDuration time.Duration) Option {
+	return func(w *QueueWorker) error {
+		if locks == nil {
+			return fmt.Errorf("lock repository cannot be nil")
+		}
+		if leaseDuration <= 0 {
+			return fmt.Errorf("leader lease duration must be > 0, got %v", leaseDuration)
+		}
+		w.locks = locks
+		w.leaderLease = leaseDuration
+		return nil
+	}
+}
+
+// WithRetention purges messages once they are older than their topic's retention period
+// (Topic.RetentionDays) and fully handled, optionally archiving them first (see RetentionConfig).
+// This is an optional configuration - by default messages are kept forever.
+//
+// Requires WithTopicRepository. Combine with WithLeaderElection so only one worker purges.
+func WithRetention(cfg RetentionConfig) Option {
+	return func(w *QueueWorker) error {
+		if err := cfg.validate(); err != nil {
+			return err
+		}
+		cfg = cfg.withDefaults()
+		w.retention = &cfg
+		return nil
+	}
+}
+
+// WithLeaseDuration sets how long claimed queue items are reserved for this worker.
+// This is an optional configuration - default is 5 minutes.
+//
+// Must be > 0 and longer than a batch takes to process: items are skipped once their
+// lease has expired, and items of a crashed worker are only claimed again after expiry.
+func WithLeaseDuration(d time.Duration) Option {
+	return func(w *QueueWorker) error {
+		if d <= 0 {
+			return fmt.Errorf("lease duration must be > 0, got %v", d)
+		}
+		w.leaseDuration = d
+		return nil
+	}
+}
+
+// WithWakeup makes Run process a batch as soon as source signals new queue items,
+// in addition to the regular interval. This is an optional configuration - by default
+// Run only polls on its interval.
+//
+// The interval remains the fallback for missed signals, retries and cleanup, so it can stay
+// long while first delivery happens within milliseconds of publishing.
+// Use NewChannelWakeup in a single process or adapters/postgres.Waker across processes.
+func WithWakeup(source WakeupSource) Option {
+	return func(w *QueueWorker) error {
+		if source == nil {
+			return fmt.Errorf("wakeup source cannot be nil")
+		}
+		w.wakeup = source
+		return nil
+	}
+}
+
+// WithAdaptivePolling makes Run adapt its poll delay to the queue load.
+// This is an optional configuration - by default Run polls at a fixed interval.
+//
+// Run's interval becomes the base delay. When a batch comes back full, the next batch starts
+// right away. When the queue is empty, the delay doubles after every idle poll up to maxInterval,
+// and snaps back to the interval once items show up again.
+// Must be > 0; values below Run's interval have no effect.
+func WithAdaptivePolling(maxInterval time.Duration) Option {
+	return func(w *QueueWorker) error {
+		if maxInterval <= 0 {
+			return fmt.Errorf("max poll interval must be > 0, got %v", maxInterval)
+		}
+		w.maxPollInterval = maxInterval
+		return nil
+	}
+}
+
 // WithNotifications sets an optional notification service for the queue worker.
 // This is an optional configuration - if not provided, NoOpNotificationService will be used (no notifications).
 //
@@ -153,3 +320,65 @@ func WithNotifications(service NotificationService) Option {
 		return nil
 	}
 }
+
+// WithCircuitBreaker enables a per-subscriber circuit breaker.
+// This is an optional configuration - by default every queue item is attempted.
+//
+// After config.FailureThreshold consecutive transient failures the subscriber's circuit opens:
+// its queue items are rescheduled without a delivery attempt (and without counting an attempt)
+// until config.OpenDuration has passed. Then a single probe delivery is allowed; success closes
+// the circuit, failure opens it again. State changes are reported to a NotificationService
+// that implements CircuitStateNotifier.
+//
+// Use DefaultCircuitBreakerConfig() for sensible defaults.
+func WithCircuitBreaker(config CircuitBreakerConfig) Option {
+	return func(w *QueueWorker) error {
+		if err := config.validate(); err != nil {
+			return err
+		}
+		w.circuitBreaker = newCircuitBreaker(config)
+		return nil
+	}
+}
+
+// WithRateLimiting enables per-subscriber delivery rate limits from provider.
+// This is an optional configuration - by default deliveries are not rate limited.
+//
+// Each subscriber gets a token bucket sized by its RateLimit. Items exceeding the limit
+// are rescheduled to when the next token is available, without counting a delivery attempt,
+// so AttemptCount and the DLQ threshold are not affected. Items deferred together are spread
+// one token interval apart.
+//
+// SubscriberTransmitterProvider can be passed as provider. Passing the same instance to
+// WithDelivery loads each subscriber once per attempt (see DeliveryTargetProvider).
+func WithRateLimiting(provider RateLimitProvider) Option {
+	return func(w *QueueWorker) error {
+		if provider == nil {
+			return fmt.Errorf("rate limit provider cannot be nil")
+		}
+		if w.rateLimiter == nil {
+			w.rateLimiter = newRateLimiter()
+		}
+		w.rateLimitProvider = provider
+		return nil
+	}
+}
+
+// WithHostRateLimit limits deliveries per destination host (the callback URL's host:port),
+// shared by all subscribers using that host.
+// This is an optional configuration and can be combined with WithRateLimiting.
+func WithHostRateLimit(limit RateLimit) Option {
+	return func(w *QueueWorker) error {
+		if limit.Unlimited() {
+			return fmt.Errorf("host rate limit must be > 0, got %v", limit.PerSecond)
+		}
+		if limit.Burst < 0 {
+			return fmt.Errorf("host rate limit burst must be >= 0, got %d", limit.Burst)
+		}
+		if w.rateLimiter == nil {
+			w.rateLimiter = newRateLimiter()
+		}
+		w.rateLimiter.hostLimit = limit
+		return nil
+	}
+}
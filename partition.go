@@ -0,0 +1,207 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/coregx/pubsub/model"
+)
+
+// DefaultHeartbeatInterval is how often partitioned workers refresh their heartbeat
+// and partition assignment when PartitionConfig.HeartbeatInterval is not set.
+const DefaultHeartbeatInterval = 10 * time.Second
+
+// PartitionOf returns the partition of a subscription: its ID modulo count.
+// All queue items of a subscription are in the same partition.
+func PartitionOf(subscriptionID int64, count int) int {
+	return int(subscriptionID % int64(count))
+}
+
+// PartitionSet selects the queue partitions a worker claims from.
+type PartitionSet struct {
+	Count int   // Total number of partitions
+	Owned []int // Owned partitions, each in [0, Count)
+}
+
+// Contains reports whether the subscription's partition is owned.
+func (p PartitionSet) Contains(subscriptionID int64) bool {
+	return p.Count > 0 && slices.Contains(p.Owned, PartitionOf(subscriptionID, p.Count))
+}
+
+// PartitionConfig configures partitioned queue processing (see WithPartitioning).
+//
+// Subscriptions are split into Count partitions (see PartitionOf) and each worker instance
+// only claims items of the partitions it owns, which keeps a subscription's deliveries,
+// ordering and caches on one instance.
+//
+// With Heartbeats set, partitions are assigned dynamically: every worker records a heartbeat
+// and the partitions are split round-robin among the workers with a recent heartbeat (sorted
+// by worker ID, see WithWorkerID). When instances join or leave, the assignment is rebalanced
+// within one heartbeat interval (or HeartbeatTimeout for instances that stopped without Stop).
+// Leases still prevent double delivery while workers briefly disagree about the assignment.
+//
+// Without Heartbeats, the worker owns the static Assigned partitions.
+type PartitionConfig struct {
+	Count      int                 // Number of partitions, must be > 0
+	Heartbeats HeartbeatRepository // Heartbeat table for dynamic assignment (nil = static Assigned)
+	Assigned   []int               // Static partitions when Heartbeats is nil
+
+	HeartbeatInterval time.Duration // How often to heartbeat and rebalance (default: DefaultHeartbeatInterval)
+	HeartbeatTimeout  time.Duration // Heartbeat age after which a worker is considered gone (default: 3 intervals)
+}
+
+// validate checks the configuration values.
+func (c PartitionConfig) validate() error {
+	if c.Count <= 0 {
+		return fmt.Errorf("partition count must be > 0, got %d", c.Count)
+	}
+	if c.HeartbeatInterval < 0 || c.HeartbeatTimeout < 0 {
+		return fmt.Errorf("heartbeat interval and timeout must be >= 0")
+	}
+	if c.HeartbeatTimeout > 0 && c.HeartbeatTimeout <= c.HeartbeatInterval {
+		return fmt.Errorf("heartbeat timeout (%v) must be longer than the interval (%v)", c.HeartbeatTimeout, c.HeartbeatInterval)
+	}
+	if c.Heartbeats == nil && len(c.Assigned) == 0 {
+		return fmt.Errorf("either a heartbeat repository or assigned partitions are required")
+	}
+	for _, partition := range c.Assigned {
+		if partition < 0 || partition >= c.Count {
+			return fmt.Errorf("assigned partition %d out of range [0, %d)", partition, c.Count)
+		}
+	}
+	return nil
+}
+
+// partitioner tracks the partitions owned by a worker.
+//
+// Thread safety: Safe for concurrent use.
+type partitioner struct {
+	heartbeats HeartbeatRepository // nil = static assignment
+	count      int
+	interval   time.Duration
+	timeout    time.Duration
+	workerID   string
+	logger     Logger
+
+	mu          sync.Mutex
+	owned       []int
+	refreshedAt time.Time // Last successful refresh (zero = never)
+}
+
+// newPartitioner creates a partitioner for a validated configuration.
+func newPartitioner(cfg PartitionConfig, workerID string, logger Logger) *partitioner {
+	p := &partitioner{
+		heartbeats: cfg.Heartbeats,
+		count:      cfg.Count,
+		interval:   cfg.HeartbeatInterval,
+		timeout:    cfg.HeartbeatTimeout,
+		workerID:   workerID,
+		logger:     logger,
+	}
+	if p.interval == 0 {
+		p.interval = DefaultHeartbeatInterval
+	}
+	if p.timeout == 0 {
+		p.timeout = 3 * p.interval
+	}
+	if p.heartbeats == nil {
+		p.owned = slices.Sorted(slices.Values(cfg.Assigned))
+		p.owned = slices.Compact(p.owned)
+	}
+	return p
+}
+
+// assigned returns the owned partitions, refreshing a stale dynamic assignment first.
+func (p *partitioner) assigned(ctx context.Context) PartitionSet {
+	p.mu.Lock()
+	stale := p.heartbeats != nil && time.Since(p.refreshedAt) >= p.interval
+	p.mu.Unlock()
+
+	if stale {
+		if err := p.refresh(ctx); err != nil {
+			p.logger.Warnf("Failed to refresh partition assignment (keeping %v): %v", p.current().Owned, err)
+		}
+	}
+	return p.current()
+}
+
+// current returns the owned partitions without refreshing.
+func (p *partitioner) current() PartitionSet {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PartitionSet{Count: p.count, Owned: slices.Clone(p.owned)}
+}
+
+// refresh records the worker's heartbeat and recomputes the assignment from the live workers.
+func (p *partitioner) refresh(ctx context.Context) error {
+	now := time.Now()
+	if err := p.heartbeats.Save(ctx, model.WorkerHeartbeat{WorkerID: p.workerID, HeartbeatAt: now}); err != nil {
+		return fmt.Errorf("failed to save heartbeat: %w", err)
+	}
+
+	alive, err := p.heartbeats.FindAlive(ctx, now.Add(-p.timeout))
+	if err != nil && !IsNoData(err) {
+		return fmt.Errorf("failed to find live workers: %w", err)
+	}
+	workers := make([]string, 0, len(alive)+1)
+	for _, heartbeat := range alive {
+		workers = append(workers, heartbeat.WorkerID)
+	}
+	if !slices.Contains(workers, p.workerID) {
+		workers = append(workers, p.workerID) // Our own heartbeat may not be visible yet
+	}
+	slices.Sort(workers)
+	owned := assignPartitions(p.count, workers, p.workerID)
+
+	p.mu.Lock()
+	changed := p.refreshedAt.IsZero() || !slices.Equal(owned, p.owned)
+	p.owned = owned
+	p.refreshedAt = now
+	p.mu.Unlock()
+
+	if changed {
+		p.logger.Infof("Partition assignment: owned=%v of %d partitions (workers=%d)", owned, p.count, len(workers))
+	}
+	return nil
+}
+
+// run refreshes the assignment every interval until ctx is canceled, then removes the
+// worker's heartbeat so the others take over its partitions right away.
+func (p *partitioner) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			leaveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.interval)
+			if err := p.heartbeats.Delete(leaveCtx, p.workerID); err != nil {
+				p.logger.Warnf("Failed to remove heartbeat of worker %s: %v", p.workerID, err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if err := p.refresh(ctx); err != nil && ctx.Err() == nil {
+				p.logger.Warnf("Failed to refresh partition assignment: %v", err)
+			}
+		}
+	}
+}
+
+// assignPartitions splits count partitions round-robin among the sorted workers
+// and returns those of self.
+func assignPartitions(count int, workers []string, self string) []int {
+	index := slices.Index(workers, self)
+	if index < 0 {
+		return nil
+	}
+
+	var owned []int
+	for partition := index; partition < count; partition += len(workers) {
+		owned = append(owned, partition)
+	}
+	return owned
+}
@@ -0,0 +1,228 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coregx/pubsub/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testHeartbeats is an in-memory HeartbeatRepository.
+type testHeartbeats struct {
+	mu         sync.Mutex
+	heartbeats map[string]time.Time
+	findErr    error
+}
+
+func newTestHeartbeats() *testHeartbeats {
+	return &testHeartbeats{heartbeats: make(map[string]time.Time)}
+}
+
+func (h *testHeartbeats) Save(_ context.Context, m model.WorkerHeartbeat) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.heartbeats[m.WorkerID] = m.HeartbeatAt
+	return nil
+}
+
+func (h *testHeartbeats) FindAlive(_ context.Context, since time.Time) ([]model.WorkerHeartbeat, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.findErr != nil {
+		return nil, h.findErr
+	}
+
+	var alive []model.WorkerHeartbeat
+	for workerID, at := range h.heartbeats {
+		if !at.Before(since) {
+			alive = append(alive, model.WorkerHeartbeat{WorkerID: workerID, HeartbeatAt: at})
+		}
+	}
+	if len(alive) == 0 {
+		return nil, ErrNoData
+	}
+	slices.SortFunc(alive, func(a, b model.WorkerHeartbeat) int { return strings.Compare(a.WorkerID, b.WorkerID) })
+	return alive, nil
+}
+
+func (h *testHeartbeats) Delete(_ context.Context, workerID string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.heartbeats, workerID)
+	return nil
+}
+
+func TestPartitionSet_Contains(t *testing.T) {
+	set := PartitionSet{Count: 4, Owned: []int{1, 3}}
+
+	tests := []struct {
+		subscriptionID int64
+		want           bool
+	}{
+		{1, true}, {3, true}, {5, true}, {7, true},
+		{0, false}, {2, false}, {4, false}, {6, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, set.Contains(tt.subscriptionID), "subscription %d", tt.subscriptionID)
+	}
+	assert.False(t, PartitionSet{}.Contains(1), "no partitions")
+}
+
+func TestPartitionConfig_Validate(t *testing.T) {
+	heartbeats := newTestHeartbeats()
+
+	tests := []struct {
+		name    string
+		config  PartitionConfig
+		wantErr bool
+	}{
+		{"dynamic", PartitionConfig{Count: 4, Heartbeats: heartbeats}, false},
+		{"static", PartitionConfig{Count: 4, Assigned: []int{0, 3}}, false},
+		{"zero count", PartitionConfig{Count: 0, Heartbeats: heartbeats}, true},
+		{"neither heartbeats nor assigned", PartitionConfig{Count: 4}, true},
+		{"assigned out of range", PartitionConfig{Count: 4, Assigned: []int{4}}, true},
+		{"negative assigned", PartitionConfig{Count: 4, Assigned: []int{-1}}, true},
+		{"negative interval", PartitionConfig{Count: 4, Heartbeats: heartbeats, HeartbeatInterval: -time.Second}, true},
+		{"timeout not above interval", PartitionConfig{Count: 4, Heartbeats: heartbeats,
+			HeartbeatInterval: time.Second, HeartbeatTimeout: time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAssignPartitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		count   int
+		workers []string
+		want    map[string][]int
+	}{
+		{
+			name:    "single worker owns everything",
+			count:   4,
+			workers: []string{"a"},
+			want:    map[string][]int{"a": {0, 1, 2, 3}},
+		},
+		{
+			name:    "second worker joins",
+			count:   4,
+			workers: []string{"a", "b"},
+			want:    map[string][]int{"a": {0, 2}, "b": {1, 3}},
+		},
+		{
+			name:    "third worker joins",
+			count:   4,
+			workers: []string{"a", "b", "c"},
+			want:    map[string][]int{"a": {0, 3}, "b": {1}, "c": {2}},
+		},
+		{
+			name:    "middle worker leaves",
+			count:   4,
+			workers: []string{"a", "c"},
+			want:    map[string][]int{"a": {0, 2}, "c": {1, 3}},
+		},
+		{
+			name:    "more workers than partitions",
+			count:   2,
+			workers: []string{"a", "b", "c"},
+			want:    map[string][]int{"a": {0}, "b": {1}, "c": nil},
+		},
+		{
+			name:    "unknown worker owns nothing",
+			count:   4,
+			workers: []string{"a", "b"},
+			want:    map[string][]int{"z": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for worker, want := range tt.want {
+				assert.Equal(t, want, assignPartitions(tt.count, tt.workers, worker), "worker %s", worker)
+			}
+		})
+	}
+}
+
+func TestAssignPartitions_CoversEachPartitionOnce(t *testing.T) {
+	workers := []string{"a", "b", "c", "d", "e"}
+	for n := 1; n <= len(workers); n++ {
+		for _, count := range []int{1, 3, 8, 16} {
+			seen := make(map[int]int)
+			for _, worker := range workers[:n] {
+				for _, partition := range assignPartitions(count, workers[:n], worker) {
+					seen[partition]++
+				}
+			}
+			assert.Len(t, seen, count, "%d workers, %d partitions", n, count)
+			for partition, owners := range seen {
+				assert.Equal(t, 1, owners, "partition %d", partition)
+			}
+		}
+	}
+}
+
+func TestPartitioner_Static(t *testing.T) {
+	p := newPartitioner(PartitionConfig{Count: 4, Assigned: []int{3, 1, 3}}, "worker-a", &NoopLogger{})
+
+	assert.Equal(t, PartitionSet{Count: 4, Owned: []int{1, 3}}, p.assigned(context.Background()))
+}
+
+func TestPartitioner_RebalancesOnJoinAndLeave(t *testing.T) {
+	ctx := context.Background()
+	heartbeats := newTestHeartbeats()
+	config := PartitionConfig{Count: 4, Heartbeats: heartbeats, HeartbeatInterval: time.Hour}
+	a := newPartitioner(config, "worker-a", &NoopLogger{})
+	b := newPartitioner(config, "worker-b", &NoopLogger{})
+
+	// Alone: the first refresh records the heartbeat and takes every partition
+	assert.Equal(t, []int{0, 1, 2, 3}, a.assigned(ctx).Owned)
+	assert.Contains(t, heartbeats.heartbeats, "worker-a")
+
+	// worker-b joins; worker-a rebalances on its next refresh
+	assert.Equal(t, []int{1, 3}, b.assigned(ctx).Owned)
+	assert.Equal(t, []int{0, 1, 2, 3}, a.assigned(ctx).Owned, "no refresh within the interval")
+	require.NoError(t, a.refresh(ctx))
+	assert.Equal(t, []int{0, 2}, a.current().Owned)
+
+	// worker-b leaves
+	require.NoError(t, heartbeats.Delete(ctx, "worker-b"))
+	require.NoError(t, a.refresh(ctx))
+	assert.Equal(t, []int{0, 1, 2, 3}, a.current().Owned)
+
+	// worker-b stops heartbeating without leaving: it is dropped after the timeout
+	require.NoError(t, b.refresh(ctx))
+	require.NoError(t, a.refresh(ctx))
+	assert.Equal(t, []int{0, 2}, a.current().Owned)
+	heartbeats.heartbeats["worker-b"] = time.Now().Add(-3*time.Hour - time.Second)
+	require.NoError(t, a.refresh(ctx))
+	assert.Equal(t, []int{0, 1, 2, 3}, a.current().Owned)
+}
+
+func TestPartitioner_KeepsAssignmentOnError(t *testing.T) {
+	ctx := context.Background()
+	heartbeats := newTestHeartbeats()
+	p := newPartitioner(PartitionConfig{Count: 2, Heartbeats: heartbeats, HeartbeatInterval: time.Nanosecond}, "worker-a", &NoopLogger{})
+
+	require.Equal(t, []int{0, 1}, p.assigned(ctx).Owned)
+
+	heartbeats.findErr = errors.New("db down")
+	assert.Error(t, p.refresh(ctx))
+	assert.Equal(t, []int{0, 1}, p.assigned(ctx).Owned)
+}
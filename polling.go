@@ -0,0 +1,33 @@
+package pubsub
+
+import "time"
+
+// batchLoad describes how much work a batch found. It drives adaptive polling.
+type batchLoad int
+
+const (
+	batchIdle    batchLoad = iota // No items were claimed
+	batchPartial                  // Some items were claimed
+	batchFull                     // A full batch was claimed: more items are likely waiting
+)
+
+// nextPollDelay returns how long Run waits before the next batch.
+//
+// Without adaptive polling (see WithAdaptivePolling) it is always interval. Otherwise a full
+// batch is followed immediately by the next one, a batch with some items resets the delay to
+// interval, and every idle batch doubles the delay up to maxPollInterval.
+func (w *QueueWorker) nextPollDelay(load batchLoad, current, interval time.Duration) time.Duration {
+	if w.maxPollInterval <= 0 {
+		return interval
+	}
+
+	switch load {
+	case batchFull:
+		return 0
+	case batchPartial:
+		return interval
+	default:
+		next := max(current*2, interval)
+		return min(next, max(w.maxPollInterval, interval))
+	}
+}
@@ -0,0 +1,54 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueueWorker_NextPollDelay(t *testing.T) {
+	const interval = time.Second
+
+	tests := []struct {
+		name            string
+		maxPollInterval time.Duration
+		load            batchLoad
+		current         time.Duration
+		want            time.Duration
+	}{
+		{"fixed interval ignores a full batch", 0, batchFull, interval, interval},
+		{"fixed interval ignores an idle batch", 0, batchIdle, 4 * interval, interval},
+		{"full batch polls again immediately", time.Minute, batchFull, interval, 0},
+		{"partial batch resets to the interval", time.Minute, batchPartial, 8 * interval, interval},
+		{"idle after a full batch starts at the interval", time.Minute, batchIdle, 0, interval},
+		{"idle doubles the delay", time.Minute, batchIdle, 4 * interval, 8 * interval},
+		{"idle is capped at the max interval", time.Minute, batchIdle, 40 * interval, time.Minute},
+		{"max interval below the interval has no effect", interval / 2, batchIdle, interval, interval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &QueueWorker{maxPollInterval: tt.maxPollInterval}
+			assert.Equal(t, tt.want, w.nextPollDelay(tt.load, tt.current, interval))
+		})
+	}
+}
+
+func TestQueueWorker_NextPollDelay_IdleBackoff(t *testing.T) {
+	w := &QueueWorker{maxPollInterval: 10 * time.Second}
+
+	var delays []time.Duration
+	delay := time.Second
+	for range 6 {
+		delay = w.nextPollDelay(batchIdle, delay, time.Second)
+		delays = append(delays, delay)
+	}
+	assert.Equal(t, []time.Duration{
+		2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second, 10 * time.Second,
+	}, delays)
+
+	// Items show up again: back to the interval, then straight on while batches are full
+	assert.Equal(t, time.Second, w.nextPollDelay(batchPartial, delay, time.Second))
+	assert.Zero(t, w.nextPollDelay(batchFull, delay, time.Second))
+}
@@ -1,20 +1,34 @@
 package pubsub
 
 import (
+	"cmp"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/coregx/pubsub/model"
 )
 
+// DefaultPriorityAging is how much waiting time one priority level is worth by default
+// (see WithPriorityAging).
+const DefaultPriorityAging = 5 * time.Minute
+
 // Publisher handles publishing messages to topics and creating queue items
 // for active subscriptions.
 type Publisher struct {
-	messageRepo      MessageRepository
-	queueRepo        QueueRepository
-	subscriptionRepo SubscriptionRepository
-	topicRepo        TopicRepository
-	logger           Logger
+	messageRepo         MessageRepository
+	queueRepo           QueueRepository
+	subscriptionRepo    SubscriptionRepository
+	topicRepo           TopicRepository
+	logger              Logger
+	wakeup              WakeupNotifier // nil = workers find new items on their next poll
+	priorityAging       time.Duration  // Waiting time one priority level is worth
+	backpressure        BackpressureConfig
+	notificationService NotificationService
+	idempotencyWindow   time.Duration    // How long an idempotency key deduplicates publishes
+	outbox              OutboxRepository // nil = PublishTx is not available
 }
 
 // PublisherOption configures a Publisher.
@@ -26,6 +40,14 @@ type PublisherOption func(*Publisher) error
 //   - WithPublisherRepositories: message, queue, subscription, and topic repositories
 //   - WithPublisherLogger: logger instance
 //
+// Optional options:
+//   - WithPublisherWakeup: signal queue workers after publishing (default: none)
+//   - WithPriorityAging: waiting time one priority level is worth (default: DefaultPriorityAging)
+//   - WithBackpressure: max queue depth per subscription (default: Subscription.MaxQueueDepth only)
+//   - WithPublisherNotifications: notification service (default: no notifications)
+//   - WithIdempotencyWindow: how long idempotency keys deduplicate (default: DefaultIdempotencyWindow)
+//   - WithOutbox: transactional writes for PublishTx (default: PublishTx is not available)
+//
 // Example:
 //
 //	publisher, err := pubsub.NewPublisher(
@@ -33,7 +55,12 @@ type PublisherOption func(*Publisher) error
 //	    pubsub.WithPublisherLogger(logger),
 //	)
 func NewPublisher(opts ...PublisherOption) (*Publisher, error) {
-	p := &Publisher{}
+	p := &Publisher{
+		priorityAging:       DefaultPriorityAging,
+		backpressure:        BackpressureConfig{}.withDefaults(),
+		notificationService: &NoOpNotificationService{},
+		idempotencyWindow:   DefaultIdempotencyWindow,
+	}
 
 	for _, opt := range opts {
 		if err := opt(p); err != nil {
@@ -101,11 +128,126 @@ func WithPublisherLogger(logger Logger) PublisherOption {
 	}
 }
 
+// WithPublisherWakeup sets a notifier that wakes queue workers as soon as queue items
+// are created, so first delivery does not wait for the worker's poll interval.
+// Notification errors are logged and do not fail the publish.
+func WithPublisherWakeup(notifier WakeupNotifier) PublisherOption {
+	return func(p *Publisher) error {
+		if notifier == nil {
+			return fmt.Errorf("wakeup notifier cannot be nil")
+		}
+		p.wakeup = notifier
+		return nil
+	}
+}
+
+// WithPriorityAging sets how much waiting time one priority level is worth.
+// This is an optional configuration - default is DefaultPriorityAging (5 minutes).
+//
+// A message of priority p is delivered before lower-priority messages published up to
+// p × aging earlier; messages that have waited longer are delivered first, so low
+// priorities are never starved. Must be > 0.
+func WithPriorityAging(aging time.Duration) PublisherOption {
+	return func(p *Publisher) error {
+		if aging <= 0 {
+			return fmt.Errorf("priority aging must be > 0, got %v", aging)
+		}
+		p.priorityAging = aging
+		return nil
+	}
+}
+
+// WithBackpressure limits the backlog of undelivered queue items per subscription.
+// This is an optional configuration - by default only subscriptions with MaxQueueDepth are limited,
+// with the OverflowReject policy.
+//
+// When a subscription's queue is full, cfg.Policy rejects the publish (ErrQueueFull), drops the
+// oldest items or dead-letters the message for that subscription; PublishResult.Throttled reports
+// the affected subscriptions. Crossing the high-water mark fires NotifyBacklogHighWater if the
+// notification service implements BacklogNotifier (see WithPublisherNotifications).
+func WithBackpressure(cfg BackpressureConfig) PublisherOption {
+	return func(p *Publisher) error {
+		if err := cfg.validate(); err != nil {
+			return err
+		}
+		p.backpressure = cfg.withDefaults()
+		return nil
+	}
+}
+
+// WithPublisherNotifications sets an optional notification service for the publisher.
+// This is an optional configuration - by default no notifications are sent.
+//
+// The notification service receives backlog high-water alerts and DLQ additions
+// of dead-lettered overflow (see WithBackpressure).
+func WithPublisherNotifications(service NotificationService) PublisherOption {
+	return func(p *Publisher) error {
+		if service == nil {
+			return fmt.Errorf("notification service cannot be nil")
+		}
+		p.notificationService = service
+		return nil
+	}
+}
+
+// WithIdempotencyWindow sets how long a PublishRequest.IdempotencyKey deduplicates publishes.
+// This is an optional configuration - defaults to DefaultIdempotencyWindow.
+//
+// A key published again after the window creates a new message. Must be > 0.
+func WithIdempotencyWindow(window time.Duration) PublisherOption {
+	return func(p *Publisher) error {
+		if window <= 0 {
+			return fmt.Errorf("idempotency window must be > 0, got %v", window)
+		}
+		p.idempotencyWindow = window
+		return nil
+	}
+}
+
+// WithOutbox enables PublishTx with the repository that writes within the caller's transaction.
+// This is an optional configuration - without it, PublishTx returns a configuration error.
+func WithOutbox(outbox OutboxRepository) PublisherOption {
+	return func(p *Publisher) error {
+		if outbox == nil {
+			return fmt.Errorf("outbox repository cannot be nil")
+		}
+		p.outbox = outbox
+		return nil
+	}
+}
+
 // PublishRequest represents a request to publish a message.
 type PublishRequest struct {
 	TopicCode  string // Topic code to publish to
 	Identifier string // Message identifier (event type)
 	Data       string // Message payload
+
+	// OrderingKey groups messages that must be delivered in publish order (optional).
+	// Per subscription, a message with a key is only delivered after all earlier messages
+	// with the same key were delivered (or dead-lettered). Messages with other keys are not blocked.
+	OrderingKey string
+
+	// Priority from 0 (default) to model.MaxPriority (optional). Workers serve due items of
+	// higher priority first, with aging so lower priorities are not starved (see WithPriorityAging).
+	Priority int
+
+	// DeliverAt schedules the first delivery for a future time (optional, zero = immediately).
+	// The delivery window (expiry) starts at that time. Scheduled messages can be canceled
+	// with CancelScheduled until they become due.
+	DeliverAt time.Time
+
+	// Delay schedules the first delivery relative to now (optional, alternative to DeliverAt).
+	Delay time.Duration
+
+	// TTL is how long the message stays deliverable, counted from its (scheduled) delivery time
+	// (optional). Defaults to the topic's MessageTTL, then model.DefaultQueueTTL.
+	// Items are never expired before a scheduled retry, so a short TTL does not cut the retry schedule.
+	TTL time.Duration
+
+	// IdempotencyKey deduplicates retried publishes (optional, at most MaxIdempotencyKeyLength bytes).
+	// Within the idempotency window (see WithIdempotencyWindow), publishing a key again on the same
+	// topic returns the original result with Duplicate set instead of creating a new message.
+	IdempotencyKey string
 }
 
 // PublishResult represents the result of a publish operation.
@@ -113,18 +255,78 @@ type PublishResult struct {
 	MessageID         int64   // Created message ID
 	QueueItemsCreated int     // Number of queue items created
 	SubscriptionsIDs  []int64 // Subscription IDs that received the message
+
+	// Throttled lists the subscriptions whose queue was full (see WithBackpressure).
+	// Subscriptions with OverflowDeadLetter are not in SubscriptionsIDs.
+	Throttled []ThrottledSubscription
+
+	// Duplicate is true if the request repeated an earlier IdempotencyKey: the result then
+	// describes the original message and its queue items, and nothing new was stored.
+	Duplicate bool
 }
 
 // Publish publishes a message to a topic and creates queue items for all active subscriptions.
 //
 // The process:
 //  1. Validate topic exists
-//  2. Create message record
+//  2. Return the original result if the idempotency key was already published
 //  3. Find all active subscriptions for the topic
-//  4. Create queue items for each subscription
+//  4. Check the backlog of subscriptions with a max queue depth (see WithBackpressure)
+//  5. Create message record
+//  6. Create queue items for each subscription
 //
 // Returns PublishResult with message ID and queue item count, or error if publish fails.
+// Returns an error wrapping ErrQueueFull if a subscription's queue is full and the
+// overflow policy is OverflowReject; nothing is stored then.
 func (p *Publisher) Publish(ctx context.Context, req PublishRequest) (*PublishResult, error) {
+	return p.publish(ctx, nil, req)
+}
+
+// PublishTx publishes a message like Publish, but writes the message, its queue items and
+// dead-lettered overflow within the caller's transaction (transactional outbox): they are
+// committed or rolled back together with the caller's own changes. Requires WithOutbox.
+//
+// Topics and subscriptions are read outside of tx; backlogs and idempotency keys are read
+// within tx, so they include the caller's uncommitted publishes. Workers only see the queue
+// items once tx is committed and are not woken by PublishTx: call WakeWorkers after the commit
+// for immediate delivery, otherwise they are delivered on the next poll.
+//
+// PublishTx sends no notifications (NotifyDLQItemAdded for dead-lettered overflow,
+// NotifyBacklogHighWater), since tx may still be rolled back. Full queues are reported in
+// PublishResult.Throttled, for the caller to act on after the commit.
+//
+// Unlike Publish, a failed write returns an error, since tx may no longer be usable;
+// the caller should roll back then.
+//
+// Example:
+//
+//	tx, err := db.BeginTx(ctx, nil)
+//	// ... insert the order with tx ...
+//	result, err := publisher.PublishTx(ctx, tx, pubsub.PublishRequest{
+//	    TopicCode:  "order.created",
+//	    Identifier: "order-42",
+//	    Data:       `{"orderId": 42}`,
+//	})
+//	if err != nil {
+//	    _ = tx.Rollback()
+//	    return err
+//	}
+//	if err := tx.Commit(); err != nil {
+//	    return err
+//	}
+//	publisher.WakeWorkers(ctx)
+func (p *Publisher) PublishTx(ctx context.Context, tx *sql.Tx, req PublishRequest) (*PublishResult, error) {
+	if tx == nil {
+		return nil, NewError(ErrCodeValidation, "transaction is required")
+	}
+	if p.outbox == nil {
+		return nil, NewError(ErrCodeConfiguration, "OutboxRepository is required (use WithOutbox)")
+	}
+	return p.publish(ctx, tx, req)
+}
+
+// publish implements Publish and PublishTx. Writes go to tx through the outbox if tx is not nil.
+func (p *Publisher) publish(ctx context.Context, tx *sql.Tx, req PublishRequest) (*PublishResult, error) {
 	// Validate request
 	if req.TopicCode == "" {
 		return nil, NewError(ErrCodeValidation, "topic code is required")
@@ -132,6 +334,26 @@ func (p *Publisher) Publish(ctx context.Context, req PublishRequest) (*PublishRe
 	if req.Identifier == "" {
 		return nil, NewError(ErrCodeValidation, "identifier is required")
 	}
+	if req.Priority < 0 || req.Priority > model.MaxPriority {
+		return nil, NewError(ErrCodeValidation, fmt.Sprintf("priority must be between 0 and %d", model.MaxPriority))
+	}
+	if req.Delay < 0 {
+		return nil, NewError(ErrCodeValidation, "delay must be >= 0")
+	}
+	if req.TTL < 0 {
+		return nil, NewError(ErrCodeValidation, "TTL must be >= 0")
+	}
+	if req.Delay > 0 && !req.DeliverAt.IsZero() {
+		return nil, NewError(ErrCodeValidation, "deliverAt and delay are mutually exclusive")
+	}
+	if len(req.IdempotencyKey) > MaxIdempotencyKeyLength {
+		return nil, NewError(ErrCodeValidation, fmt.Sprintf("idempotency key must be at most %d bytes", MaxIdempotencyKeyLength))
+	}
+	deliverAt := req.DeliverAt
+	if req.Delay > 0 {
+		deliverAt = time.Now().Add(req.Delay)
+	}
+	scheduled := deliverAt.After(time.Now())
 
 	// Find topic by code
 	topic, err := p.topicRepo.GetByTopicCode(ctx, req.TopicCode)
@@ -142,14 +364,17 @@ func (p *Publisher) Publish(ctx context.Context, req PublishRequest) (*PublishRe
 		return nil, NewErrorWithCause(ErrCodeDatabase, "failed to load topic", err)
 	}
 
-	// Create message
-	message := model.NewMessage(topic.ID, req.Identifier, req.Data)
-	message, err = p.messageRepo.Save(ctx, message)
-	if err != nil {
-		return nil, NewErrorWithCause(ErrCodeDatabase, "failed to save message", err)
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = topic.MessageTTL()
 	}
 
-	p.logger.Infof("Message created: id=%d, topic=%s, identifier=%s", message.ID, req.TopicCode, req.Identifier)
+	if req.IdempotencyKey != "" {
+		duplicate, err := p.findDuplicate(ctx, tx, topic.ID, req.IdempotencyKey)
+		if err != nil || duplicate != nil {
+			return duplicate, err
+		}
+	}
 
 	// Find active subscriptions for topic
 	subscriptions, err := p.subscriptionRepo.FindActive(ctx, 0, req.Identifier)
@@ -165,6 +390,35 @@ func (p *Publisher) Publish(ctx context.Context, req PublishRequest) (*PublishRe
 		}
 	}
 
+	// Check backlogs before storing anything, so a rejected publish leaves no trace
+	backlogs, err := p.loadBacklogs(ctx, tx, activeSubscriptions)
+	if err != nil {
+		if errors.Is(err, ErrQueueFull) {
+			p.logger.Warnf("Rejected message for topic=%s, identifier=%s: %v", req.TopicCode, req.Identifier, err)
+		}
+		return nil, err
+	}
+
+	// Create message
+	message := model.NewMessage(topic.ID, req.Identifier, req.Data)
+	message.OrderingKey = req.OrderingKey
+	message.Priority = req.Priority
+	if req.IdempotencyKey != "" {
+		message.IdempotencyKey = sql.NullString{String: req.IdempotencyKey, Valid: true}
+	}
+	message, err = p.saveMessage(ctx, tx, message)
+	if err != nil {
+		if req.IdempotencyKey != "" && tx == nil {
+			// A concurrent publish with the same key may have won the unique constraint
+			if duplicate, findErr := p.findDuplicate(ctx, nil, topic.ID, req.IdempotencyKey); findErr == nil && duplicate != nil {
+				return duplicate, nil
+			}
+		}
+		return nil, NewErrorWithCause(ErrCodeDatabase, "failed to save message", err)
+	}
+
+	p.logger.Infof("Message created: id=%d, topic=%s, identifier=%s", message.ID, req.TopicCode, req.Identifier)
+
 	if len(activeSubscriptions) == 0 {
 		p.logger.Warnf("No active subscriptions found for topic=%s, identifier=%s", req.TopicCode, req.Identifier)
 		return &PublishResult{
@@ -177,29 +431,145 @@ func (p *Publisher) Publish(ctx context.Context, req PublishRequest) (*PublishRe
 	// Create queue items for each subscription
 	subscriptionIDs := make([]int64, 0, len(activeSubscriptions))
 	queueItemsCreated := 0
+	var throttled []ThrottledSubscription
 
 	for _, subscription := range activeSubscriptions {
+		backlog, limited := backlogs[subscription.ID]
+		full := limited && backlog.full()
+		if full && p.backpressure.Policy == OverflowDeadLetter {
+			if err := p.deadLetterOverflow(ctx, tx, subscription, message, backlog); err != nil {
+				if tx != nil {
+					return nil, NewErrorWithCause(ErrCodeDatabase, "failed to dead-letter overflow", err)
+				}
+				p.logger.Errorf("Failed to dead-letter overflow for subscription %d: %v", subscription.ID, err)
+				continue
+			}
+			p.logger.Warnf("Queue of subscription %d is full (%d items): message %d dead-lettered",
+				subscription.ID, backlog.depth, message.ID)
+			throttled = append(throttled, ThrottledSubscription{
+				SubscriptionID: subscription.ID,
+				Depth:          backlog.depth,
+				MaxDepth:       backlog.maxDepth,
+				Policy:         OverflowDeadLetter,
+			})
+			continue
+		}
+
 		queueItem := model.NewQueue(subscription.ID, message.ID)
-		_, err := p.queueRepo.Save(ctx, &queueItem)
-		if err != nil {
+		queueItem.OrderingKey = message.OrderingKey
+		if scheduled {
+			queueItem.Schedule(deliverAt)
+		}
+		queueItem.SetTTL(ttl)
+		if subscription.IsPausedAt(time.Now()) && subscription.ResumeAt.Valid {
+			// Held until the automatic resume: stay deliverable for the TTL after it
+			queueItem.ExtendExpiry(subscription.ResumeAt.Time.Add(cmp.Or(ttl, model.DefaultQueueTTL)))
+		}
+		queueItem.Prioritize(message.Priority, p.priorityAging)
+		if err := p.saveQueueItem(ctx, tx, &queueItem); err != nil {
+			if tx != nil {
+				return nil, NewErrorWithCause(ErrCodeDatabase, "failed to create queue item", err)
+			}
 			p.logger.Errorf("Failed to create queue item for subscription %d: %v", subscription.ID, err)
 			continue // Continue creating other queue items
 		}
 
 		subscriptionIDs = append(subscriptionIDs, subscription.ID)
 		queueItemsCreated++
+
+		switch {
+		case full:
+			dropped, err := p.dropOldest(ctx, tx, subscription.ID, backlog)
+			if err != nil {
+				if tx != nil {
+					return nil, NewErrorWithCause(ErrCodeDatabase, "failed to drop oldest items", err)
+				}
+				p.logger.Errorf("Failed to drop oldest items of subscription %d: %v", subscription.ID, err)
+			}
+			p.logger.Warnf("Queue of subscription %d is full (%d items): dropped %d oldest items",
+				subscription.ID, backlog.depth, dropped)
+			throttled = append(throttled, ThrottledSubscription{
+				SubscriptionID: subscription.ID,
+				Depth:          backlog.depth,
+				MaxDepth:       backlog.maxDepth,
+				Policy:         OverflowDropOldest,
+				Dropped:        dropped,
+			})
+		case limited && tx == nil: // Not notified for a PublishTx that may still be rolled back
+			p.notifyHighWater(ctx, subscription.ID, backlog)
+		}
 	}
 
-	p.logger.Infof("Published message %d to %d subscriptions (topic=%s, identifier=%s)",
-		message.ID, queueItemsCreated, req.TopicCode, req.Identifier)
+	if scheduled {
+		p.logger.Infof("Scheduled message %d to %d subscriptions at %v (topic=%s, identifier=%s)",
+			message.ID, queueItemsCreated, deliverAt, req.TopicCode, req.Identifier)
+	} else {
+		p.logger.Infof("Published message %d to %d subscriptions (topic=%s, identifier=%s)",
+			message.ID, queueItemsCreated, req.TopicCode, req.Identifier)
+	}
+
+	if queueItemsCreated > 0 && !scheduled && tx == nil {
+		p.WakeWorkers(ctx)
+	}
 
 	return &PublishResult{
 		MessageID:         message.ID,
 		QueueItemsCreated: queueItemsCreated,
 		SubscriptionsIDs:  subscriptionIDs,
+		Throttled:         throttled,
 	}, nil
 }
 
+// CancelScheduled cancels the pending deliveries of a message published with DeliverAt or Delay.
+// Deliveries that are already due, in progress or done are not affected.
+//
+// Returns the number of canceled deliveries, or ErrNoData if the message has no
+// scheduled deliveries (unknown message, not scheduled, or already due).
+func (p *Publisher) CancelScheduled(ctx context.Context, messageID int64) (int, error) {
+	if messageID == 0 {
+		return 0, NewError(ErrCodeValidation, "message ID is required")
+	}
+
+	canceled, err := p.queueRepo.DeleteScheduled(ctx, messageID)
+	if err != nil {
+		return 0, NewErrorWithCause(ErrCodeDatabase, "failed to cancel scheduled message", err)
+	}
+	if canceled == 0 {
+		return 0, ErrNoData
+	}
+
+	p.logger.Infof("Canceled %d scheduled deliveries of message %d", canceled, messageID)
+	return canceled, nil
+}
+
+// saveMessage inserts a message through the outbox if tx is not nil, else through the repository.
+func (p *Publisher) saveMessage(ctx context.Context, tx *sql.Tx, message model.Message) (model.Message, error) {
+	if tx != nil {
+		return p.outbox.SaveMessage(ctx, tx, message)
+	}
+	return p.messageRepo.Save(ctx, message)
+}
+
+// saveQueueItem inserts a queue item through the outbox if tx is not nil, else through the repository.
+func (p *Publisher) saveQueueItem(ctx context.Context, tx *sql.Tx, item *model.Queue) error {
+	if tx != nil {
+		return p.outbox.SaveQueueItem(ctx, tx, item)
+	}
+	_, err := p.queueRepo.Save(ctx, item)
+	return err
+}
+
+// WakeWorkers signals queue workers that new items are ready (if a notifier is set, see
+// WithPublisherWakeup). Publish does this itself; call it after committing a PublishTx.
+func (p *Publisher) WakeWorkers(ctx context.Context) {
+	if p.wakeup == nil {
+		return
+	}
+	if err := p.wakeup.NotifyQueued(ctx); err != nil {
+		p.logger.Warnf("Failed to wake queue workers: %v", err)
+	}
+}
+
 // PublishBatch publishes multiple messages in a batch.
 // This is more efficient than calling Publish multiple times.
 func (p *Publisher) PublishBatch(ctx context.Context, requests []PublishRequest) ([]*PublishResult, error) {
@@ -0,0 +1,233 @@
+package pubsub_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// publishPriority publishes a message with a priority and returns its ID.
+func (f *publisherFixture) publishPriority(t *testing.T, publisher *pubsub.Publisher, priority int) int64 {
+	t.Helper()
+	req := f.request()
+	req.Priority = priority
+	result, err := publisher.Publish(context.Background(), req)
+	require.NoError(t, err)
+	return result.MessageID
+}
+
+func TestPublisher_PriorityValidation(t *testing.T) {
+	f := newPublisherFixture(t)
+	publisher := f.publisher(t)
+
+	for _, priority := range []int{-1, model.MaxPriority + 1} {
+		req := f.request()
+		req.Priority = priority
+		_, err := publisher.Publish(context.Background(), req)
+		var pubsubErr *pubsub.Error
+		require.ErrorAs(t, err, &pubsubErr, "priority %d", priority)
+		assert.Equal(t, pubsub.ErrCodeValidation, pubsubErr.Code)
+	}
+
+	_, err := pubsub.NewPublisher(
+		pubsub.WithPublisherRepositories(f.repos.Message, f.repos.Queue, f.repos.Subscription, f.repos.Topic),
+		pubsub.WithPriorityAging(0),
+	)
+	assert.Error(t, err)
+}
+
+func TestPublisher_PriorityAging(t *testing.T) {
+	f := newPublisherFixture(t)
+	publisher := f.publisher(t, pubsub.WithPriorityAging(time.Minute))
+
+	// Each priority level moves the item a minute ahead of the time it became due
+	for _, priority := range []int{0, 1, model.MaxPriority} {
+		item := f.queueItem(t, f.publishPriority(t, publisher, priority))
+		assert.Equal(t, priority, item.Priority)
+		assert.WithinDuration(t, item.CreatedAt.Add(-time.Duration(priority)*time.Minute), item.PriorityAt, time.Second,
+			"priority %d", priority)
+	}
+}
+
+func TestPublisher_PriorityIsServedFirst(t *testing.T) {
+	tests := []struct {
+		name        string
+		aging       time.Duration
+		urgentFirst bool
+	}{
+		{"urgent message overtakes the backlog", time.Hour, true},
+		{"backlog that waited longer than the priority is worth", time.Millisecond, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newPublisherFixture(t)
+			var received []int64
+			worker := f.worker(t, deliveryFunc(func(_ context.Context, _ string, message *model.DataMessage) error {
+				id, err := strconv.ParseInt(message.MessageID, 10, 64)
+				received = append(received, id)
+				return err
+			}), pubsub.WithBatchSize(1))
+
+			publisher := f.publisher(t, pubsub.WithPriorityAging(tt.aging))
+			backlog := f.publishPriority(t, publisher, 0)
+			time.Sleep(20 * time.Millisecond) // Longer than MaxPriority × 1ms
+			urgent := f.publishPriority(t, publisher, model.MaxPriority)
+
+			for range 2 {
+				_, err := worker.ProcessPendingItems(ctx)
+				require.NoError(t, err)
+			}
+
+			if tt.urgentFirst {
+				assert.Equal(t, []int64{urgent, backlog}, received)
+			} else {
+				assert.Equal(t, []int64{backlog, urgent}, received)
+			}
+		})
+	}
+}
+
+func TestPublisher_ScheduleValidation(t *testing.T) {
+	f := newPublisherFixture(t)
+	publisher := f.publisher(t)
+
+	negative := f.request()
+	negative.Delay = -time.Second
+	both := f.request()
+	both.Delay = time.Minute
+	both.DeliverAt = time.Now().Add(time.Hour)
+
+	for name, req := range map[string]pubsub.PublishRequest{"negative delay": negative, "delay and deliverAt": both} {
+		_, err := publisher.Publish(context.Background(), req)
+		var pubsubErr *pubsub.Error
+		require.ErrorAs(t, err, &pubsubErr, name)
+		assert.Equal(t, pubsub.ErrCodeValidation, pubsubErr.Code, name)
+	}
+}
+
+func TestPublisher_ScheduledDelivery(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+	delivered := 0
+	worker := f.worker(t, deliveryFunc(func(context.Context, string, *model.DataMessage) error {
+		delivered++
+		return nil
+	}))
+	publisher := f.publisher(t)
+
+	req := f.request()
+	req.Delay = 24 * time.Hour
+	scheduled, err := publisher.Publish(ctx, req)
+	require.NoError(t, err)
+
+	// Not delivered before it is due; the delivery window starts at the scheduled time
+	processed, err := worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, processed)
+
+	item := f.queueItem(t, scheduled.MessageID)
+	assert.True(t, item.IsScheduled())
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), item.NextRetryAt.Time, time.Minute)
+	assert.True(t, item.ExpiresAt.After(item.NextRetryAt.Time))
+
+	// Delivered once due
+	item.NextRetryAt.Time = time.Now()
+	_, err = f.repos.Queue.Save(ctx, &item)
+	require.NoError(t, err)
+	processed, err = worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, 1, delivered)
+
+	// A delivery time in the past is delivered immediately
+	req = f.request()
+	req.DeliverAt = time.Now().Add(-time.Hour)
+	past, err := publisher.Publish(ctx, req)
+	require.NoError(t, err)
+	item = f.queueItem(t, past.MessageID)
+	assert.False(t, item.IsScheduled())
+}
+
+func TestPublisher_CancelScheduled(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+	publisher := f.publisher(t)
+
+	req := f.request()
+	req.DeliverAt = time.Now().Add(time.Hour)
+	scheduled, err := publisher.Publish(ctx, req)
+	require.NoError(t, err)
+
+	canceled, err := publisher.CancelScheduled(ctx, scheduled.MessageID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, canceled)
+	_, err = f.repos.Queue.FindByMessageID(ctx, f.subscription.ID, scheduled.MessageID)
+	assert.ErrorIs(t, err, pubsub.ErrNoData, "queue item is deleted")
+
+	_, err = publisher.CancelScheduled(ctx, scheduled.MessageID)
+	assert.ErrorIs(t, err, pubsub.ErrNoData, "already canceled")
+
+	// Messages that are already due cannot be canceled
+	immediate, err := publisher.Publish(ctx, f.request())
+	require.NoError(t, err)
+	_, err = publisher.CancelScheduled(ctx, immediate.MessageID)
+	assert.ErrorIs(t, err, pubsub.ErrNoData)
+	assert.Equal(t, 1, f.backlog(t))
+
+	_, err = publisher.CancelScheduled(ctx, 0)
+	var pubsubErr *pubsub.Error
+	require.ErrorAs(t, err, &pubsubErr)
+	assert.Equal(t, pubsub.ErrCodeValidation, pubsubErr.Code)
+}
+
+func TestPublisher_TTL(t *testing.T) {
+	tests := []struct {
+		name       string
+		topicTTL   int // Topic MessageTTLSeconds
+		ttl        time.Duration
+		delay      time.Duration
+		wantExpiry time.Duration // From now
+	}{
+		{name: "default", wantExpiry: model.DefaultQueueTTL},
+		{name: "topic", topicTTL: 60, wantExpiry: time.Minute},
+		{name: "publish overrides topic", topicTTL: 60, ttl: 7 * 24 * time.Hour, wantExpiry: 7 * 24 * time.Hour},
+		{name: "counted from scheduled time", ttl: time.Hour, delay: 24 * time.Hour, wantExpiry: 25 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newPublisherFixture(t)
+			f.topic.MessageTTLSeconds = tt.topicTTL
+			_, err := f.repos.Topic.Save(ctx, f.topic)
+			require.NoError(t, err)
+
+			req := f.request()
+			req.TTL = tt.ttl
+			req.Delay = tt.delay
+			result, err := f.publisher(t).Publish(ctx, req)
+			require.NoError(t, err)
+
+			item := f.queueItem(t, result.MessageID)
+			assert.WithinDuration(t, time.Now().Add(tt.wantExpiry), item.ExpiresAt, 5*time.Second)
+		})
+	}
+
+	t.Run("negative", func(t *testing.T) {
+		f := newPublisherFixture(t)
+		req := f.request()
+		req.TTL = -time.Second
+		_, err := f.publisher(t).Publish(context.Background(), req)
+		var pubsubErr *pubsub.Error
+		require.ErrorAs(t, err, &pubsubErr)
+		assert.Equal(t, pubsub.ErrCodeValidation, pubsubErr.Code)
+	})
+}
@@ -2,9 +2,14 @@ package pubsub
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/coregx/pubsub/model"
@@ -17,6 +22,8 @@ import (
 //
 // Implementations should handle HTTP transport, retries at the transport level,
 // and return errors for failed deliveries to trigger the retry mechanism.
+// Return a *DeliveryError to mark failures that must not be retried.
+// The webhook package provides a ready-to-use HTTP implementation.
 type MessageDeliveryGateway interface {
 	// DeliverMessage sends a message to the subscriber's webhook endpoint.
 	// Returns error if delivery fails (network error, non-2xx response, timeout).
@@ -47,20 +54,58 @@ type TransmitterProvider interface {
 //   - Clean up expired queue items
 //   - Send notifications for delivery failures and DLQ additions
 //
-// Thread safety: Safe for concurrent use. Each batch is processed sequentially.
+// Thread safety: Safe for concurrent use. Items of a batch are delivered by up to
+// concurrency goroutines (see WithConcurrency); each item is handled by exactly one of them.
 type QueueWorker struct {
 	qr                  QueueRepository
 	mr                  MessageRepository
 	sr                  SubscriptionRepository
 	dlqr                DLQRepository
+	tr                  TopicRepository // nil = topic settings are not applied
 	transmitterProvider TransmitterProvider
-	gateway             MessageDeliveryGateway
+	gateway             MessageDeliveryGateway // Delivery gateway wrapped by interceptors
+	interceptors        []DeliveryInterceptor
 	retryStrategy       retry.Strategy
 	logger              Logger
 	notificationService NotificationService
+	circuitBreaker      *circuitBreaker
+	rateLimiter         *rateLimiter
+	rateLimitProvider   RateLimitProvider      // nil = no per-subscriber limits
+	targetProvider      DeliveryTargetProvider // nil = callback URL and rate limit are resolved separately
+	wakeup              WakeupSource
+	maxPollInterval     time.Duration // Upper bound of the idle poll backoff (0 = fixed interval)
+	partitionConfig     *PartitionConfig
+	partitions          *partitioner // nil = claim from all partitions
+	locks               LockRepository
+	leaderLease         time.Duration
+	leader              *LeaderElector   // nil = every worker runs maintenance
+	retention           *RetentionConfig // nil = messages are kept forever
 	batchSize           int
+	concurrency         int
+	workerID            string        // Lease owner name for claimed queue items
+	leaseDuration       time.Duration // How long claimed items are reserved for this worker
+	lifecycle           workerLifecycle
+	stats               workerStats
 }
 
+// workerLifecycle is the state of a worker started with Start.
+type workerLifecycle struct {
+	mu          sync.Mutex
+	stop        context.CancelFunc // Stops starting new batches and deliveries
+	abort       context.Context    // Canceled when Stop's deadline passes: interrupts in-flight deliveries
+	cancelAbort context.CancelFunc
+	done        chan struct{} // Closed when the run loop has exited; nil when not started
+	abandoned   atomic.Int64  // Deliveries interrupted by abort
+}
+
+// errLeaseExpired is returned by processQueueItem when the worker's claim on an item ran out
+// before the item was processed. Another worker may have claimed it in the meantime.
+var errLeaseExpired = errors.New("queue item lease expired")
+
+// errDeliveryDeferred is returned by processQueueItem when an item was rescheduled
+// without a delivery attempt. It is not a failure.
+var errDeliveryDeferred = errors.New("delivery deferred")
+
 // NewQueueWorker creates a new queue worker with the provided options.
 //
 // Required options:
@@ -71,6 +116,19 @@ type QueueWorker struct {
 // Optional options:
 //   - WithRetryStrategy: custom retry strategy (default: retry.DefaultStrategy())
 //   - WithBatchSize: batch processing size (default: 100)
+//   - WithConcurrency: parallel deliveries per batch (default: 1)
+//   - WithInterceptors: wrap every delivery (default: none)
+//   - WithTopicRepository: apply per-topic settings such as DropExpired (default: not applied)
+//   - WithWorkerID: lease owner name (default: hostname, PID and a random suffix)
+//   - WithLeaseDuration: how long claimed items are reserved (default: 5 minutes)
+//   - WithPartitioning: only claim items of owned partitions (default: all items)
+//   - WithLeaderElection: run maintenance on one worker only (default: every worker)
+//   - WithRetention: purge messages after their topic's retention period (default: keep forever)
+//   - WithWakeup: process immediately when new items are published (default: interval only)
+//   - WithAdaptivePolling: back off when idle, poll immediately when busy (default: fixed interval)
+//   - WithNotifications: notification service (default: no notifications)
+//   - WithCircuitBreaker: per-subscriber circuit breaker (default: disabled)
+//   - WithRateLimiting, WithHostRateLimit: delivery rate limits (default: unlimited)
 //
 // Example:
 //
@@ -88,6 +146,9 @@ func NewQueueWorker(opts ...Option) (*QueueWorker, error) {
 	w := &QueueWorker{
 		retryStrategy:       retry.DefaultStrategy(),
 		batchSize:           100,
+		concurrency:         1,
+		workerID:            defaultWorkerID(),
+		leaseDuration:       5 * time.Minute,
 		notificationService: &NoOpNotificationService{}, // Default: no notifications
 	}
 
@@ -121,63 +182,165 @@ func NewQueueWorker(opts ...Option) (*QueueWorker, error) {
 		return nil, NewError(ErrCodeConfiguration, "Logger is required (use WithLogger)")
 	}
 
+	w.targetProvider = sharedTargetProvider(w.transmitterProvider, w.rateLimitProvider)
+	if w.partitionConfig != nil {
+		w.partitions = newPartitioner(*w.partitionConfig, w.workerID, w.logger)
+	}
+	if w.locks != nil {
+		leader, err := NewLeaderElector(w.locks, MaintenanceLockName, w.workerID, w.leaderLease)
+		if err != nil {
+			return nil, NewErrorWithCause(ErrCodeConfiguration, "failed to create leader elector", err)
+		}
+		w.leader = leader
+	}
+	if w.retention != nil && w.tr == nil {
+		return nil, NewError(ErrCodeConfiguration, "TopicRepository is required for retention (use WithTopicRepository)")
+	}
+
+	w.gateway = chainInterceptors(w.gateway, w.interceptors)
+	if w.gateway == nil {
+		return nil, NewError(ErrCodeConfiguration, "delivery interceptor returned a nil gateway")
+	}
+
 	return w, nil
 }
 
 // ProcessPendingItems processes pending queue items ready for first delivery attempt.
-// It finds all items with status=PENDING and next_retry_at <= now, ordered by created_at ASC (FIFO).
+// It claims items with status=PENDING and next_retry_at <= now, ordered by created_at ASC (FIFO).
+// Claimed items are leased to this worker, so other replicas skip them until the lease expires.
 //
-// Returns the number of successfully processed items and any critical error.
+// Items are delivered in parallel when WithConcurrency is set. Returns the number of
+// successfully processed items and any critical error.
 // Individual item failures are logged but don't stop batch processing.
 func (w *QueueWorker) ProcessPendingItems(ctx context.Context) (int, error) {
-	items, err := w.qr.FindPendingItems(ctx, w.batchSize)
+	_, processed, err := w.claimAndProcess(ctx, model.QueueStatusPending, "pending")
+	return processed, err
+}
+
+// ProcessRetryableItems processes failed items ready for retry attempts.
+// It claims items with status=FAILED and next_retry_at <= now, ordered by created_at ASC.
+//
+// Items are delivered in parallel when WithConcurrency is set. Returns the number of
+// successfully processed items and any critical error.
+// Individual item failures are logged but don't stop batch processing.
+func (w *QueueWorker) ProcessRetryableItems(ctx context.Context) (int, error) {
+	_, processed, err := w.claimAndProcess(ctx, model.QueueStatusFailed, "retryable")
+	return processed, err
+}
+
+// claimAndProcess claims up to batchSize items with the given status and processes them.
+// Returns the number of claimed and of successfully processed items.
+func (w *QueueWorker) claimAndProcess(ctx context.Context, status model.QueueStatus, kind string) (int, int, error) {
+	items, err := w.claimItems(ctx, status)
 	if err != nil {
 		if errors.Is(err, ErrNoData) {
-			return 0, nil
+			return 0, 0, nil
 		}
-		return 0, fmt.Errorf("failed to find pending items: %w", err)
+		return 0, 0, fmt.Errorf("failed to find %s items: %w", kind, err)
 	}
 
-	processed := 0
-	for i := range items {
-		if err := w.processQueueItem(ctx, &items[i]); err != nil {
-			w.logger.Errorf("Failed to process queue item %d: %v", items[i].ID, err)
-			continue
-		}
-		processed++
+	return len(items), w.processItems(ctx, items, kind), nil
+}
+
+// claimItems claims up to batchSize items with the given status, limited to the
+// owned partitions when partitioning is enabled.
+func (w *QueueWorker) claimItems(ctx context.Context, status model.QueueStatus) ([]model.Queue, error) {
+	if w.partitions == nil {
+		return w.qr.ClaimItems(ctx, status, w.workerID, w.leaseDuration, w.batchSize)
 	}
 
-	return processed, nil
+	partitions := w.partitions.assigned(ctx)
+	if len(partitions.Owned) == 0 {
+		return nil, ErrNoData // More workers than partitions: standby
+	}
+	return w.qr.ClaimPartitionItems(ctx, status, partitions, w.workerID, w.leaseDuration, w.batchSize)
 }
 
-// ProcessRetryableItems processes failed items ready for retry attempts.
-// It finds all items with status=FAILED and next_retry_at <= now, ordered by created_at ASC.
-//
-// Returns the number of successfully processed items and any critical error.
-// Individual item failures are logged but don't stop batch processing.
-func (w *QueueWorker) ProcessRetryableItems(ctx context.Context) (int, error) {
-	items, err := w.qr.FindRetryableItems(ctx, w.batchSize)
+// defaultWorkerID returns a lease owner name that is unique per process.
+func defaultWorkerID() string {
+	hostname, err := os.Hostname()
 	if err != nil {
-		if errors.Is(err, ErrNoData) {
-			return 0, nil
-		}
-		return 0, fmt.Errorf("failed to find retryable items: %w", err)
+		hostname = "pubsub"
+	}
+
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// processItems delivers a batch of queue items using up to w.concurrency goroutines.
+//
+// It stops starting new deliveries once ctx is canceled and waits for in-flight ones.
+// Started items are processed with cancellation detached from ctx, so a shutdown does not
+// abort a delivery halfway or leave its status unsaved. Returns the number of successfully
+// processed items.
+func (w *QueueWorker) processItems(ctx context.Context, items []model.Queue, kind string) int {
+	var (
+		processed atomic.Int64
+		wg        sync.WaitGroup
+		slots     = make(chan struct{}, w.concurrency)
+		itemCtx   = context.WithoutCancel(ctx)
+	)
+
+	next := 0
+	for ; next < len(items) && acquireSlot(ctx, slots); next++ {
+		wg.Add(1)
+		go func(item *model.Queue) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if err := w.processQueueItem(itemCtx, item); err != nil {
+				if !errors.Is(err, errDeliveryDeferred) {
+					w.logger.Errorf("Failed to process %s item %d: %v", kind, item.ID, err)
+				}
+				return
+			}
+			processed.Add(1)
+		}(&items[next])
+	}
+
+	// Claimed items that were not started are handed back to other workers right away
+	w.releaseLeases(itemCtx, items[next:])
+
+	wg.Wait()
+	return int(processed.Load())
+}
+
+// acquireSlot waits for a free delivery slot. Returns false once ctx is canceled.
+func acquireSlot(ctx context.Context, slots chan struct{}) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case slots <- struct{}{}:
+		return true
 	}
+}
 
-	processed := 0
+// releaseLeases clears this worker's lease on claimed items it will not process.
+func (w *QueueWorker) releaseLeases(ctx context.Context, items []model.Queue) {
 	for i := range items {
-		if err := w.processQueueItem(ctx, &items[i]); err != nil {
-			w.logger.Errorf("Failed to process retryable item %d: %v", items[i].ID, err)
-			continue
+		items[i].ReleaseLease()
+		if _, err := w.qr.Save(ctx, &items[i]); err != nil {
+			w.logger.Warnf("Failed to release lease of queue item %d: %v", items[i].ID, err)
 		}
-		processed++
 	}
-
-	return processed, nil
+	if len(items) > 0 {
+		w.logger.Debugf("Released %d claimed queue items", len(items))
+	}
 }
 
 // processQueueItem processes a single queue item with retry logic.
 func (w *QueueWorker) processQueueItem(ctx context.Context, queueItem *model.Queue) error {
+	var attempted bool // The delivery was attempted (the gateway was called)
+
+	// Items whose lease ran out while waiting in a slow batch may belong to another worker now
+	if !queueItem.HasLease(w.workerID) {
+		return errLeaseExpired
+	}
+
 	// Check if delivery can be attempted
 	if err := queueItem.CanAttemptDelivery(w.retryStrategy.MaxAttempts); err != nil {
 		w.logger.Debugf("Cannot attempt delivery for queue item %d: %v", queueItem.ID, err)
@@ -190,6 +353,33 @@ func (w *QueueWorker) processQueueItem(ctx context.Context, queueItem *model.Que
 		return fmt.Errorf("failed to load subscription: %w", err)
 	}
 
+	// Hold items of subscriptions paused after the item was claimed: claims skip them until resumed
+	if subscription.IsPausedAt(time.Now()) {
+		if subscription.ResumeAt.Valid {
+			// Never expire before the automatic resume (the expiry sweep skips paused subscriptions)
+			queueItem.ExtendExpiry(subscription.ResumeAt.Time.Add(w.leaseDuration))
+		}
+		return w.deferDelivery(ctx, queueItem, time.Now(),
+			fmt.Sprintf("subscription %d paused", subscription.ID))
+	}
+
+	// Skip subscribers whose circuit is open
+	if w.circuitBreaker != nil {
+		allowed, retryAt, change := w.circuitBreaker.allow(subscription.SubscriberID)
+		w.notifyCircuitStateChanged(ctx, change)
+		if !allowed {
+			return w.deferDelivery(ctx, queueItem, retryAt,
+				fmt.Sprintf("circuit open for subscriber %d", subscription.SubscriberID))
+		}
+
+		// A half-open probe that ends before its delivery is attempted frees the probe slot
+		defer func() {
+			if !attempted {
+				w.circuitBreaker.releaseProbe(subscription.SubscriberID)
+			}
+		}()
+	}
+
 	// Load message
 	message, err := w.mr.Load(ctx, queueItem.MessageID)
 	if err != nil {
@@ -202,14 +392,48 @@ func (w *QueueWorker) processQueueItem(ctx context.Context, queueItem *model.Que
 		return fmt.Errorf("failed to prepare message: %w", err)
 	}
 
-	// Get callback URL via transmitter provider (avoiding circular dependency)
-	callbackURL, err := w.transmitterProvider.GetCallbackUrl(ctx, subscription.SubscriberID)
+	// Get callback URL and rate limit via the providers (avoiding circular dependency)
+	target, err := w.deliveryTarget(ctx, subscription.SubscriberID)
 	if err != nil {
-		return fmt.Errorf("failed to get callback URL: %w", err)
+		return err
+	}
+	callbackURL := target.CallbackURL
+
+	// Defer deliveries above the subscriber's or host's rate limit
+	if w.rateLimiter != nil {
+		if allowed, retryAt := w.rateLimiter.allow(subscription.SubscriberID, target.RateLimit, callbackURL); !allowed {
+			return w.deferDelivery(ctx, queueItem, retryAt,
+				fmt.Sprintf("rate limit exceeded for subscriber %d", subscription.SubscriberID))
+		}
 	}
 
 	// Attempt delivery using the gateway interface
-	err = w.gateway.DeliverMessage(ctx, callbackURL, dataMessage)
+	deliveryCtx := ContextWithDeliveryInfo(ctx, DeliveryInfo{
+		QueueID:        queueItem.ID,
+		MessageID:      queueItem.MessageID,
+		SubscriptionID: queueItem.SubscriptionID,
+		SubscriberID:   subscription.SubscriberID,
+		Attempt:        queueItem.AttemptCount + 1,
+	})
+	deliveryCtx = context.WithValue(deliveryCtx, deliveryTargetKey{}, deliveryTarget{
+		item:         queueItem,
+		subscription: &subscription,
+	})
+	abort := w.abortContext()
+	deliveryCtx, cancelDelivery := withAbort(deliveryCtx, abort)
+	attempted = true
+	finishDelivery := w.stats.startDelivery()
+	start := time.Now()
+	err = w.gateway.DeliverMessage(deliveryCtx, callbackURL, dataMessage)
+	latency := time.Since(start)
+	finishDelivery()
+	cancelDelivery()
+	if err != nil && abort != nil && abort.Err() != nil {
+		// Interrupted by Stop: the subscriber is not at fault
+		return w.abandonDelivery(ctx, queueItem)
+	}
+	w.stats.recordDelivery(queueItem.SubscriptionID, latency, err)
+	w.recordCircuitResult(ctx, subscription.SubscriberID, err)
 	if err != nil {
 		// Delivery failed
 		w.handleDeliveryFailure(ctx, queueItem, err)
@@ -221,6 +445,108 @@ func (w *QueueWorker) processQueueItem(ctx context.Context, queueItem *model.Que
 	return nil
 }
 
+// sharedTargetProvider returns the provider resolving both callback URLs and rate limits with one
+// subscriber lookup, or nil if they come from different providers.
+func sharedTargetProvider(transmitters TransmitterProvider, limits RateLimitProvider) DeliveryTargetProvider {
+	provider, ok := transmitters.(DeliveryTargetProvider)
+	if !ok {
+		return nil
+	}
+	if limitProvider, ok := limits.(DeliveryTargetProvider); !ok || limitProvider != provider {
+		return nil
+	}
+	return provider
+}
+
+// deliveryTarget resolves the callback URL and, with WithRateLimiting, the rate limit of a subscriber.
+func (w *QueueWorker) deliveryTarget(ctx context.Context, subscriberID int64) (DeliveryTarget, error) {
+	if w.targetProvider != nil {
+		target, err := w.targetProvider.GetDeliveryTarget(ctx, subscriberID)
+		if err != nil {
+			return DeliveryTarget{}, fmt.Errorf("failed to get delivery target: %w", err)
+		}
+		return target, nil
+	}
+
+	callbackURL, err := w.transmitterProvider.GetCallbackUrl(ctx, subscriberID)
+	if err != nil {
+		return DeliveryTarget{}, fmt.Errorf("failed to get callback URL: %w", err)
+	}
+	target := DeliveryTarget{CallbackURL: callbackURL}
+	if w.rateLimitProvider != nil {
+		target.RateLimit, err = w.rateLimitProvider.GetRateLimit(ctx, subscriberID)
+		if err != nil {
+			return DeliveryTarget{}, fmt.Errorf("failed to get rate limit: %w", err)
+		}
+	}
+	return target, nil
+}
+
+// deferDelivery reschedules a queue item without a delivery attempt.
+// Always returns errDeliveryDeferred (or a persistence error).
+func (w *QueueWorker) deferDelivery(ctx context.Context, queueItem *model.Queue, until time.Time, reason string) error {
+	queueItem.Defer(until)
+
+	if _, err := w.qr.Save(ctx, queueItem); err != nil {
+		return fmt.Errorf("failed to defer queue item: %w", err)
+	}
+
+	w.logger.Debugf("Deferred queue item %d until %v: %s", queueItem.ID, until, reason)
+	return errDeliveryDeferred
+}
+
+// abandonDelivery reschedules a queue item whose delivery was interrupted by Stop.
+// The attempt is not counted and the lease is released, so any worker retries it right away.
+func (w *QueueWorker) abandonDelivery(ctx context.Context, queueItem *model.Queue) error {
+	w.lifecycle.abandoned.Add(1)
+	w.logger.Warnf("Abandoned delivery of queue item %d at shutdown", queueItem.ID)
+	return w.deferDelivery(ctx, queueItem, time.Now(), "delivery interrupted at shutdown")
+}
+
+// recordCircuitResult feeds a delivery outcome into the circuit breaker (if enabled).
+// Permanent failures count as a response from the subscriber, not as an outage.
+func (w *QueueWorker) recordCircuitResult(ctx context.Context, subscriberID int64, deliveryErr error) {
+	if w.circuitBreaker == nil {
+		return
+	}
+
+	var change *CircuitStateChange
+	if deliveryErr == nil || IsPermanentDeliveryError(deliveryErr) {
+		change = w.circuitBreaker.recordSuccess(subscriberID)
+	} else {
+		change = w.circuitBreaker.recordFailure(subscriberID)
+	}
+	w.notifyCircuitStateChanged(ctx, change)
+}
+
+// notifyCircuitStateChanged logs a circuit breaker transition (nil means no transition) and reports
+// it if the notification service implements CircuitStateNotifier.
+func (w *QueueWorker) notifyCircuitStateChanged(ctx context.Context, change *CircuitStateChange) {
+	if change == nil {
+		return
+	}
+
+	w.logger.Infof("Circuit for subscriber %d: %s → %s (failures=%d)",
+		change.SubscriberID, change.From, change.To, change.Failures)
+
+	notifier, ok := w.notificationService.(CircuitStateNotifier)
+	if !ok {
+		return
+	}
+	if err := notifier.NotifyCircuitStateChanged(ctx, *change); err != nil {
+		w.logger.Warnf("Failed to send circuit state notification: %v", err)
+	}
+}
+
+// CircuitState returns the circuit breaker state of a subscriber.
+// Always CircuitClosed when the circuit breaker is disabled.
+func (w *QueueWorker) CircuitState(subscriberID int64) CircuitState {
+	if w.circuitBreaker == nil {
+		return CircuitClosed
+	}
+	return w.circuitBreaker.state(subscriberID)
+}
+
 // prepareMessage prepares a message for delivery.
 func (w *QueueWorker) prepareMessage(message model.Message) (*model.DataMessage, error) {
 	strBase64 := base64.StdEncoding.EncodeToString([]byte(message.Data))
@@ -232,6 +558,8 @@ func (w *QueueWorker) prepareMessage(message model.Message) (*model.DataMessage,
 		strBase64,
 	)
 
+	dataMessage.OrderingKey = message.OrderingKey
+
 	if err := dataMessage.FromString(message.Data); err != nil {
 		return nil, fmt.Errorf("failed to parse message data: %w", err)
 	}
@@ -254,11 +582,18 @@ func (w *QueueWorker) handleDeliverySuccess(ctx context.Context, queueItem *mode
 
 // handleDeliveryFailure handles failed message delivery with retry logic.
 func (w *QueueWorker) handleDeliveryFailure(ctx context.Context, queueItem *model.Queue, deliveryErr error) {
-	// Calculate next retry delay
+	// Calculate next retry delay, preferring the subscriber's Retry-After hint
 	retryDelay := w.retryStrategy.CalculateRetryDelay(queueItem.AttemptCount + 1)
 
 	// Mark as failed with retry schedule
-	queueItem.MarkFailed(deliveryErr, retryDelay)
+	if retryAfter, ok := RetryAfterFromError(deliveryErr); ok {
+		retryDelay = w.retryStrategy.ClampDelay(retryAfter)
+		queueItem.MarkFailedWithRetryAfter(deliveryErr, retryDelay)
+	} else {
+		queueItem.MarkFailed(deliveryErr, retryDelay)
+	}
+	// A TTL shorter than the retry schedule must not expire the item before its next attempt
+	queueItem.ExtendExpiry(queueItem.NextRetryAt.Time.Add(w.leaseDuration))
 
 	if _, err := w.qr.Save(ctx, queueItem); err != nil {
 		w.logger.Errorf("Failed to update queue item %d after failure: %v", queueItem.ID, err)
@@ -270,13 +605,24 @@ func (w *QueueWorker) handleDeliveryFailure(ctx context.Context, queueItem *mode
 		w.logger.Warnf("Failed to send delivery failure notification: %v", err)
 	}
 
+	// Permanent failures (e.g. 410 Gone) will never succeed: dead-letter immediately
+	if IsPermanentDeliveryError(deliveryErr) {
+		w.logger.Warnf("Moving queue item %d to DLQ after permanent failure (attempts=%d): %v",
+			queueItem.ID, queueItem.AttemptCount, deliveryErr)
+
+		if err := w.moveToDLQ(ctx, queueItem, w.dlqFailureReason(queueItem, deliveryErr)); err != nil {
+			w.logger.Errorf("Failed to move queue item %d to DLQ: %v", queueItem.ID, err)
+		}
+		return
+	}
+
 	// Check if should move to DLQ
 	if queueItem.ShouldMoveToDLQ(w.retryStrategy.DLQThreshold) {
 		w.logger.Warnf("Moving queue item %d to DLQ (attempts=%d, threshold=%d)",
 			queueItem.ID, queueItem.AttemptCount, w.retryStrategy.DLQThreshold)
 
 		// Move to DLQ
-		if err := w.moveToDLQ(ctx, queueItem, deliveryErr); err != nil {
+		if err := w.moveToDLQ(ctx, queueItem, w.dlqFailureReason(queueItem, deliveryErr)); err != nil {
 			w.logger.Errorf("Failed to move queue item %d to DLQ: %v", queueItem.ID, err)
 		}
 		return
@@ -289,10 +635,14 @@ func (w *QueueWorker) handleDeliveryFailure(ctx context.Context, queueItem *mode
 // CleanupExpiredItems removes expired queue items from the queue.
 // Items are considered expired when expires_at <= now and status != SENT.
 //
-// This prevents the queue from growing indefinitely with stale messages.
-// Returns the number of deleted items and any critical error.
+// Expired items are moved to the Dead Letter Queue (reason "Expired after N attempts",
+// with a NotifyDLQItemAdded call), so undelivered messages leave a trace. Items of topics
+// with DropExpired set are deleted instead (requires WithTopicRepository).
+// Expired items are claimed like deliveries, so each is handled by one worker only.
+//
+// Returns the number of dead-lettered and deleted items and any critical error.
 func (w *QueueWorker) CleanupExpiredItems(ctx context.Context) (int, error) {
-	items, err := w.qr.FindExpiredItems(ctx, w.batchSize)
+	items, err := w.qr.ClaimExpiredItems(ctx, w.workerID, w.leaseDuration, w.batchSize)
 	if err != nil {
 		if errors.Is(err, ErrNoData) {
 			return 0, nil
@@ -300,36 +650,120 @@ func (w *QueueWorker) CleanupExpiredItems(ctx context.Context) (int, error) {
 		return 0, fmt.Errorf("failed to find expired items: %w", err)
 	}
 
-	deleted := 0
+	topics := make(map[int64]model.Topic) // Topic settings, loaded once per cleanup
+	deadLettered, deleted := 0, 0
 	for i := range items {
-		if err := w.qr.Delete(ctx, &items[i]); err != nil {
-			w.logger.Errorf("Failed to delete expired queue item %d: %v", items[i].ID, err)
+		item := &items[i]
+
+		if !w.dropsExpired(ctx, item, topics) {
+			reason := fmt.Sprintf("Expired after %d attempts", item.AttemptCount)
+			if err := w.moveToDLQ(ctx, item, reason); err != nil {
+				w.logger.Errorf("Failed to move expired queue item %d to DLQ: %v", item.ID, err)
+				continue
+			}
+			w.stats.recordExpired(item.SubscriptionID)
+			deadLettered++
 			continue
 		}
+
+		if err := w.qr.Delete(ctx, item); err != nil {
+			w.logger.Errorf("Failed to delete expired queue item %d: %v", item.ID, err)
+			continue
+		}
+		w.stats.recordExpired(item.SubscriptionID)
 		deleted++
 	}
 
-	w.logger.Infof("Cleaned up %d expired queue items", deleted)
-	return deleted, nil
+	w.logger.Infof("Cleaned up %d expired queue items (dead-lettered=%d, deleted=%d)",
+		deadLettered+deleted, deadLettered, deleted)
+	return deadLettered + deleted, nil
+}
+
+// dropsExpired reports whether an expired item's topic opted into plain deletion.
+// Lookup failures dead-letter the item, so nothing is lost silently.
+func (w *QueueWorker) dropsExpired(ctx context.Context, item *model.Queue, topics map[int64]model.Topic) bool {
+	if w.tr == nil {
+		return false
+	}
+
+	message, err := w.mr.Load(ctx, item.MessageID)
+	if err != nil {
+		w.logger.Warnf("Failed to load message %d of expired queue item %d: %v", item.MessageID, item.ID, err)
+		return false
+	}
+
+	topic, ok := topics[message.TopicID]
+	if !ok {
+		topic, err = w.tr.Load(ctx, message.TopicID)
+		if err != nil {
+			w.logger.Warnf("Failed to load topic %d of expired queue item %d: %v", message.TopicID, item.ID, err)
+			return false
+		}
+		topics[message.TopicID] = topic
+	}
+
+	return topic.DropExpired
 }
 
 // Run starts the queue worker event loop that processes messages continuously.
-// It runs until the context is canceled, processing batches at the specified interval.
+// It runs until the context is canceled, processing batches at the specified interval
+// and, with WithWakeup, whenever new queue items are signaled.
+// With WithAdaptivePolling the interval is the base delay between batches instead.
 //
 // Each batch processes:
 //   - Pending items (first delivery attempt)
 //   - Retryable items (retry after backoff delay)
 //   - Expired items (cleanup)
 //
-// This method blocks and should typically be run in a goroutine.
+// This method blocks and should typically be run in a goroutine, or use Start and Stop.
+// On cancellation it returns after in-flight deliveries of the current batch have finished.
 //
 // Example:
 //
 //	ctx := context.Background()
 //	go worker.Run(ctx, 30*time.Second) // Process every 30 seconds
 func (w *QueueWorker) Run(ctx context.Context, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	delay := interval
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var wakeups <-chan struct{} // nil channel: never ready
+	if w.wakeup != nil {
+		wakeups = w.wakeup.Wakeups()
+	}
+
+	// Heartbeat and rebalance partitions independently of the poll delay
+	if w.partitions != nil && w.partitions.heartbeats != nil {
+		heartbeatCtx, stopHeartbeats := context.WithCancel(ctx)
+		var heartbeats sync.WaitGroup
+		heartbeats.Go(func() { w.partitions.run(heartbeatCtx) })
+		defer func() {
+			stopHeartbeats()
+			heartbeats.Wait()
+		}()
+	}
+
+	// Campaign for leadership of maintenance tasks
+	if w.leader != nil {
+		campaignCtx, stopCampaign := context.WithCancel(ctx)
+		var campaign sync.WaitGroup
+		campaign.Go(func() { w.campaign(campaignCtx) })
+		defer func() {
+			stopCampaign()
+			campaign.Wait()
+		}()
+	}
+
+	// Purge outdated messages independently of the poll delay
+	if w.retention != nil {
+		retentionCtx, stopRetention := context.WithCancel(ctx)
+		var retention sync.WaitGroup
+		retention.Go(func() { w.runRetention(retentionCtx) })
+		defer func() {
+			stopRetention()
+			retention.Wait()
+		}()
+	}
 
 	w.logger.Info("Queue worker started")
 
@@ -338,36 +772,174 @@ func (w *QueueWorker) Run(ctx context.Context, interval time.Duration) {
 		case <-ctx.Done():
 			w.logger.Info("Queue worker stopped")
 			return
-		case <-ticker.C:
-			w.processBatch(ctx)
+		case <-timer.C:
+		case <-wakeups:
 		}
+
+		load := w.processBatch(ctx)
+		delay = w.nextPollDelay(load, delay, interval)
+		timer.Reset(delay)
+	}
+}
+
+// Start runs the worker loop (see Run) in a background goroutine until Stop is called.
+// Returns ErrWorkerAlreadyStarted if the worker is already running.
+//
+// Example:
+//
+//	if err := worker.Start(30 * time.Second); err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer func() {
+//	    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	    defer cancel()
+//	    abandoned, err := worker.Stop(ctx)
+//	    log.Printf("worker stopped (abandoned=%d, err=%v)", abandoned, err)
+//	}()
+func (w *QueueWorker) Start(interval time.Duration) error {
+	l := &w.lifecycle
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done != nil {
+		return ErrWorkerAlreadyStarted
+	}
+
+	runCtx, stop := context.WithCancel(context.Background())
+	abort, cancelAbort := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	l.stop, l.abort, l.cancelAbort, l.done = stop, abort, cancelAbort, done
+	l.abandoned.Store(0)
+
+	go func() {
+		defer close(done)
+		w.Run(runCtx, interval)
+	}()
+	return nil
+}
+
+// Stop gracefully stops a worker started with Start and drains in-flight deliveries.
+//
+// No new batches or deliveries are started; claimed items that were not started yet are
+// released to other workers. In-flight deliveries may finish until ctx is done. If ctx expires
+// first, they are interrupted and rescheduled without counting an attempt, and ctx.Err() is returned.
+// The outcome of every item is persisted before Stop returns.
+//
+// Returns the number of interrupted (abandoned) deliveries.
+// Calling Stop on a worker that is not running returns 0, nil.
+func (w *QueueWorker) Stop(ctx context.Context) (int, error) {
+	l := &w.lifecycle
+	l.mu.Lock()
+	stop, cancelAbort, done := l.stop, l.cancelAbort, l.done
+	l.mu.Unlock()
+
+	if done == nil {
+		return 0, nil
+	}
+
+	stop()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		w.logger.Warnf("Stop deadline reached, interrupting in-flight deliveries")
+		cancelAbort()
+		<-done
+		err = ctx.Err()
+	}
+	cancelAbort()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	abandoned := int(l.abandoned.Load())
+	if l.done == done {
+		l.stop, l.abort, l.cancelAbort, l.done = nil, nil, nil, nil
+	}
+	return abandoned, err
+}
+
+// Wait blocks until a worker started with Start has stopped.
+// Returns immediately if the worker is not running.
+func (w *QueueWorker) Wait() {
+	w.lifecycle.mu.Lock()
+	done := w.lifecycle.done
+	w.lifecycle.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+// abortContext returns the context canceled when Stop's deadline passes, nil if not started.
+func (w *QueueWorker) abortContext() context.Context {
+	w.lifecycle.mu.Lock()
+	defer w.lifecycle.mu.Unlock()
+	return w.lifecycle.abort
+}
+
+// withAbort returns a copy of ctx that is also canceled when abort is (abort may be nil).
+func withAbort(ctx, abort context.Context) (context.Context, context.CancelFunc) {
+	if abort == nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stopAfter := context.AfterFunc(abort, cancel)
+	return ctx, func() {
+		stopAfter()
+		cancel()
 	}
 }
 
 // processBatch processes one batch of pending and retryable items.
-func (w *QueueWorker) processBatch(ctx context.Context) {
+// Returns how much work the batch found.
+func (w *QueueWorker) processBatch(ctx context.Context) batchLoad {
+	if ctx.Err() != nil {
+		return batchIdle
+	}
+	start := time.Now()
+	defer func() { w.stats.recordBatch(start, time.Since(start)) }()
+
 	// Process pending items (first delivery)
-	pendingCount, err := w.ProcessPendingItems(ctx)
+	pendingClaimed, pendingCount, err := w.claimAndProcess(ctx, model.QueueStatusPending, "pending")
 	if err != nil {
 		w.logger.Errorf("Error processing pending items: %v", err)
 	}
+	if ctx.Err() != nil {
+		return batchIdle // Stopping: don't start the next phase
+	}
 
 	// Process retryable items (retry attempts)
-	retryCount, err := w.ProcessRetryableItems(ctx)
+	retryClaimed, retryCount, err := w.claimAndProcess(ctx, model.QueueStatusFailed, "retryable")
 	if err != nil {
 		w.logger.Errorf("Error processing retryable items: %v", err)
 	}
+	if ctx.Err() != nil {
+		return batchIdle
+	}
 
-	// Periodic cleanup of expired items
-	expiredCount, err := w.CleanupExpiredItems(ctx)
-	if err != nil {
-		w.logger.Errorf("Error cleaning up expired items: %v", err)
+	// Periodic cleanup of expired items (leader only)
+	var expiredCount int
+	if w.leader == nil || w.leader.IsLeader() {
+		expiredCount, err = w.CleanupExpiredItems(ctx)
+		if err != nil {
+			w.logger.Errorf("Error cleaning up expired items: %v", err)
+		}
 	}
 
 	if pendingCount > 0 || retryCount > 0 || expiredCount > 0 {
 		w.logger.Infof("Batch processed: pending=%d, retries=%d, expired=%d",
 			pendingCount, retryCount, expiredCount)
 	}
+
+	switch {
+	case pendingClaimed >= w.batchSize || retryClaimed >= w.batchSize:
+		return batchFull
+	case pendingClaimed > 0 || retryClaimed > 0:
+		return batchPartial
+	default:
+		return batchIdle
+	}
 }
 
 // GetRetrySchedule returns a human-readable description of the retry schedule.
@@ -378,11 +950,12 @@ func (w *QueueWorker) GetRetrySchedule() string {
 	return w.retryStrategy.GetRetrySchedule()
 }
 
-// moveToDLQ moves a failed queue item to the Dead Letter Queue after retry exhaustion.
+// moveToDLQ moves a failed queue item to the Dead Letter Queue.
 // It creates a DLQ entry with full diagnostic information and removes the item from the queue.
 //
-// This method is called automatically when a queue item exceeds the retry threshold.
-func (w *QueueWorker) moveToDLQ(ctx context.Context, queueItem *model.Queue, _ error) error {
+// This method is called automatically when a queue item exceeds the retry threshold,
+// when the gateway reports a permanent DeliveryError, or when the item expired undelivered.
+func (w *QueueWorker) moveToDLQ(ctx context.Context, queueItem *model.Queue, failureReason string) error {
 	// Load message for DLQ entry
 	message, err := w.mr.Load(ctx, queueItem.MessageID)
 	if err != nil {
@@ -402,10 +975,6 @@ func (w *QueueWorker) moveToDLQ(ctx context.Context, queueItem *model.Queue, _ e
 		callbackURL = "unknown" // Still create DLQ entry with placeholder
 	}
 
-	// Determine failure reason
-	failureReason := fmt.Sprintf("Max retry attempts exceeded (%d >= %d)",
-		queueItem.AttemptCount, w.retryStrategy.DLQThreshold)
-
 	// Create DLQ entry
 	dlqEntry := model.NewDeadLetterQueue(
 		queueItem.SubscriptionID,
@@ -425,6 +994,7 @@ func (w *QueueWorker) moveToDLQ(ctx context.Context, queueItem *model.Queue, _ e
 	if err != nil {
 		return fmt.Errorf("failed to save DLQ entry: %w", err)
 	}
+	w.stats.recordDeadLettered(queueItem.SubscriptionID)
 
 	// Delete from queue (moved to DLQ)
 	if err := w.qr.Delete(ctx, queueItem); err != nil {
@@ -443,6 +1013,41 @@ func (w *QueueWorker) moveToDLQ(ctx context.Context, queueItem *model.Queue, _ e
 	return nil
 }
 
+// dlqFailureReason describes why a queue item is dead-lettered.
+func (w *QueueWorker) dlqFailureReason(queueItem *model.Queue, deliveryErr error) string {
+	var de *DeliveryError
+	hasDeliveryErr := errors.As(deliveryErr, &de)
+
+	if hasDeliveryErr && !de.Retryable {
+		return fmt.Sprintf("Permanent delivery failure: %s", de.Reason)
+	}
+
+	failureReason := fmt.Sprintf("Max retry attempts exceeded (%d >= %d)",
+		queueItem.AttemptCount, w.retryStrategy.DLQThreshold)
+	if hasDeliveryErr && de.Reason != "" {
+		failureReason += ", last failure: " + de.Reason
+	}
+	return failureReason
+}
+
+// Stats returns a snapshot of the worker's runtime statistics: cumulative delivery,
+// failure, DLQ and expiry counts (in total and per subscription), deliveries in progress,
+// the last batch and recent delivery latency.
+//
+// Safe to call while the worker is running, e.g. from a monitoring endpoint.
+func (w *QueueWorker) Stats() WorkerStats {
+	stats := w.stats.snapshot()
+	stats.WorkerID = w.workerID
+	if w.partitions != nil {
+		stats.Partitions = w.partitions.current().Owned
+	}
+	stats.Leader = true
+	if w.leader != nil {
+		stats.Leader, stats.LeaderSince = w.leader.Leadership()
+	}
+	return stats
+}
+
 // GetDLQStats retrieves Dead Letter Queue statistics for monitoring.
 // Returns aggregated stats including total count, unresolved count, resolution rate, and average age.
 //
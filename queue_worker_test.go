@@ -0,0 +1,509 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deliveryFunc is a MessageDeliveryGateway calling a function.
+type deliveryFunc func(ctx context.Context, callbackURL string, message *model.DataMessage) error
+
+func (f deliveryFunc) DeliverMessage(ctx context.Context, callbackURL string, message *model.DataMessage) error {
+	return f(ctx, callbackURL, message)
+}
+
+// worker creates a queue worker on the fixture's repositories that delivers through gateway.
+func (f *publisherFixture) worker(t *testing.T, gateway pubsub.MessageDeliveryGateway, opts ...pubsub.Option) *pubsub.QueueWorker {
+	t.Helper()
+	opts = append([]pubsub.Option{
+		pubsub.WithRepositories(f.repos.Queue, f.repos.Message, f.repos.Subscription, f.repos.DLQ),
+		pubsub.WithDelivery(pubsub.NewSubscriberTransmitterProvider(f.repos.Subscriber), gateway),
+		pubsub.WithLogger(&pubsub.NoopLogger{}),
+	}, opts...)
+	worker, err := pubsub.NewQueueWorker(opts...)
+	require.NoError(t, err)
+	return worker
+}
+
+// queueItem loads the fixture subscription's queue item of a message.
+func (f *publisherFixture) queueItem(t *testing.T, messageID int64) model.Queue {
+	t.Helper()
+	item, err := f.repos.Queue.FindByMessageID(context.Background(), f.subscription.ID, messageID)
+	require.NoError(t, err)
+	return item
+}
+
+func TestQueueWorker_DeliveryErrors(t *testing.T) {
+	t.Run("permanent failure is dead-lettered", func(t *testing.T) {
+		ctx := context.Background()
+		f := newPublisherFixture(t)
+		attempts := 0
+		worker := f.worker(t, deliveryFunc(func(context.Context, string, *model.DataMessage) error {
+			attempts++
+			return pubsub.NewPermanentDeliveryError(410, "HTTP 410 Gone", nil)
+		}))
+
+		published, err := f.publisher(t).Publish(ctx, f.request())
+		require.NoError(t, err)
+		_, err = worker.ProcessPendingItems(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempts)
+
+		// No retry scheduled
+		_, err = f.repos.Queue.FindByMessageID(ctx, f.subscription.ID, published.MessageID)
+		assert.ErrorIs(t, err, pubsub.ErrNoData)
+		dlq, err := f.repos.DLQ.FindByMessageID(ctx, published.MessageID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, dlq.AttemptCount)
+		assert.Equal(t, "Permanent delivery failure: HTTP 410 Gone", dlq.FailureReason)
+	})
+
+	t.Run("retry after is honored", func(t *testing.T) {
+		ctx := context.Background()
+		f := newPublisherFixture(t)
+		worker := f.worker(t, deliveryFunc(func(context.Context, string, *model.DataMessage) error {
+			return &pubsub.DeliveryError{StatusCode: 429, Retryable: true, Reason: "HTTP 429 Too Many Requests",
+				RetryAfter: 10 * time.Minute, HasRetryAfter: true}
+		}))
+
+		published, err := f.publisher(t).Publish(ctx, f.request())
+		require.NoError(t, err)
+		_, err = worker.ProcessPendingItems(ctx)
+		require.NoError(t, err)
+
+		item := f.queueItem(t, published.MessageID)
+		assert.Equal(t, model.QueueStatusFailed, item.Status)
+		assert.Equal(t, int64(600), item.RetryAfterSeconds.Int64)
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), item.NextRetryAt.Time, 5*time.Second)
+	})
+
+	t.Run("other errors are transient", func(t *testing.T) {
+		ctx := context.Background()
+		f := newPublisherFixture(t)
+		worker := f.worker(t, deliveryFunc(func(context.Context, string, *model.DataMessage) error {
+			return errors.New("connection refused")
+		}))
+
+		published, err := f.publisher(t).Publish(ctx, f.request())
+		require.NoError(t, err)
+		_, err = worker.ProcessPendingItems(ctx)
+		require.NoError(t, err)
+
+		item := f.queueItem(t, published.MessageID)
+		assert.Equal(t, model.QueueStatusFailed, item.Status)
+		assert.Equal(t, 1, item.AttemptCount)
+		assert.False(t, item.RetryAfterSeconds.Valid)
+	})
+}
+
+// statuses counts the fixture subscription's queue items by status.
+func (f *publisherFixture) statuses(t *testing.T) map[model.QueueStatus]int {
+	t.Helper()
+	items, err := f.repos.Queue.FindBySubscriptionID(context.Background(), f.subscription.ID)
+	require.NoError(t, err)
+	statuses := map[model.QueueStatus]int{}
+	for _, item := range items {
+		statuses[item.Status]++
+	}
+	return statuses
+}
+
+func TestQueueWorker_Concurrency(t *testing.T) {
+	const concurrency = 4
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+	)
+	allArrived := make(chan struct{})
+	worker := f.worker(t, deliveryFunc(func(context.Context, string, *model.DataMessage) error {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		if inFlight == concurrency {
+			close(allArrived)
+		}
+		mu.Unlock()
+
+		// Hold each delivery until all of them run in parallel
+		select {
+		case <-allArrived:
+		case <-time.After(2 * time.Second):
+		}
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return nil
+	}), pubsub.WithConcurrency(concurrency))
+
+	publisher := f.publisher(t)
+	for range concurrency {
+		_, err := publisher.Publish(ctx, f.request())
+		require.NoError(t, err)
+	}
+
+	processed, err := worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, concurrency, processed)
+	assert.Equal(t, concurrency, maxInFlight)
+	assert.Equal(t, map[model.QueueStatus]int{model.QueueStatusSent: concurrency}, f.statuses(t))
+}
+
+func TestQueueWorker_CancelWaitsForInFlightDeliveries(t *testing.T) {
+	f := newPublisherFixture(t)
+	arrived := make(chan struct{}, 4)
+	release := make(chan struct{})
+	worker := f.worker(t, deliveryFunc(func(context.Context, string, *model.DataMessage) error {
+		arrived <- struct{}{}
+		<-release
+		return nil
+	}), pubsub.WithConcurrency(2))
+
+	publisher := f.publisher(t)
+	for range 4 {
+		_, err := publisher.Publish(context.Background(), f.request())
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan int)
+	go func() {
+		processed, err := worker.ProcessPendingItems(ctx)
+		assert.NoError(t, err)
+		done <- processed
+	}()
+
+	// Cancel while two deliveries are in flight
+	<-arrived
+	<-arrived
+	cancel()
+
+	select {
+	case <-done:
+		t.Fatal("ProcessPendingItems returned before in-flight deliveries finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	// In-flight deliveries complete; remaining items are left for the next run
+	assert.Equal(t, 2, <-done)
+	assert.Equal(t, map[model.QueueStatus]int{model.QueueStatusSent: 2, model.QueueStatusPending: 2}, f.statuses(t))
+}
+
+func TestQueueWorker_OrderingKey(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+
+	var mu sync.Mutex
+	var received []string
+	failing := map[string]bool{}
+	worker := f.worker(t, deliveryFunc(func(_ context.Context, _ string, message *model.DataMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing[message.MessageID] {
+			return errors.New("service unavailable")
+		}
+		received = append(received, message.OrderingKey+":"+message.MessageID)
+		return nil
+	}), pubsub.WithConcurrency(4))
+
+	publisher := f.publisher(t)
+	publish := func(orderingKey string) int64 {
+		req := f.request()
+		req.OrderingKey = orderingKey
+		result, err := publisher.Publish(ctx, req)
+		require.NoError(t, err)
+		return result.MessageID
+	}
+	key := func(orderingKey string, messageID int64) string {
+		return orderingKey + ":" + strconv.FormatInt(messageID, 10)
+	}
+	a1, a2 := publish("order-a"), publish("order-a")
+	b1, b2 := publish("order-b"), publish("order-b")
+
+	// The head of order-a fails: order-a is blocked, order-b keeps flowing
+	failing[strconv.FormatInt(a1, 10)] = true
+	for range 3 {
+		_, err := worker.ProcessPendingItems(ctx)
+		require.NoError(t, err)
+	}
+	mu.Lock()
+	assert.Equal(t, []string{key("order-b", b1), key("order-b", b2)}, received)
+	failing = map[string]bool{}
+	received = nil
+	mu.Unlock()
+
+	// Once the head is retried successfully, the rest of order-a follows in order
+	head := f.queueItem(t, a1)
+	head.Defer(time.Now())
+	_, err := f.repos.Queue.Save(ctx, &head)
+	require.NoError(t, err)
+
+	processed, err := worker.ProcessRetryableItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	processed, err = worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, []string{key("order-a", a1), key("order-a", a2)}, received)
+}
+
+func TestQueueWorker_StopDrainsInFlightDeliveries(t *testing.T) {
+	f := newPublisherFixture(t)
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	wakeup := pubsub.NewChannelWakeup()
+	worker := f.worker(t, deliveryFunc(func(context.Context, string, *model.DataMessage) error {
+		arrived <- struct{}{}
+		<-release
+		return nil
+	}), pubsub.WithWakeup(wakeup))
+	worker.Wait() // Not running: returns immediately
+
+	require.NoError(t, worker.Start(time.Hour))
+	assert.ErrorIs(t, worker.Start(time.Hour), pubsub.ErrWorkerAlreadyStarted)
+	waited := make(chan struct{})
+	go func() {
+		defer close(waited)
+		worker.Wait()
+	}()
+
+	_, err := f.publisher(t, pubsub.WithPublisherWakeup(wakeup)).Publish(context.Background(), f.request())
+	require.NoError(t, err)
+	<-arrived
+
+	type stopResult struct {
+		abandoned int
+		err       error
+	}
+	done := make(chan stopResult)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		abandoned, err := worker.Stop(ctx)
+		done <- stopResult{abandoned, err}
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Stop returned before the in-flight delivery finished")
+	case <-waited:
+		t.Fatal("Wait returned before the in-flight delivery finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	result := <-done
+	require.NoError(t, result.err)
+	assert.Equal(t, 0, result.abandoned)
+	<-waited
+	assert.Equal(t, map[model.QueueStatus]int{model.QueueStatusSent: 1}, f.statuses(t))
+
+	// Stopped worker can be stopped again and started again
+	abandoned, err := worker.Stop(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, abandoned)
+	require.NoError(t, worker.Start(time.Hour))
+	_, err = worker.Stop(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestQueueWorker_StopAbandonsDeliveriesAfterDeadline(t *testing.T) {
+	f := newPublisherFixture(t)
+	arrived := make(chan struct{}, 1)
+	wakeup := pubsub.NewChannelWakeup()
+	worker := f.worker(t, deliveryFunc(func(ctx context.Context, _ string, _ *model.DataMessage) error {
+		arrived <- struct{}{}
+		<-ctx.Done() // Doesn't answer: the worker must give up
+		return ctx.Err()
+	}), pubsub.WithWakeup(wakeup))
+	require.NoError(t, worker.Start(time.Hour))
+
+	published, err := f.publisher(t, pubsub.WithPublisherWakeup(wakeup)).Publish(context.Background(), f.request())
+	require.NoError(t, err)
+	<-arrived
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	abandoned, err := worker.Stop(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, abandoned)
+
+	// The item is handed back without counting the interrupted attempt
+	item := f.queueItem(t, published.MessageID)
+	assert.Equal(t, model.QueueStatusPending, item.Status)
+	assert.Zero(t, item.AttemptCount)
+	assert.False(t, item.LeaseOwner.Valid)
+}
+
+func TestQueueWorker_FailedItemOutlivesTTLUntilRetry(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+	worker := f.worker(t, deliveryFunc(func(context.Context, string, *model.DataMessage) error {
+		return errors.New("service unavailable")
+	}))
+
+	req := f.request()
+	req.TTL = time.Second
+	published, err := f.publisher(t).Publish(ctx, req)
+	require.NoError(t, err)
+	_, err = worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+
+	// A TTL shorter than the retry schedule does not cut it
+	failed := f.queueItem(t, published.MessageID)
+	require.Equal(t, model.QueueStatusFailed, failed.Status)
+	assert.True(t, failed.ExpiresAt.After(failed.NextRetryAt.Time))
+}
+
+func TestQueueWorker_CleanupExpiredItems(t *testing.T) {
+	tests := []struct {
+		name            string
+		dropExpired     bool
+		topicRepository bool
+		wantDLQ         bool
+	}{
+		{name: "dead-lettered", topicRepository: true, wantDLQ: true},
+		{name: "dropped by ephemeral topic", dropExpired: true, topicRepository: true},
+		{name: "dead-lettered without topic settings", dropExpired: true, wantDLQ: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newPublisherFixture(t)
+			f.topic.DropExpired = tt.dropExpired
+			_, err := f.repos.Topic.Save(ctx, f.topic)
+			require.NoError(t, err)
+
+			var opts []pubsub.Option
+			if tt.topicRepository {
+				opts = append(opts, pubsub.WithTopicRepository(f.repos.Topic))
+			}
+			worker := f.worker(t, deliveryFunc(func(context.Context, string, *model.DataMessage) error {
+				t.Error("expired message was delivered")
+				return nil
+			}), opts...)
+
+			req := f.request()
+			req.TTL = time.Millisecond
+			expired, err := f.publisher(t).Publish(ctx, req)
+			require.NoError(t, err)
+			time.Sleep(5 * time.Millisecond)
+
+			processed, err := worker.ProcessPendingItems(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, processed)
+
+			cleaned, err := worker.CleanupExpiredItems(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, cleaned)
+			assert.Zero(t, f.backlog(t), "expired item left the queue")
+
+			dlq, err := f.repos.DLQ.FindByMessageID(ctx, expired.MessageID)
+			if tt.wantDLQ {
+				require.NoError(t, err)
+				assert.Equal(t, "Expired after 0 attempts", dlq.FailureReason)
+			} else {
+				assert.ErrorIs(t, err, pubsub.ErrNoData)
+			}
+			assert.Equal(t, int64(1), worker.Stats().Expired)
+		})
+	}
+}
+
+func TestQueueWorker_Stats(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+	gone := false
+	worker := f.worker(t, deliveryFunc(func(context.Context, string, *model.DataMessage) error {
+		if gone {
+			return pubsub.NewPermanentDeliveryError(410, "HTTP 410 Gone", nil)
+		}
+		return nil
+	}))
+	publisher := f.publisher(t)
+	publish := func(ttl time.Duration) {
+		req := f.request()
+		req.TTL = ttl
+		_, err := publisher.Publish(ctx, req)
+		require.NoError(t, err)
+	}
+
+	publish(0)
+	publish(0)
+	processed, err := worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, processed)
+
+	// A permanent failure and an expired item are dead-lettered
+	gone = true
+	publish(0)
+	_, err = worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	publish(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	_, err = worker.CleanupExpiredItems(ctx)
+	require.NoError(t, err)
+
+	stats := worker.Stats()
+	assert.NotEmpty(t, stats.WorkerID)
+	assert.Equal(t, int64(2), stats.Delivered)
+	assert.Equal(t, int64(1), stats.Failed)
+	assert.Equal(t, int64(2), stats.DeadLettered)
+	assert.Equal(t, int64(1), stats.Expired)
+	assert.Zero(t, stats.InFlight)
+	assert.Positive(t, stats.AvgLatency)
+	assert.Positive(t, stats.P95Latency)
+	assert.Equal(t, pubsub.SubscriptionStats{Delivered: 2, Failed: 1, DeadLettered: 2, Expired: 1},
+		stats.Subscriptions[f.subscription.ID])
+}
+
+func TestQueueWorker_OpenCircuitDoesNotOutliveTTL(t *testing.T) {
+	ctx := context.Background()
+	f := newPublisherFixture(t)
+	publisher := f.publisher(t)
+	worker := f.worker(t,
+		deliveryFunc(func(context.Context, string, *model.DataMessage) error { return errors.New("connection refused") }),
+		pubsub.WithCircuitBreaker(pubsub.CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Hour}),
+	)
+
+	// The first failure opens the circuit
+	_, err := publisher.Publish(ctx, f.request())
+	require.NoError(t, err)
+	_, err = worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+
+	// The open circuit defers the next item without an attempt, far beyond its TTL
+	req := f.request()
+	req.TTL = 20 * time.Millisecond
+	held, err := publisher.Publish(ctx, req)
+	require.NoError(t, err)
+	processed, err := worker.ProcessPendingItems(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, processed)
+	item := f.queueItem(t, held.MessageID)
+	require.True(t, item.NextRetryAt.Valid)
+	assert.True(t, item.ExpiresAt.Before(item.NextRetryAt.Time), "deferral keeps the expiry")
+
+	time.Sleep(2 * req.TTL)
+	cleaned, err := worker.CleanupExpiredItems(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cleaned)
+	dlq, err := f.repos.DLQ.FindByMessageID(ctx, held.MessageID)
+	require.NoError(t, err)
+	assert.Equal(t, "Expired after 0 attempts", dlq.FailureReason)
+}
@@ -0,0 +1,179 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token-bucket delivery rate limit.
+// The zero value means unlimited.
+type RateLimit struct {
+	// PerSecond is the sustained number of deliveries per second (<= 0 = unlimited).
+	PerSecond float64
+
+	// Burst is the number of deliveries allowed at once. Defaults to ceil(PerSecond), at least 1.
+	Burst int
+}
+
+// Unlimited reports whether the limit does not restrict deliveries.
+func (l RateLimit) Unlimited() bool {
+	return l.PerSecond <= 0
+}
+
+// burst returns the effective bucket size.
+func (l RateLimit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.PerSecond))
+}
+
+// RateLimitProvider provides per-subscriber delivery rate limits.
+//
+// SubscriberTransmitterProvider implements it using model.Subscriber.RateLimit and RateBurst.
+type RateLimitProvider interface {
+	// GetRateLimit returns the delivery rate limit for a subscriber.
+	// A zero RateLimit means unlimited.
+	GetRateLimit(ctx context.Context, subscriberID int64) (RateLimit, error)
+}
+
+// DeliveryTarget is where and how fast a subscriber's deliveries may be sent.
+type DeliveryTarget struct {
+	CallbackURL string
+	RateLimit   RateLimit
+}
+
+// DeliveryTargetProvider resolves a subscriber's callback URL and rate limit with one lookup.
+//
+// When the TransmitterProvider passed to WithDelivery is also the RateLimitProvider passed to
+// WithRateLimiting and implements this interface, the worker loads each subscriber once per
+// delivery attempt instead of once per provider. SubscriberTransmitterProvider implements it.
+type DeliveryTargetProvider interface {
+	// GetDeliveryTarget returns the callback URL and delivery rate limit of a subscriber.
+	// Returns ErrNoData if the subscriber does not exist.
+	GetDeliveryTarget(ctx context.Context, subscriberID int64) (DeliveryTarget, error)
+}
+
+// bucketPruneInterval is how often idle token buckets are removed.
+const bucketPruneInterval = time.Minute
+
+// tokenBucket is the state of a single rate limit key.
+type tokenBucket struct {
+	tokens        float64
+	last          time.Time
+	deferredUntil time.Time // Latest retry time handed out to a deferred delivery
+	idleAt        time.Time // When the bucket is full again with no deferred deliveries
+}
+
+// rateLimiter holds token buckets per subscriber and per destination host.
+//
+// Thread safety: Safe for concurrent use.
+type rateLimiter struct {
+	mu         sync.Mutex
+	hostLimit  RateLimit // zero = no per-host limit
+	buckets    map[string]*tokenBucket
+	lastPruned time.Time
+	now        func() time.Time
+}
+
+// newRateLimiter creates an empty rate limiter.
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// reserve takes one token for key if available.
+// Otherwise it returns false and the time to try again. Deliveries deferred in a row get
+// retry times one token interval apart, so they are spread by their token deficit instead
+// of all waking at once. Caller must hold mu.
+func (rl *rateLimiter) reserve(key string, limit RateLimit, now time.Time) (bool, time.Time) {
+	burst := limit.burst()
+	interval := time.Duration(float64(time.Second) / limit.PerSecond)
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		rl.buckets[key] = b
+	}
+
+	// Refill since the last reservation
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.PerSecond)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	var retryAt time.Time
+	if allowed {
+		b.tokens--
+	} else {
+		retryAt = now.Add(time.Duration((1 - b.tokens) * float64(interval)))
+		if !b.deferredUntil.Before(retryAt) {
+			retryAt = b.deferredUntil.Add(interval)
+		}
+		b.deferredUntil = retryAt
+	}
+
+	b.idleAt = now.Add(time.Duration((burst - b.tokens) * float64(interval)))
+	if b.idleAt.Before(b.deferredUntil) {
+		b.idleAt = b.deferredUntil
+	}
+	return allowed, retryAt
+}
+
+// prune removes buckets that are full again and have no deferred deliveries:
+// they behave exactly like a new bucket. Runs at most once per bucketPruneInterval.
+// Caller must hold mu.
+func (rl *rateLimiter) prune(now time.Time) {
+	if now.Sub(rl.lastPruned) < bucketPruneInterval {
+		return
+	}
+	rl.lastPruned = now
+	for key, b := range rl.buckets {
+		if !b.idleAt.After(now) {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// allow reports whether a delivery to the subscriber at callbackURL may happen now.
+// Both the subscriber's limit and the host limit must have a token; tokens are only taken
+// when both allow the delivery. When not allowed, retryAt is the time to try again.
+func (rl *rateLimiter) allow(subscriberID int64, subscriberLimit RateLimit, callbackURL string) (bool, time.Time) {
+	type check struct {
+		key   string
+		limit RateLimit
+	}
+	var checks []check
+	if !subscriberLimit.Unlimited() {
+		checks = append(checks, check{key: fmt.Sprintf("subscriber:%d", subscriberID), limit: subscriberLimit})
+	}
+	if !rl.hostLimit.Unlimited() {
+		if u, err := url.Parse(callbackURL); err == nil && u.Host != "" {
+			checks = append(checks, check{key: "host:" + u.Host, limit: rl.hostLimit})
+		}
+	}
+	if len(checks) == 0 {
+		return true, time.Time{}
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	rl.prune(now)
+	for i, c := range checks {
+		if ok, retryAt := rl.reserve(c.key, c.limit, now); !ok {
+			// Give back tokens taken by earlier checks
+			for _, taken := range checks[:i] {
+				rl.buckets[taken.key].tokens++
+			}
+			return false, retryAt
+		}
+	}
+	return true, time.Time{}
+}
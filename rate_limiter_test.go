@@ -0,0 +1,273 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coregx/pubsub/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rateLimitFunc adapts a function to RateLimitProvider.
+type rateLimitFunc func(ctx context.Context, subscriberID int64) (RateLimit, error)
+
+func (f rateLimitFunc) GetRateLimit(ctx context.Context, subscriberID int64) (RateLimit, error) {
+	return f(ctx, subscriberID)
+}
+
+func TestRateLimit_Burst(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit RateLimit
+		want  float64
+	}{
+		{"explicit burst", RateLimit{PerSecond: 10, Burst: 3}, 3},
+		{"derived from rate", RateLimit{PerSecond: 2.5}, 3},
+		{"at least one", RateLimit{PerSecond: 0.1}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.limit.burst())
+		})
+	}
+	assert.True(t, RateLimit{}.Unlimited())
+}
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	limit := RateLimit{PerSecond: 2, Burst: 2} // A token every 500ms
+
+	tests := []struct {
+		name        string
+		advance     []time.Duration // Clock advance before each reservation
+		wantAllowed []bool
+		wantWait    time.Duration // Wait reported by the last reservation if not allowed
+	}{
+		{
+			name:        "burst is available at once",
+			advance:     []time.Duration{0, 0},
+			wantAllowed: []bool{true, true},
+		},
+		{
+			name:        "empty bucket reports the time to the next token",
+			advance:     []time.Duration{0, 0, 0},
+			wantAllowed: []bool{true, true, false},
+			wantWait:    500 * time.Millisecond,
+		},
+		{
+			name:        "partially refilled bucket reports the remaining time",
+			advance:     []time.Duration{0, 0, 200 * time.Millisecond},
+			wantAllowed: []bool{true, true, false},
+			wantWait:    300 * time.Millisecond,
+		},
+		{
+			name:        "tokens refill at the sustained rate",
+			advance:     []time.Duration{0, 0, 500 * time.Millisecond, 0},
+			wantAllowed: []bool{true, true, true, false},
+			wantWait:    500 * time.Millisecond,
+		},
+		{
+			name:        "refill is capped at the burst",
+			advance:     []time.Duration{0, 0, time.Hour, 0, 0},
+			wantAllowed: []bool{true, true, true, true, false},
+			wantWait:    500 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newTestClock()
+			rl := newRateLimiter()
+
+			var allowed bool
+			var retryAt time.Time
+			for i, advance := range tt.advance {
+				clock.Advance(advance)
+				allowed, retryAt = rl.reserve("key", limit, clock.Now())
+				assert.Equal(t, tt.wantAllowed[i], allowed, "reservation %d", i)
+			}
+			if !allowed {
+				assert.Equal(t, clock.Now().Add(tt.wantWait), retryAt)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	limit := RateLimit{PerSecond: 1, Burst: 1}
+
+	clock := newTestClock()
+	rl := newRateLimiter()
+	rl.now = clock.Now
+	rl.hostLimit = RateLimit{PerSecond: 1, Burst: 2}
+
+	allowed, _ := rl.allow(1, limit, "https://a.example/hook")
+	assert.True(t, allowed)
+
+	// Subscriber 1 is out of tokens; the host token is not taken
+	allowed, retryAt := rl.allow(1, limit, "https://a.example/hook")
+	assert.False(t, allowed)
+	assert.Equal(t, clock.Now().Add(time.Second), retryAt)
+
+	// The host bucket (burst 2) still has one token for an unlimited subscriber
+	allowed, _ = rl.allow(2, RateLimit{}, "https://a.example/other")
+	assert.True(t, allowed)
+
+	allowed, _ = rl.allow(2, RateLimit{}, "https://a.example/other")
+	assert.False(t, allowed, "host limit applies across subscribers")
+
+	allowed, _ = rl.allow(2, RateLimit{}, "https://b.example/hook")
+	assert.True(t, allowed, "hosts are limited separately")
+}
+
+func TestRateLimiter_AllowGivesBackTokens(t *testing.T) {
+	clock := newTestClock()
+	rl := newRateLimiter()
+	rl.now = clock.Now
+	rl.hostLimit = RateLimit{PerSecond: 1, Burst: 1}
+	limit := RateLimit{PerSecond: 1, Burst: 2}
+
+	allowed, _ := rl.allow(1, limit, "https://a.example/hook")
+	require.True(t, allowed)
+
+	// The host refuses: the subscriber token taken by the first check is returned
+	allowed, _ = rl.allow(1, limit, "https://a.example/hook")
+	require.False(t, allowed)
+	assert.InDelta(t, 1, rl.buckets["subscriber:1"].tokens, 1e-9)
+}
+
+func TestRateLimiter_StaggersDeferrals(t *testing.T) {
+	limit := RateLimit{PerSecond: 4, Burst: 1} // A token every 250ms
+
+	clock := newTestClock()
+	rl := newRateLimiter()
+	rl.now = clock.Now
+
+	steps := []struct {
+		advance     time.Duration
+		wantAllowed bool
+		wantWait    time.Duration
+	}{
+		{0, true, 0},
+		// A batch deferred at once: each item waits for its own token
+		{0, false, 250 * time.Millisecond},
+		{0, false, 500 * time.Millisecond},
+		{0, false, 750 * time.Millisecond},
+		// Later deferrals queue up behind the earlier ones
+		{100 * time.Millisecond, false, 900 * time.Millisecond},
+		// Once the handed out slots have passed, waits start over
+		{2 * time.Second, true, 0},
+		{0, false, 250 * time.Millisecond},
+	}
+
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		allowed, retryAt := rl.allow(1, limit, "")
+		require.Equal(t, step.wantAllowed, allowed, "step %d", i)
+		if !allowed {
+			assert.Equal(t, step.wantWait, retryAt.Sub(clock.Now()), "step %d", i)
+		}
+	}
+}
+
+func TestRateLimiter_PrunesIdleBuckets(t *testing.T) {
+	clock := newTestClock()
+	rl := newRateLimiter()
+	rl.now = clock.Now
+	slow := RateLimit{PerSecond: 0.001, Burst: 1} // Refills in 1000s
+	fast := RateLimit{PerSecond: 10, Burst: 1}
+
+	rl.allow(1, slow, "")
+	rl.allow(2, fast, "")
+	rl.allow(2, fast, "") // Deferred
+	require.Len(t, rl.buckets, 2)
+
+	// The fast bucket refilled and is pruned; the slow one is still empty and kept
+	clock.Advance(bucketPruneInterval)
+	allowed, _ := rl.allow(1, slow, "")
+	assert.False(t, allowed, "pruning must not reset a draining bucket")
+	assert.NotContains(t, rl.buckets, "subscriber:2")
+	assert.Contains(t, rl.buckets, "subscriber:1")
+
+	// Pruning runs at most once per interval
+	rl.buckets["subscriber:3"] = &tokenBucket{}
+	clock.Advance(time.Second)
+	rl.allow(2, fast, "")
+	assert.Contains(t, rl.buckets, "subscriber:3")
+	clock.Advance(bucketPruneInterval)
+	rl.allow(2, fast, "")
+	assert.NotContains(t, rl.buckets, "subscriber:3")
+
+	// Refilled after its deferred slot, the slow bucket is pruned too
+	clock.Advance(time.Hour)
+	allowed, _ = rl.allow(2, fast, "")
+	assert.True(t, allowed)
+	assert.NotContains(t, rl.buckets, "subscriber:1")
+}
+
+// testSubscribers is a SubscriberRepository holding one subscriber that counts its loads.
+type testSubscribers struct {
+	SubscriberRepository
+	subscriber model.Subscriber
+	loads      atomic.Int64
+}
+
+func (s *testSubscribers) Load(_ context.Context, id int64) (model.Subscriber, error) {
+	s.loads.Add(1)
+	if id != s.subscriber.ID {
+		return model.Subscriber{}, ErrNoData
+	}
+	return s.subscriber, nil
+}
+
+func TestQueueWorker_DeliveryTarget(t *testing.T) {
+	ctx := context.Background()
+	subscribers := &testSubscribers{subscriber: model.Subscriber{
+		ID: 1, WebhookURL: "https://a.example/hook", IsActive: true, RateLimit: 2, RateBurst: 5,
+	}}
+	shared := NewSubscriberTransmitterProvider(subscribers)
+	limited := DeliveryTarget{CallbackURL: "https://a.example/hook", RateLimit: RateLimit{PerSecond: 2, Burst: 5}}
+
+	tests := []struct {
+		name      string
+		limits    RateLimitProvider
+		want      DeliveryTarget
+		wantLoads int64
+	}{
+		{"without rate limiting", nil, DeliveryTarget{CallbackURL: "https://a.example/hook"}, 1},
+		{"one provider for both loads the subscriber once", shared, limited, 1},
+		{"separate providers", NewSubscriberTransmitterProvider(subscribers), limited, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &QueueWorker{
+				transmitterProvider: shared,
+				rateLimitProvider:   tt.limits,
+				targetProvider:      sharedTargetProvider(shared, tt.limits),
+			}
+
+			subscribers.loads.Store(0)
+			target, err := w.deliveryTarget(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, target)
+			assert.Equal(t, tt.wantLoads, subscribers.loads.Load())
+		})
+	}
+
+	t.Run("rate limit provider error", func(t *testing.T) {
+		w := &QueueWorker{
+			transmitterProvider: shared,
+			rateLimitProvider: rateLimitFunc(func(context.Context, int64) (RateLimit, error) {
+				return RateLimit{}, errors.New("db down")
+			}),
+		}
+
+		_, err := w.deliveryTarget(ctx, 1)
+		assert.ErrorContains(t, err, "db down")
+	})
+}
@@ -2,6 +2,7 @@ package pubsub
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/coregx/pubsub/model"
@@ -41,21 +42,66 @@ type QueueRepository interface {
 	// Returns empty slice if none found.
 	FindBySubscriptionID(ctx context.Context, subscriptionID int64) ([]model.Queue, error)
 
+	// FindAllByMessageID retrieves the queue items of a message for all subscriptions,
+	// ordered by ID. Returns ErrNoData if none found.
+	FindAllByMessageID(ctx context.Context, messageID int64) ([]model.Queue, error)
+
 	// FindPendingItems finds queue items ready for first-time delivery.
 	// Items must have status=PENDING and next_retry_at <= now.
-	// Results are ordered by created_at ASC (FIFO).
+	// Results are ordered by priority_at ASC (higher priorities first, with aging; see Queue.Prioritize).
 	FindPendingItems(ctx context.Context, limit int) ([]model.Queue, error)
 
 	// FindRetryableItems finds queue items ready for retry.
 	// Items must have status=FAILED and next_retry_at <= now.
-	// Results are ordered by created_at ASC (oldest failures first).
+	// Results are ordered by priority_at ASC (higher priorities first, with aging).
 	FindRetryableItems(ctx context.Context, limit int) ([]model.Queue, error)
 
+	// ClaimItems atomically leases up to limit items with the given status that are due
+	// (next_retry_at <= now), not expired (expires_at > now) and not leased by another worker,
+	// or whose lease has expired.
+	// Items with an ordering key are only claimable when no earlier item (lower sequence_number)
+	// with the same subscription and key is still unsent, so a failing head blocks its key.
+	// Items of paused subscriptions are skipped (see model.Subscription.IsPausedAt).
+	// Claimed items get lease_owner=owner and lease_expires_at=now+leaseDuration,
+	// so concurrent workers never receive the same item while its lease is valid.
+	// Items are claimed and returned in priority_at ASC order. Returns ErrNoData if nothing was claimed.
+	ClaimItems(ctx context.Context, status model.QueueStatus, owner string, leaseDuration time.Duration, limit int) ([]model.Queue, error)
+
+	// ClaimPartitionItems works like ClaimItems, but only claims items of subscriptions
+	// in the given partitions (subscription_id % partitions.Count, see PartitionOf).
+	// Returns ErrNoData if nothing was claimed.
+	ClaimPartitionItems(ctx context.Context, status model.QueueStatus, partitions PartitionSet, owner string, leaseDuration time.Duration, limit int) ([]model.Queue, error)
+
+	// ClaimExpiredItems atomically leases up to limit undelivered items (status != SENT) whose
+	// expires_at has passed and that are not leased by a live worker, so each expired item is
+	// dead-lettered or deleted by one worker only. Items of paused subscriptions are skipped,
+	// so a held backlog does not expire while paused. Returns ErrNoData if nothing was claimed.
+	ClaimExpiredItems(ctx context.Context, owner string, leaseDuration time.Duration, limit int) ([]model.Queue, error)
+
+	// DeleteScheduled deletes the queue items of a message that are scheduled for a future
+	// first delivery (status=PENDING, next_retry_at > now) and not leased by a worker.
+	// The check and delete are atomic, so an item is either canceled or delivered, never both.
+	// Returns the number of deleted items (0 if the message has no scheduled items).
+	DeleteScheduled(ctx context.Context, messageID int64) (int, error)
+
 	// FindExpiredItems finds queue items that have expired.
 	// Items must have expires_at <= now and status != SENT.
 	// Results are ordered by expires_at ASC (oldest first).
 	FindExpiredItems(ctx context.Context, limit int) ([]model.Queue, error)
 
+	// CountBacklog counts the undelivered queue items (status != SENT) of a subscription,
+	// including scheduled ones.
+	CountBacklog(ctx context.Context, subscriptionID int64) (int, error)
+
+	// DeleteOldest deletes up to count of the oldest undelivered queue items of a subscription
+	// that are not leased by a live worker. Returns the number of deleted items.
+	DeleteOldest(ctx context.Context, subscriptionID int64, count int) (int, error)
+
+	// ExtendExpiry moves expires_at of the undelivered items (status != SENT) of a subscription
+	// to until where it is earlier, so the backlog held by a pause is delivered after resuming
+	// instead of expiring. Returns the number of updated items.
+	ExtendExpiry(ctx context.Context, subscriptionID int64, until time.Time) (int, error)
+
 	// UpdateNextRetry updates the retry schedule for a queue item.
 	// Used by retry middleware to schedule next delivery attempt.
 	UpdateNextRetry(ctx context.Context, id int64, nextRetryAt time.Time, attemptCount int) error
@@ -79,6 +125,26 @@ type MessageRepository interface {
 	// FindOutdatedMessages finds messages older than the specified number of days.
 	// Used for cleanup/archival operations.
 	FindOutdatedMessages(ctx context.Context, days int) ([]model.Message, error)
+
+	// FindPurgeable finds up to limit messages of a topic created before the cutoff
+	// that have no pending or failed queue items and no unresolved DLQ entries.
+	// Results are ordered by created_at ASC. Returns ErrNoData if none found.
+	FindPurgeable(ctx context.Context, topicID int64, before time.Time, limit int) ([]model.Message, error)
+
+	// Purge permanently removes messages together with their sent queue items, and their
+	// resolved DLQ entries if purgeResolvedDLQ is set (otherwise these are kept for audit).
+	// Messages that are no longer purgeable (see FindPurgeable) are skipped.
+	// Returns the number of removed messages.
+	Purge(ctx context.Context, ids []int64, purgeResolvedDLQ bool) (int, error)
+
+	// FindByIdempotencyKey finds the message of a topic that holds the idempotency key.
+	// Keys are unique per topic: Save fails for a second message with the same key.
+	// Returns ErrNoData if not found.
+	FindByIdempotencyKey(ctx context.Context, topicID int64, key string) (model.Message, error)
+
+	// ReleaseIdempotencyKey clears the idempotency key of a message whose idempotency window
+	// has passed, so that the key can be published again. Only the key column is updated.
+	ReleaseIdempotencyKey(ctx context.Context, id int64) error
 }
 
 // SubscriptionRepository defines the persistence interface for subscription mappings.
@@ -196,4 +262,72 @@ type TopicRepository interface {
 	// GetByTopicCode retrieves a topic by its unique code.
 	// Returns ErrNoData if not found.
 	GetByTopicCode(ctx context.Context, topicCode string) (model.Topic, error)
+
+	// FindWithRetention retrieves the topics with a retention period (RetentionDays > 0).
+	// Returns ErrNoData if none found.
+	FindWithRetention(ctx context.Context) ([]model.Topic, error)
+}
+
+// HeartbeatRepository defines the persistence interface for worker heartbeats.
+// Partitioned queue workers (see WithPartitioning) use it to discover each other.
+type HeartbeatRepository interface {
+	// Save records a heartbeat, creating or replacing the worker's entry.
+	Save(ctx context.Context, m model.WorkerHeartbeat) error
+
+	// FindAlive retrieves the workers whose last heartbeat is at or after since,
+	// ordered by worker ID. Returns ErrNoData if none found.
+	FindAlive(ctx context.Context, since time.Time) ([]model.WorkerHeartbeat, error)
+
+	// Delete removes a worker's heartbeat, e.g. when it shuts down.
+	Delete(ctx context.Context, workerID string) error
+}
+
+// LockRepository defines the persistence interface for named, expiring locks.
+// QueueWorker uses it for leader election (see WithLeaderElection).
+//
+// Implementations must make Acquire atomic, so at most one holder gets a lock at a time.
+type LockRepository interface {
+	// Acquire takes or renews the named lock for holder until expiresAt.
+	// Succeeds if the lock does not exist, has expired, or is already held by holder.
+	// Returns whether holder holds the lock afterwards.
+	Acquire(ctx context.Context, name, holder string, expiresAt time.Time) (bool, error)
+
+	// Release frees the named lock if it is held by holder.
+	Release(ctx context.Context, name, holder string) error
+}
+
+// OutboxRepository defines the persistence interface for publishing within a caller's
+// database transaction (transactional outbox, see Publisher.PublishTx).
+//
+// All writes use tx and become visible when the caller commits it. Reads use tx as well,
+// so they see the caller's uncommitted publishes.
+type OutboxRepository interface {
+	// SaveMessage inserts a message and returns it with populated ID.
+	SaveMessage(ctx context.Context, tx *sql.Tx, m model.Message) (model.Message, error)
+
+	// SaveQueueItem inserts a queue item and populates its ID.
+	SaveQueueItem(ctx context.Context, tx *sql.Tx, m *model.Queue) error
+
+	// SaveDeadLetter inserts a DLQ entry and returns it with populated ID.
+	SaveDeadLetter(ctx context.Context, tx *sql.Tx, m model.DeadLetterQueue) (model.DeadLetterQueue, error)
+
+	// DeleteOldest deletes up to count of the oldest undelivered items of a subscription,
+	// like QueueRepository.DeleteOldest. Returns the number of deleted items.
+	DeleteOldest(ctx context.Context, tx *sql.Tx, subscriptionID int64, count int) (int, error)
+
+	// ReleaseIdempotencyKey clears the idempotency key of a message,
+	// like MessageRepository.ReleaseIdempotencyKey.
+	ReleaseIdempotencyKey(ctx context.Context, tx *sql.Tx, id int64) error
+
+	// FindByIdempotencyKey finds the message of a topic that holds the idempotency key,
+	// like MessageRepository.FindByIdempotencyKey. Returns ErrNoData if not found.
+	FindByIdempotencyKey(ctx context.Context, tx *sql.Tx, topicID int64, key string) (model.Message, error)
+
+	// FindSubscriptionIDs returns the subscriptions a message has queue items for, ordered by
+	// queue item ID. Returns an empty slice if there are none.
+	FindSubscriptionIDs(ctx context.Context, tx *sql.Tx, messageID int64) ([]int64, error)
+
+	// CountBacklog counts the undelivered queue items of a subscription,
+	// like QueueRepository.CountBacklog.
+	CountBacklog(ctx context.Context, tx *sql.Tx, subscriptionID int64) (int, error)
 }
@@ -0,0 +1,158 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coregx/pubsub/model"
+)
+
+const (
+	// DefaultRetentionInterval is how often retention runs when RetentionConfig.Interval is not set.
+	DefaultRetentionInterval = time.Hour
+
+	// DefaultRetentionBatchSize is how many messages are purged per transaction
+	// when RetentionConfig.BatchSize is not set.
+	DefaultRetentionBatchSize = 500
+)
+
+// MessageArchiver stores messages before retention purges them (e.g. in object storage).
+type MessageArchiver interface {
+	// ArchiveMessages archives a batch of messages of a topic.
+	// On error the batch is not purged and retention stops until its next run.
+	// A batch may be archived again if purging it fails.
+	ArchiveMessages(ctx context.Context, topic model.Topic, messages []model.Message) error
+}
+
+// RetentionConfig configures the message retention job (see WithRetention).
+//
+// Retention purges messages older than their topic's retention period (Topic.RetentionDays),
+// together with their sent queue items. Messages with pending or failed queue items or
+// unresolved DLQ entries are kept until they are handled. Resolved DLQ entries keep a copy
+// of the message data and are kept for audit unless PurgeResolvedDLQ is set.
+type RetentionConfig struct {
+	Interval         time.Duration   // How often to purge (default: DefaultRetentionInterval)
+	BatchSize        int             // Messages per purge transaction (default: DefaultRetentionBatchSize)
+	Archiver         MessageArchiver // Archives each batch before it is purged (nil = purge only)
+	PurgeResolvedDLQ bool            // Also delete the resolved DLQ entries of purged messages (default: keep them)
+}
+
+// validate checks the configuration values.
+func (c RetentionConfig) validate() error {
+	if c.Interval < 0 {
+		return fmt.Errorf("retention interval must be >= 0, got %v", c.Interval)
+	}
+	if c.BatchSize < 0 {
+		return fmt.Errorf("retention batch size must be >= 0, got %d", c.BatchSize)
+	}
+	return nil
+}
+
+// withDefaults returns the configuration with unset values defaulted.
+func (c RetentionConfig) withDefaults() RetentionConfig {
+	if c.Interval == 0 {
+		c.Interval = DefaultRetentionInterval
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = DefaultRetentionBatchSize
+	}
+	return c
+}
+
+// PurgeOutdatedMessages purges (and archives, see RetentionConfig.Archiver) the fully handled
+// messages that are older than their topic's retention period, in batches of
+// RetentionConfig.BatchSize. Returns the number of purged messages.
+//
+// Run calls it every RetentionConfig.Interval when WithRetention is set (on the leader only
+// with WithLeaderElection). Requires WithTopicRepository.
+func (w *QueueWorker) PurgeOutdatedMessages(ctx context.Context) (int, error) {
+	if w.tr == nil {
+		return 0, NewError(ErrCodeConfiguration, "TopicRepository is required for retention (use WithTopicRepository)")
+	}
+	cfg := RetentionConfig{}.withDefaults()
+	if w.retention != nil {
+		cfg = *w.retention
+	}
+
+	topics, err := w.tr.FindWithRetention(ctx)
+	if IsNoData(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, topic := range topics {
+		purged, err := w.purgeTopic(ctx, topic, cfg)
+		total += purged
+		if purged > 0 {
+			w.logger.Infof("Retention purged %d messages of topic %s (older than %d days)", purged, topic.Code, topic.RetentionDays)
+		}
+		if err != nil {
+			return total, fmt.Errorf("failed to purge messages of topic %s: %w", topic.Code, err)
+		}
+	}
+	return total, nil
+}
+
+// purgeTopic purges the outdated messages of a topic batch by batch.
+func (w *QueueWorker) purgeTopic(ctx context.Context, topic model.Topic, cfg RetentionConfig) (int, error) {
+	before := time.Now().Add(-topic.Retention())
+	total := 0
+	for ctx.Err() == nil {
+		messages, err := w.mr.FindPurgeable(ctx, topic.ID, before, cfg.BatchSize)
+		if IsNoData(err) {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+
+		if cfg.Archiver != nil {
+			if err := cfg.Archiver.ArchiveMessages(ctx, topic, messages); err != nil {
+				return total, fmt.Errorf("failed to archive messages: %w", err)
+			}
+		}
+
+		ids := make([]int64, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		purged, err := w.mr.Purge(ctx, ids, cfg.PurgeResolvedDLQ)
+		if err != nil {
+			return total, err
+		}
+		total += purged
+		w.stats.recordPurged(purged)
+
+		// A short or fully skipped batch means nothing more to purge right now
+		if len(messages) < cfg.BatchSize || purged == 0 {
+			return total, nil
+		}
+	}
+	return total, ctx.Err()
+}
+
+// runRetention purges outdated messages every retention interval until ctx is canceled.
+// With leader election, only the leader purges.
+func (w *QueueWorker) runRetention(ctx context.Context) {
+	ticker := time.NewTicker(w.retention.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if w.leader != nil && !w.leader.IsLeader() {
+			continue
+		}
+		if _, err := w.PurgeOutdatedMessages(ctx); err != nil && ctx.Err() == nil {
+			w.logger.Errorf("Error purging outdated messages: %v", err)
+		}
+	}
+}
@@ -65,6 +65,18 @@ func (s Strategy) CalculateRetryDelay(attemptNumber int) time.Duration {
 	return time.Duration(delay)
 }
 
+// ClampDelay limits an externally suggested delay (e.g. a Retry-After header)
+// to the range [0, MaxDelay].
+func (s Strategy) ClampDelay(delay time.Duration) time.Duration {
+	if delay < 0 {
+		return 0
+	}
+	if delay > s.MaxDelay {
+		return s.MaxDelay
+	}
+	return delay
+}
+
 // ShouldMoveToDLQ determines if a message should be moved to the Dead Letter Queue.
 // Returns true when the attempt count reaches or exceeds the DLQ threshold.
 func (s Strategy) ShouldMoveToDLQ(attemptCount int) bool {
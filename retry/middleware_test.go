@@ -111,6 +111,38 @@ func TestStrategy_CalculateRetryDelay_CustomStrategy(t *testing.T) {
 	}
 }
 
+func TestStrategy_ClampDelay(t *testing.T) {
+	strategy := DefaultStrategy()
+
+	tests := []struct {
+		name     string
+		delay    time.Duration
+		expected time.Duration
+	}{
+		{
+			name:     "Negative delay",
+			delay:    -5 * time.Second,
+			expected: 0,
+		},
+		{
+			name:     "Within range",
+			delay:    2 * time.Minute,
+			expected: 2 * time.Minute,
+		},
+		{
+			name:     "Above max delay",
+			delay:    2 * time.Hour,
+			expected: 30 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, strategy.ClampDelay(tt.delay))
+		})
+	}
+}
+
 func TestStrategy_ShouldMoveToDLQ(t *testing.T) {
 	strategy := DefaultStrategy()
 
@@ -0,0 +1,174 @@
+package pubsub
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// latencyWindow is the number of most recent deliveries the latency statistics cover.
+const latencyWindow = 1024
+
+// WorkerStats is a snapshot of a QueueWorker's runtime statistics (see QueueWorker.Stats).
+// Counters are cumulative since the worker was created and cover this worker only,
+// not other replicas sharing the queue.
+type WorkerStats struct {
+	WorkerID   string
+	Partitions []int // Owned partitions (nil = partitioning disabled)
+
+	Leader      bool      // Runs maintenance tasks (always true without leader election)
+	LeaderSince time.Time // Start of the current leadership (zero = not leader or no election)
+
+	Delivered    int64 // Successful delivery attempts
+	Failed       int64 // Failed delivery attempts (including those that dead-lettered the item)
+	DeadLettered int64 // Items moved to the DLQ (including expired items)
+	Expired      int64 // Expired items dead-lettered or deleted by cleanup
+	Purged       int64 // Messages purged by retention
+	InFlight     int   // Deliveries in progress
+
+	LastBatchAt       time.Time     // Start of the last completed batch (zero = none yet)
+	LastBatchDuration time.Duration // Duration of the last completed batch
+
+	// Delivery latency (gateway call duration) over the last 1024 delivery attempts
+	AvgLatency time.Duration
+	P95Latency time.Duration
+
+	Subscriptions map[int64]SubscriptionStats // Counters by subscription ID
+}
+
+// SubscriptionStats holds a WorkerStats counter set for a single subscription.
+type SubscriptionStats struct {
+	Delivered    int64
+	Failed       int64
+	DeadLettered int64
+	Expired      int64
+}
+
+// workerStats accumulates the statistics reported by QueueWorker.Stats.
+//
+// Thread safety: Safe for concurrent use.
+type workerStats struct {
+	mu                sync.Mutex
+	totals            SubscriptionStats
+	subscriptions     map[int64]*SubscriptionStats
+	inFlight          int
+	lastBatchAt       time.Time
+	lastBatchDuration time.Duration
+	latencies         []time.Duration // Ring buffer of the last latencyWindow samples
+	nextLatency       int
+	purged            int64
+}
+
+// subscription returns the counters of a subscription. Caller must hold mu.
+func (s *workerStats) subscription(subscriptionID int64) *SubscriptionStats {
+	if s.subscriptions == nil {
+		s.subscriptions = make(map[int64]*SubscriptionStats)
+	}
+	counters, ok := s.subscriptions[subscriptionID]
+	if !ok {
+		counters = &SubscriptionStats{}
+		s.subscriptions[subscriptionID] = counters
+	}
+	return counters
+}
+
+// startDelivery counts a delivery in progress until the returned function is called.
+func (s *workerStats) startDelivery() func() {
+	s.mu.Lock()
+	s.inFlight++
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}
+}
+
+// recordDelivery counts the outcome and latency of a delivery attempt.
+func (s *workerStats) recordDelivery(subscriptionID int64, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counters := s.subscription(subscriptionID)
+	if err != nil {
+		s.totals.Failed++
+		counters.Failed++
+	} else {
+		s.totals.Delivered++
+		counters.Delivered++
+	}
+
+	if len(s.latencies) < latencyWindow {
+		s.latencies = append(s.latencies, latency)
+	} else {
+		s.latencies[s.nextLatency] = latency
+	}
+	s.nextLatency = (s.nextLatency + 1) % latencyWindow
+}
+
+// recordDeadLettered counts an item moved to the DLQ.
+func (s *workerStats) recordDeadLettered(subscriptionID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totals.DeadLettered++
+	s.subscription(subscriptionID).DeadLettered++
+}
+
+// recordExpired counts an expired item removed by cleanup.
+func (s *workerStats) recordExpired(subscriptionID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totals.Expired++
+	s.subscription(subscriptionID).Expired++
+}
+
+// recordPurged counts messages purged by retention.
+func (s *workerStats) recordPurged(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purged += int64(count)
+}
+
+// recordBatch records the start time and duration of a completed batch.
+func (s *workerStats) recordBatch(start time.Time, duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastBatchAt = start
+	s.lastBatchDuration = duration
+}
+
+// snapshot returns a copy of the statistics.
+func (s *workerStats) snapshot() WorkerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := WorkerStats{
+		Delivered:         s.totals.Delivered,
+		Failed:            s.totals.Failed,
+		DeadLettered:      s.totals.DeadLettered,
+		Expired:           s.totals.Expired,
+		Purged:            s.purged,
+		InFlight:          s.inFlight,
+		LastBatchAt:       s.lastBatchAt,
+		LastBatchDuration: s.lastBatchDuration,
+		Subscriptions:     make(map[int64]SubscriptionStats, len(s.subscriptions)),
+	}
+	for id, counters := range s.subscriptions {
+		stats.Subscriptions[id] = *counters
+	}
+
+	if len(s.latencies) > 0 {
+		sorted := slices.Clone(s.latencies)
+		slices.Sort(sorted)
+
+		var total time.Duration
+		for _, latency := range sorted {
+			total += latency
+		}
+		stats.AvgLatency = total / time.Duration(len(sorted))
+		stats.P95Latency = sorted[(len(sorted)*95+99)/100-1] // Nearest rank
+	}
+
+	return stats
+}
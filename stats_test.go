@@ -0,0 +1,100 @@
+package pubsub
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerStats_Snapshot(t *testing.T) {
+	var s workerStats
+	assert.Equal(t, WorkerStats{Subscriptions: map[int64]SubscriptionStats{}}, s.snapshot())
+
+	failure := errors.New("timeout")
+	s.recordDelivery(1, time.Millisecond, nil)
+	s.recordDelivery(1, time.Millisecond, failure)
+	s.recordDelivery(2, time.Millisecond, nil)
+	s.recordDeadLettered(1)
+	s.recordDeadLettered(2)
+	s.recordExpired(2)
+	s.recordPurged(3)
+	start := time.Now()
+	s.recordBatch(start, time.Second)
+	done := s.startDelivery()
+
+	stats := s.snapshot()
+	assert.Equal(t, int64(2), stats.Delivered)
+	assert.Equal(t, int64(1), stats.Failed)
+	assert.Equal(t, int64(2), stats.DeadLettered)
+	assert.Equal(t, int64(1), stats.Expired)
+	assert.Equal(t, int64(3), stats.Purged)
+	assert.Equal(t, 1, stats.InFlight)
+	assert.Equal(t, start, stats.LastBatchAt)
+	assert.Equal(t, time.Second, stats.LastBatchDuration)
+	assert.Equal(t, map[int64]SubscriptionStats{
+		1: {Delivered: 1, Failed: 1, DeadLettered: 1},
+		2: {Delivered: 1, DeadLettered: 1, Expired: 1},
+	}, stats.Subscriptions)
+
+	// The snapshot is a copy
+	stats.Subscriptions[1] = SubscriptionStats{}
+	done()
+	stats = s.snapshot()
+	assert.Equal(t, int64(1), stats.Subscriptions[1].Delivered)
+	assert.Zero(t, stats.InFlight)
+}
+
+func TestWorkerStats_Latency(t *testing.T) {
+	// milliseconds returns the latencies 1ms..n ms in descending order.
+	milliseconds := func(n int) []time.Duration {
+		latencies := make([]time.Duration, n)
+		for i := range latencies {
+			latencies[i] = time.Duration(n-i) * time.Millisecond
+		}
+		return latencies
+	}
+
+	tests := []struct {
+		name      string
+		latencies []time.Duration
+		wantAvg   time.Duration
+		wantP95   time.Duration
+	}{
+		{"single sample", []time.Duration{7 * time.Millisecond}, 7 * time.Millisecond, 7 * time.Millisecond},
+		{"rank rounds up", milliseconds(10), 5500 * time.Microsecond, 10 * time.Millisecond},
+		{"20 samples", milliseconds(20), 10500 * time.Microsecond, 19 * time.Millisecond},
+		{"100 samples", milliseconds(100), 50500 * time.Microsecond, 95 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s workerStats
+			for _, latency := range tt.latencies {
+				s.recordDelivery(1, latency, nil)
+			}
+
+			stats := s.snapshot()
+			assert.Equal(t, tt.wantAvg, stats.AvgLatency)
+			assert.Equal(t, tt.wantP95, stats.P95Latency)
+		})
+	}
+}
+
+func TestWorkerStats_LatencyWindow(t *testing.T) {
+	var s workerStats
+	for range latencyWindow {
+		s.recordDelivery(1, time.Hour, nil)
+	}
+	// Newer samples replace the oldest ones
+	for range latencyWindow {
+		s.recordDelivery(1, time.Millisecond, nil)
+	}
+
+	stats := s.snapshot()
+	assert.Equal(t, time.Millisecond, stats.AvgLatency)
+	assert.Equal(t, time.Millisecond, stats.P95Latency)
+	assert.Len(t, s.latencies, latencyWindow)
+	assert.Equal(t, int64(2*latencyWindow), stats.Delivered)
+}
@@ -3,6 +3,7 @@ package pubsub
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/coregx/pubsub/model"
 )
@@ -16,12 +17,14 @@ import (
 //   - Unsubscribe: Deactivate existing subscriptions
 //   - ListSubscriptions: Query subscriptions by subscriber and identifier
 //   - ReactivateSubscription: Re-enable previously deactivated subscriptions
+//   - PauseSubscription, ResumeSubscription: Hold and release delivery without unsubscribing
 //
 // Thread safety: Safe for concurrent use.
 type SubscriptionManager struct {
 	subscriptionRepo SubscriptionRepository
 	subscriberRepo   SubscriberRepository
 	topicRepo        TopicRepository
+	queueRepo        QueueRepository // nil = pause and resume leave queue item expiry unchanged
 	logger           Logger
 }
 
@@ -35,6 +38,9 @@ type SubscriptionManagerOption func(*SubscriptionManager) error
 //   - WithSubscriptionManagerRepositories: subscription, subscriber, and topic repositories
 //   - WithSubscriptionManagerLogger: logger instance
 //
+// Optional options:
+//   - WithSubscriptionManagerQueue: keep the backlog held by a pause from expiring
+//
 // Example:
 //
 //	manager, err := pubsub.NewSubscriptionManager(
@@ -94,6 +100,20 @@ func WithSubscriptionManagerRepositories(
 	}
 }
 
+// WithSubscriptionManagerQueue sets the queue repository used by PauseSubscription and
+// ResumeSubscription to extend the expiry of held queue items, so resuming releases the
+// backlog instead of dead-lettering it.
+// This is an optional configuration - by default queue items keep their expiry.
+func WithSubscriptionManagerQueue(queueRepo QueueRepository) SubscriptionManagerOption {
+	return func(sm *SubscriptionManager) error {
+		if queueRepo == nil {
+			return fmt.Errorf("queueRepo cannot be nil")
+		}
+		sm.queueRepo = queueRepo
+		return nil
+	}
+}
+
 // WithSubscriptionManagerLogger sets the logger instance for the subscription manager.
 // Logger is required and must not be nil.
 //
@@ -302,3 +322,119 @@ func (sm *SubscriptionManager) ReactivateSubscription(ctx context.Context, subsc
 
 	return &subscription, nil
 }
+
+// PauseSubscription holds delivery for a subscription, e.g. while its consumer is under maintenance.
+// Publishing keeps creating queue items for the subscription; QueueWorker skips them until
+// the subscription is resumed. Pausing an already paused subscription replaces its resume time.
+//
+// Parameters:
+//   - subscriptionID: Required, must be > 0
+//   - resumeAt: Automatic resume time, must be in the future (zero = until ResumeSubscription)
+//
+// Queued items do not expire while paused. With WithSubscriptionManagerQueue and a resumeAt,
+// their expiry is moved to at least resumeAt plus the topic's message TTL
+// (model.DefaultQueueTTL if unset), so they are delivered after the automatic resume.
+func (sm *SubscriptionManager) PauseSubscription(ctx context.Context, subscriptionID int64, resumeAt time.Time) (*model.Subscription, error) {
+	if subscriptionID == 0 {
+		return nil, NewError(ErrCodeValidation, "subscription ID is required")
+	}
+	if !resumeAt.IsZero() && !resumeAt.After(time.Now()) {
+		return nil, NewError(ErrCodeValidation, "resume time must be in the future")
+	}
+
+	// Load subscription
+	subscription, err := sm.subscriptionRepo.Load(ctx, subscriptionID)
+	if err != nil {
+		if IsNoData(err) {
+			return nil, NewErrorWithCause(ErrCodeValidation, fmt.Sprintf("subscription not found: %d", subscriptionID), err)
+		}
+		return nil, NewErrorWithCause(ErrCodeDatabase, "failed to load subscription", err)
+	}
+
+	// Pause subscription
+	subscription.Pause(resumeAt)
+	subscription, err = sm.subscriptionRepo.Save(ctx, subscription)
+	if err != nil {
+		return nil, NewErrorWithCause(ErrCodeDatabase, "failed to save subscription", err)
+	}
+	if !resumeAt.IsZero() {
+		if err := sm.extendHeldExpiry(ctx, subscription, resumeAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if resumeAt.IsZero() {
+		sm.logger.Infof("Subscription paused: id=%d", subscriptionID)
+	} else {
+		sm.logger.Infof("Subscription paused: id=%d, resumeAt=%v", subscriptionID, resumeAt)
+	}
+
+	return &subscription, nil
+}
+
+// ResumeSubscription releases delivery held by PauseSubscription.
+// Queued items become deliverable immediately. With WithSubscriptionManagerQueue, items that
+// expired or are about to expire while paused get the topic's message TTL again from now.
+// If the subscription is not paused, returns without error.
+func (sm *SubscriptionManager) ResumeSubscription(ctx context.Context, subscriptionID int64) (*model.Subscription, error) {
+	if subscriptionID == 0 {
+		return nil, NewError(ErrCodeValidation, "subscription ID is required")
+	}
+
+	// Load subscription
+	subscription, err := sm.subscriptionRepo.Load(ctx, subscriptionID)
+	if err != nil {
+		if IsNoData(err) {
+			return nil, NewErrorWithCause(ErrCodeValidation, fmt.Sprintf("subscription not found: %d", subscriptionID), err)
+		}
+		return nil, NewErrorWithCause(ErrCodeDatabase, "failed to load subscription", err)
+	}
+
+	// Check if already resumed
+	if !subscription.IsPaused {
+		sm.logger.Warnf("Subscription not paused: id=%d", subscriptionID)
+		return &subscription, nil
+	}
+
+	// Extend the held items while still paused, before workers can expire them
+	if err := sm.extendHeldExpiry(ctx, subscription, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// Resume subscription
+	subscription.Resume()
+	subscription, err = sm.subscriptionRepo.Save(ctx, subscription)
+	if err != nil {
+		return nil, NewErrorWithCause(ErrCodeDatabase, "failed to save subscription", err)
+	}
+
+	sm.logger.Infof("Subscription resumed: id=%d", subscriptionID)
+
+	return &subscription, nil
+}
+
+// extendHeldExpiry moves the expiry of the subscription's undelivered items to at least
+// releaseAt plus the topic's message TTL. No-op without WithSubscriptionManagerQueue.
+func (sm *SubscriptionManager) extendHeldExpiry(ctx context.Context, subscription model.Subscription, releaseAt time.Time) error {
+	if sm.queueRepo == nil {
+		return nil
+	}
+
+	topic, err := sm.topicRepo.Load(ctx, subscription.TopicID)
+	if err != nil {
+		return NewErrorWithCause(ErrCodeDatabase, "failed to load topic", err)
+	}
+	ttl := topic.MessageTTL()
+	if ttl == 0 {
+		ttl = model.DefaultQueueTTL
+	}
+
+	extended, err := sm.queueRepo.ExtendExpiry(ctx, subscription.ID, releaseAt.Add(ttl))
+	if err != nil {
+		return NewErrorWithCause(ErrCodeDatabase, "failed to extend queue item expiry", err)
+	}
+	if extended > 0 {
+		sm.logger.Infof("Extended expiry of %d held queue items: subscription=%d", extended, subscription.ID)
+	}
+	return nil
+}
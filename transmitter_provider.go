@@ -0,0 +1,91 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coregx/pubsub/model"
+)
+
+// SubscriberTransmitterProvider is a TransmitterProvider that resolves callback URLs
+// from subscriber configuration (model.Subscriber.WebhookURL).
+//
+// It also implements RateLimitProvider using model.Subscriber.RateLimit and RateBurst,
+// and DeliveryTargetProvider to resolve both with one load.
+//
+// Example:
+//
+//	provider := pubsub.NewSubscriberTransmitterProvider(repos.Subscriber)
+//	worker, err := pubsub.NewQueueWorker(
+//	    pubsub.WithDelivery(provider, gateway),
+//	    // ...
+//	)
+type SubscriberTransmitterProvider struct {
+	subscriberRepo SubscriberRepository
+}
+
+// NewSubscriberTransmitterProvider creates a TransmitterProvider backed by a SubscriberRepository.
+func NewSubscriberTransmitterProvider(subscriberRepo SubscriberRepository) *SubscriberTransmitterProvider {
+	return &SubscriberTransmitterProvider{subscriberRepo: subscriberRepo}
+}
+
+// GetDeliveryTarget returns the webhook URL and delivery rate limit of the subscriber
+// from a single load.
+//
+// Returns ErrNoData if the subscriber does not exist, and a validation error
+// if the subscriber is inactive or has no webhook URL configured.
+func (p *SubscriberTransmitterProvider) GetDeliveryTarget(ctx context.Context, subscriberID int64) (DeliveryTarget, error) {
+	subscriber, err := p.load(ctx, subscriberID)
+	if err != nil {
+		return DeliveryTarget{}, err
+	}
+
+	if !subscriber.IsActive {
+		return DeliveryTarget{}, NewError(ErrCodeValidation, fmt.Sprintf("subscriber %d is inactive", subscriberID))
+	}
+	if subscriber.WebhookURL == "" {
+		return DeliveryTarget{}, NewError(ErrCodeValidation, fmt.Sprintf("subscriber %d has no webhook URL", subscriberID))
+	}
+
+	return DeliveryTarget{
+		CallbackURL: subscriber.WebhookURL,
+		RateLimit:   RateLimit{PerSecond: subscriber.RateLimit, Burst: subscriber.RateBurst},
+	}, nil
+}
+
+// GetCallbackUrl returns the webhook URL of the subscriber.
+//
+// Returns ErrNoData if the subscriber does not exist, and a validation error
+// if the subscriber is inactive or has no webhook URL configured.
+//
+//nolint:revive // Method name is defined by the TransmitterProvider interface
+func (p *SubscriberTransmitterProvider) GetCallbackUrl(ctx context.Context, subscriberID int64) (string, error) {
+	target, err := p.GetDeliveryTarget(ctx, subscriberID)
+	if err != nil {
+		return "", err
+	}
+	return target.CallbackURL, nil
+}
+
+// GetRateLimit returns the delivery rate limit configured on the subscriber.
+// Returns ErrNoData if the subscriber does not exist.
+func (p *SubscriberTransmitterProvider) GetRateLimit(ctx context.Context, subscriberID int64) (RateLimit, error) {
+	subscriber, err := p.load(ctx, subscriberID)
+	if err != nil {
+		return RateLimit{}, err
+	}
+
+	return RateLimit{PerSecond: subscriber.RateLimit, Burst: subscriber.RateBurst}, nil
+}
+
+// load loads the subscriber, mapping a missing subscriber to ErrNoData.
+func (p *SubscriberTransmitterProvider) load(ctx context.Context, subscriberID int64) (model.Subscriber, error) {
+	subscriber, err := p.subscriberRepo.Load(ctx, subscriberID)
+	if err != nil {
+		if IsNoData(err) {
+			return model.Subscriber{}, ErrNoData
+		}
+		return model.Subscriber{}, NewErrorWithCause(ErrCodeDatabase, "failed to load subscriber", err)
+	}
+	return subscriber, nil
+}
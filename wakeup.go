@@ -0,0 +1,55 @@
+package pubsub
+
+import "context"
+
+// WakeupNotifier signals queue workers that new queue items are ready.
+// Publisher calls it after creating queue items (see WithPublisherWakeup),
+// so workers can deliver immediately instead of waiting for the next poll.
+type WakeupNotifier interface {
+	// NotifyQueued signals that queue items were created.
+	// Signals may be coalesced; a lost signal only delays delivery until the next poll.
+	NotifyQueued(ctx context.Context) error
+}
+
+// WakeupSource delivers wakeup signals to a queue worker (see WithWakeup).
+type WakeupSource interface {
+	// Wakeups returns a channel that receives a value whenever the worker should poll.
+	Wakeups() <-chan struct{}
+}
+
+// ChannelWakeup is an in-process WakeupNotifier and WakeupSource for a Publisher and
+// QueueWorker running in the same process.
+// For workers in other processes use adapters/postgres.Waker (LISTEN/NOTIFY).
+//
+// Signals are coalesced: any number of notifications before the worker polls
+// result in a single wakeup.
+//
+// Thread safety: Safe for concurrent use.
+type ChannelWakeup struct {
+	wakeups chan struct{}
+}
+
+// NewChannelWakeup creates an in-process wakeup channel.
+//
+// Example:
+//
+//	wakeup := pubsub.NewChannelWakeup()
+//	publisher, _ := pubsub.NewPublisher(..., pubsub.WithPublisherWakeup(wakeup))
+//	worker, _ := pubsub.NewQueueWorker(..., pubsub.WithWakeup(wakeup))
+func NewChannelWakeup() *ChannelWakeup {
+	return &ChannelWakeup{wakeups: make(chan struct{}, 1)}
+}
+
+// NotifyQueued signals the worker without blocking. Never returns an error.
+func (c *ChannelWakeup) NotifyQueued(_ context.Context) error {
+	select {
+	case c.wakeups <- struct{}{}:
+	default: // A wakeup is already pending
+	}
+	return nil
+}
+
+// Wakeups returns the channel the worker listens on.
+func (c *ChannelWakeup) Wakeups() <-chan struct{} {
+	return c.wakeups
+}
@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coregx/pubsub"
+)
+
+// ErrorKind categorizes a failed webhook delivery.
+type ErrorKind string
+
+const (
+	// KindStatus indicates the webhook responded with a non-2xx status code.
+	KindStatus ErrorKind = "status"
+
+	// KindTimeout indicates the request did not complete within the timeout.
+	KindTimeout ErrorKind = "timeout"
+
+	// KindNetwork indicates a connection-level failure (DNS, refused, reset, TLS).
+	KindNetwork ErrorKind = "network"
+
+	// KindRequest indicates the request could not be built (invalid URL, encoding, size limit).
+	KindRequest ErrorKind = "request"
+
+	// KindSigning indicates the signing secret could not be retrieved.
+	KindSigning ErrorKind = "signing"
+)
+
+// Error is returned by Gateway.DeliverMessage for every failed delivery,
+// wrapped in a *pubsub.DeliveryError that tells QueueWorker whether to retry.
+//
+// Use errors.As to inspect it:
+//
+//	var webhookErr *webhook.Error
+//	if errors.As(err, &webhookErr) && webhookErr.Kind == webhook.KindStatus {
+//	    log.Printf("subscriber responded %d", webhookErr.StatusCode)
+//	}
+type Error struct {
+	Kind       ErrorKind // Failure category
+	URL        string    // Callback URL the delivery was sent to
+	StatusCode int       // HTTP status code (KindStatus only)
+	Body       string    // Beginning of the response body (KindStatus only)
+	Err        error     // Underlying error (all kinds except KindStatus)
+
+	RetryAfter    time.Duration // Delay from the Retry-After header (429 and 503 only)
+	HasRetryAfter bool          // Whether a valid Retry-After header was present
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	switch e.Kind {
+	case KindStatus:
+		return fmt.Sprintf("webhook %s responded %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	case KindTimeout:
+		return fmt.Sprintf("webhook %s timed out: %v", e.URL, e.Err)
+	default:
+		return fmt.Sprintf("webhook %s %s error: %v", e.URL, e.Kind, e.Err)
+	}
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports whether the delivery failed because of a timeout.
+func (e *Error) Timeout() bool {
+	return e.Kind == KindTimeout
+}
+
+// Retryable reports whether a later delivery attempt may succeed.
+//
+// Timeouts, network and signing failures, 5xx responses and 408, 425 and 429
+// responses are retryable. Other responses (including 3xx redirects) and malformed
+// requests are permanent.
+func (e *Error) Retryable() bool {
+	switch e.Kind {
+	case KindTimeout, KindNetwork, KindSigning:
+		return true
+	case KindStatus:
+		return IsRetryableStatus(e.StatusCode)
+	default:
+		return false
+	}
+}
+
+// IsRetryableStatus reports whether a webhook response status code is worth retrying.
+func IsRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	default:
+		return statusCode >= http.StatusInternalServerError
+	}
+}
+
+// reason returns a short description used as the DLQ failure reason.
+func (e *Error) reason() string {
+	switch e.Kind {
+	case KindStatus:
+		return fmt.Sprintf("HTTP %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	case KindTimeout:
+		return "timeout"
+	case KindNetwork:
+		return "network error"
+	case KindSigning:
+		return "signing secret unavailable"
+	default:
+		return "invalid request"
+	}
+}
+
+// deliveryError wraps e in a *pubsub.DeliveryError.
+func (e *Error) deliveryError() *pubsub.DeliveryError {
+	if !e.Retryable() {
+		return pubsub.NewPermanentDeliveryError(e.StatusCode, e.reason(), e)
+	}
+
+	deliveryErr := pubsub.NewTransientDeliveryError(e.StatusCode, e.reason(), e)
+	if e.HasRetryAfter {
+		deliveryErr.WithRetryAfter(e.RetryAfter)
+	}
+	return deliveryErr
+}
+
+// ParseRetryAfter parses a Retry-After header value, given either as
+// delay-seconds ("120") or as an HTTP date. Dates in the past yield 0, delays beyond
+// the time.Duration range are capped at its maximum. Returns false if the value is
+// empty or malformed.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if errors.Is(err, strconv.ErrRange) && seconds > 0 {
+		return math.MaxInt64, true // More than 19 digits
+	}
+	if err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		if seconds > int64(math.MaxInt64/time.Second) {
+			return math.MaxInt64, true
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
@@ -0,0 +1,249 @@
+// Package webhook provides an HTTP implementation of pubsub.MessageDeliveryGateway.
+//
+// The gateway POSTs each model.DataMessage as JSON to the subscriber's callback URL
+// and signs the request body with a per-subscriber HMAC-SHA256 secret. Every request
+// carries a timestamp header that is part of the signed content, so receivers can
+// reject replayed requests (see Verify and VerifyRequest).
+//
+// Example:
+//
+//	gateway, err := webhook.New(
+//	    webhook.WithTimeout(5*time.Second),
+//	    webhook.WithSigningSecret(os.Getenv("WEBHOOK_SECRET")),
+//	    webhook.WithHeader("X-Environment", "production"),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	worker, err := pubsub.NewQueueWorker(
+//	    pubsub.WithDelivery(transmitterProvider, gateway),
+//	    // ...
+//	)
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/model"
+)
+
+// Default gateway configuration.
+const (
+	DefaultTimeout             = 10 * time.Second
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 10
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultUserAgent           = "coregx-pubsub-webhook/1.0"
+)
+
+// maxErrorBodySize limits how much of a non-2xx response body is kept in Error.Body.
+const maxErrorBodySize = 1024
+
+// Gateway delivers messages to subscriber webhooks over HTTP.
+// It implements pubsub.MessageDeliveryGateway.
+//
+// Thread safety: Safe for concurrent use. Connections are pooled across deliveries.
+type Gateway struct {
+	client      *http.Client
+	transport   *http.Transport
+	headers     http.Header
+	secrets     SecretProvider
+	userAgent   string
+	maxBodySize int64
+}
+
+// New creates a new webhook gateway with the provided options.
+//
+// All options are optional. Without options the gateway uses a 10s timeout,
+// a pooled transport and sends unsigned requests.
+func New(opts ...Option) (*Gateway, error) {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if ok {
+		transport = transport.Clone()
+	} else {
+		transport = &http.Transport{}
+	}
+	transport.MaxIdleConns = DefaultMaxIdleConns
+	transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	transport.IdleConnTimeout = DefaultIdleConnTimeout
+
+	g := &Gateway{
+		client: &http.Client{
+			Timeout:       DefaultTimeout,
+			Transport:     transport,
+			CheckRedirect: noRedirect,
+		},
+		transport: transport,
+		headers:   make(http.Header),
+		userAgent: DefaultUserAgent,
+	}
+
+	for _, opt := range opts {
+		if err := opt(g); err != nil {
+			return nil, pubsub.NewErrorWithCause(pubsub.ErrCodeConfiguration, "failed to apply webhook option", err)
+		}
+	}
+
+	return g, nil
+}
+
+// DeliverMessage POSTs the message to callbackURL as JSON.
+//
+// The subscriber is identified through pubsub.DeliveryInfoFromContext, which QueueWorker
+// populates before each attempt; it selects the signing secret and adds the attempt header.
+//
+// Returns nil on any 2xx response. Failures are returned as a *pubsub.DeliveryError
+// wrapping an *Error; see Error.Retryable for which failures are retried.
+// A Retry-After header on 429 and 503 responses is passed on as the retry delay.
+// Redirects are not followed: a 3xx response is a permanent failure.
+func (g *Gateway) DeliverMessage(ctx context.Context, callbackURL string, message *model.DataMessage) error {
+	if err := g.deliver(ctx, callbackURL, message); err != nil {
+		return err.deliveryError()
+	}
+	return nil
+}
+
+// deliver performs a single delivery attempt.
+func (g *Gateway) deliver(ctx context.Context, callbackURL string, message *model.DataMessage) *Error {
+	if message == nil {
+		return &Error{Kind: KindRequest, URL: callbackURL, Err: errors.New("message is nil")}
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return &Error{Kind: KindRequest, URL: callbackURL, Err: fmt.Errorf("failed to encode message: %w", err)}
+	}
+	if g.maxBodySize > 0 && int64(len(body)) > g.maxBodySize {
+		return &Error{Kind: KindRequest, URL: callbackURL,
+			Err: fmt.Errorf("payload size %d exceeds max body size %d", len(body), g.maxBodySize)}
+	}
+
+	req, reqErr := g.newRequest(ctx, callbackURL, message, body)
+	if reqErr != nil {
+		return reqErr
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return classifyTransportError(callbackURL, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body) // Drain so the connection can be reused
+		return nil
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	statusErr := &Error{
+		Kind:       KindStatus,
+		URL:        callbackURL,
+		StatusCode: resp.StatusCode,
+		Body:       string(snippet),
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		statusErr.RetryAfter, statusErr.HasRetryAfter = ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+
+	return statusErr
+}
+
+// headersKey is the context key for per-delivery headers.
+type headersKey struct{}
+
+// ContextWithHeaders returns a copy of ctx carrying extra request headers for a single delivery,
+// e.g. set by a pubsub.DeliveryInterceptor that adds auth headers. They replace headers
+// configured with WithHeader; protocol headers (signature, timestamp, message ID) cannot be overridden.
+// Headers from repeated calls are merged.
+func ContextWithHeaders(ctx context.Context, headers http.Header) context.Context {
+	merged := make(http.Header)
+	if existing, ok := ctx.Value(headersKey{}).(http.Header); ok {
+		for key, values := range existing {
+			merged[key] = append([]string(nil), values...)
+		}
+	}
+	for key, values := range headers {
+		for _, value := range values {
+			merged.Add(key, value)
+		}
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+// newRequest builds the signed HTTP request for a delivery.
+func (g *Gateway) newRequest(ctx context.Context, callbackURL string, message *model.DataMessage, body []byte) (*http.Request, *Error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, &Error{Kind: KindRequest, URL: callbackURL, Err: err}
+	}
+
+	for key, values := range g.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if headers, ok := ctx.Value(headersKey{}).(http.Header); ok {
+		for key, values := range headers {
+			req.Header.Del(key)
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", g.userAgent)
+	req.Header.Set(HeaderMessageID, message.MessageID)
+
+	timestamp := time.Now()
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+
+	info, hasInfo := pubsub.DeliveryInfoFromContext(ctx)
+	if hasInfo {
+		req.Header.Set(HeaderAttempt, strconv.Itoa(info.Attempt))
+	}
+
+	if g.secrets != nil {
+		secret, err := g.secrets.GetSigningSecret(ctx, info.SubscriberID)
+		if err != nil {
+			return nil, &Error{Kind: KindSigning, URL: callbackURL, Err: fmt.Errorf("failed to get signing secret: %w", err)}
+		}
+		if secret != "" {
+			req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+		}
+	}
+
+	return req, nil
+}
+
+// noRedirect stops the client at the first response. Following a redirect would
+// turn the signed POST into an unsigned GET to a location the subscriber did not register.
+func noRedirect(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// classifyTransportError converts an http.Client error into a typed *Error.
+func classifyTransportError(callbackURL string, err error) *Error {
+	kind := KindNetwork
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		kind = KindTimeout
+	}
+
+	return &Error{Kind: kind, URL: callbackURL, Err: err}
+}
@@ -0,0 +1,389 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coregx/pubsub"
+	"github.com/coregx/pubsub/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type secretsBySubscriber map[int64]string
+
+func (s secretsBySubscriber) GetSigningSecret(_ context.Context, subscriberID int64) (string, error) {
+	return s[subscriberID], nil
+}
+
+func newTestMessage() *model.DataMessage {
+	return model.NewDataMessage("42", time.Now(), "user.signup", "eyJpZCI6MX0=")
+}
+
+func TestNew_Defaults(t *testing.T) {
+	g, err := New()
+	require.NoError(t, err)
+
+	assert.Equal(t, DefaultTimeout, g.client.Timeout)
+	assert.Equal(t, DefaultMaxIdleConns, g.transport.MaxIdleConns)
+	assert.Equal(t, DefaultMaxIdleConnsPerHost, g.transport.MaxIdleConnsPerHost)
+	assert.Equal(t, DefaultIdleConnTimeout, g.transport.IdleConnTimeout)
+	assert.Nil(t, g.secrets)
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{"Zero timeout", WithTimeout(0)},
+		{"Nil TLS config", WithTLSConfig(nil)},
+		{"Empty header key", WithHeader("", "value")},
+		{"Empty user agent", WithUserAgent("")},
+		{"Negative max body size", WithMaxBodySize(-1)},
+		{"Negative idle conns", WithMaxIdleConns(-1)},
+		{"Negative idle conns per host", WithMaxIdleConnsPerHost(-1)},
+		{"Negative conns per host", WithMaxConnsPerHost(-1)},
+		{"Nil HTTP client", WithHTTPClient(nil)},
+		{"Nil secret provider", WithSecretProvider(nil)},
+		{"Empty signing secret", WithSigningSecret("")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(tt.opt)
+			assert.Nil(t, g)
+
+			var pubsubErr *pubsub.Error
+			require.ErrorAs(t, err, &pubsubErr)
+			assert.Equal(t, pubsub.ErrCodeConfiguration, pubsubErr.Code)
+		})
+	}
+}
+
+func TestGateway_DeliverMessage_Success(t *testing.T) {
+	var received *http.Request
+	var body []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	g, err := New(
+		WithSecretProvider(secretsBySubscriber{7: "subscriber-secret"}),
+		WithHeader("X-Environment", "test"),
+	)
+	require.NoError(t, err)
+
+	ctx := pubsub.ContextWithDeliveryInfo(context.Background(), pubsub.DeliveryInfo{
+		QueueID:      1,
+		MessageID:    42,
+		SubscriberID: 7,
+		Attempt:      3,
+	})
+
+	message := newTestMessage()
+	require.NoError(t, g.DeliverMessage(ctx, server.URL, message))
+
+	require.NotNil(t, received)
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, DefaultUserAgent, received.Header.Get("User-Agent"))
+	assert.Equal(t, "test", received.Header.Get("X-Environment"))
+	assert.Equal(t, "42", received.Header.Get(HeaderMessageID))
+	assert.Equal(t, "3", received.Header.Get(HeaderAttempt))
+
+	var decoded model.DataMessage
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	assert.Equal(t, message.MessageID, decoded.MessageID)
+	assert.Equal(t, message.Data, decoded.Data)
+
+	err = Verify("subscriber-secret", received.Header.Get(HeaderTimestamp),
+		received.Header.Get(HeaderSignature), body, DefaultTolerance)
+	assert.NoError(t, err)
+}
+
+func TestGateway_DeliverMessage_ContextHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	g, err := New(WithHeader("X-Environment", "production"), WithHeader("X-Team", "billing"))
+	require.NoError(t, err)
+
+	ctx := ContextWithHeaders(context.Background(), http.Header{"Authorization": {"Bearer token"}})
+	ctx = ContextWithHeaders(ctx, http.Header{
+		"X-Environment": {"test"},
+		HeaderMessageID: {"spoofed"},
+	})
+
+	require.NoError(t, g.DeliverMessage(ctx, server.URL, newTestMessage()))
+	require.NotNil(t, received)
+	assert.Equal(t, "Bearer token", received.Get("Authorization"))
+	assert.Equal(t, []string{"test"}, received.Values("X-Environment"))
+	assert.Equal(t, "billing", received.Get("X-Team"))
+	assert.Equal(t, "42", received.Get(HeaderMessageID))
+}
+
+func TestGateway_DeliverMessage_UnsignedWithoutSecret(t *testing.T) {
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(HeaderSignature)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	g, err := New(WithSecretProvider(secretsBySubscriber{}))
+	require.NoError(t, err)
+
+	require.NoError(t, g.DeliverMessage(context.Background(), server.URL, newTestMessage()))
+	assert.Empty(t, signature)
+}
+
+func TestGateway_DeliverMessage_StatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("maintenance"))
+	}))
+	defer server.Close()
+
+	g, err := New()
+	require.NoError(t, err)
+
+	err = g.DeliverMessage(context.Background(), server.URL, newTestMessage())
+
+	var webhookErr *Error
+	require.ErrorAs(t, err, &webhookErr)
+	assert.Equal(t, KindStatus, webhookErr.Kind)
+	assert.Equal(t, http.StatusServiceUnavailable, webhookErr.StatusCode)
+	assert.Equal(t, "maintenance", webhookErr.Body)
+	assert.Contains(t, err.Error(), "503")
+
+	var deliveryErr *pubsub.DeliveryError
+	require.ErrorAs(t, err, &deliveryErr)
+	assert.True(t, deliveryErr.Retryable)
+	assert.Equal(t, http.StatusServiceUnavailable, deliveryErr.StatusCode)
+	assert.Equal(t, "HTTP 503 Service Unavailable", deliveryErr.Reason)
+}
+
+func TestGateway_DeliverMessage_Classification(t *testing.T) {
+	tests := []struct {
+		status    int
+		retryable bool
+	}{
+		{http.StatusMovedPermanently, false},
+		{http.StatusFound, false},
+		{http.StatusTemporaryRedirect, false},
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusNotFound, false},
+		{http.StatusGone, false},
+		{http.StatusRequestEntityTooLarge, false},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooEarly, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			g, err := New()
+			require.NoError(t, err)
+
+			err = g.DeliverMessage(context.Background(), server.URL, newTestMessage())
+			require.Error(t, err)
+			assert.Equal(t, !tt.retryable, pubsub.IsPermanentDeliveryError(err))
+		})
+	}
+}
+
+func TestGateway_DeliverMessage_DoesNotFollowRedirects(t *testing.T) {
+	var redirected atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		redirected.Add(1)
+	}))
+	defer target.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer server.Close()
+
+	g, err := New(WithSigningSecret("secret"))
+	require.NoError(t, err)
+
+	err = g.DeliverMessage(context.Background(), server.URL, newTestMessage())
+
+	var webhookErr *Error
+	require.ErrorAs(t, err, &webhookErr)
+	assert.Equal(t, KindStatus, webhookErr.Kind)
+	assert.Equal(t, http.StatusFound, webhookErr.StatusCode)
+	assert.True(t, pubsub.IsPermanentDeliveryError(err))
+	assert.Zero(t, redirected.Load())
+}
+
+func TestGateway_DeliverMessage_RetryAfter(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		retryAfter    string
+		expectHint    bool
+		expectedDelay time.Duration
+	}{
+		{"429 with seconds", http.StatusTooManyRequests, "120", true, 2 * time.Minute},
+		{"503 with seconds", http.StatusServiceUnavailable, "30", true, 30 * time.Second},
+		{"429 without header", http.StatusTooManyRequests, "", false, 0},
+		{"429 malformed", http.StatusTooManyRequests, "soon", false, 0},
+		{"500 ignores header", http.StatusInternalServerError, "120", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			g, err := New()
+			require.NoError(t, err)
+
+			err = g.DeliverMessage(context.Background(), server.URL, newTestMessage())
+			require.Error(t, err)
+
+			delay, ok := pubsub.RetryAfterFromError(err)
+			assert.Equal(t, tt.expectHint, ok)
+			assert.Equal(t, tt.expectedDelay, delay)
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"Seconds", "90", 90 * time.Second, true},
+		{"Zero", "0", 0, true},
+		{"Padded", " 5 ", 5 * time.Second, true},
+		{"HTTP date", now.Add(3 * time.Minute).Format(http.TimeFormat), 3 * time.Minute, true},
+		{"Past HTTP date", now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"Empty", "", 0, false},
+		{"Negative", "-1", 0, false},
+		{"Beyond duration range", "9300000000", math.MaxInt64, true},
+		{"20 digits", "12345678901234567890", math.MaxInt64, true},
+		{"20 digits negative", "-12345678901234567890", 0, false},
+		{"Malformed", "tomorrow", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := ParseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, delay)
+		})
+	}
+}
+
+func TestGateway_DeliverMessage_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	g, err := New(WithTimeout(50 * time.Millisecond))
+	require.NoError(t, err)
+
+	err = g.DeliverMessage(context.Background(), server.URL, newTestMessage())
+
+	var webhookErr *Error
+	require.ErrorAs(t, err, &webhookErr)
+	assert.Equal(t, KindTimeout, webhookErr.Kind)
+	assert.True(t, webhookErr.Timeout())
+	assert.False(t, pubsub.IsPermanentDeliveryError(err))
+}
+
+func TestGateway_DeliverMessage_NetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	url := server.URL
+	server.Close() // Nothing listens anymore
+
+	g, err := New()
+	require.NoError(t, err)
+
+	err = g.DeliverMessage(context.Background(), url, newTestMessage())
+
+	var webhookErr *Error
+	require.ErrorAs(t, err, &webhookErr)
+	assert.Equal(t, KindNetwork, webhookErr.Kind)
+	assert.NotNil(t, errors.Unwrap(webhookErr))
+	assert.False(t, pubsub.IsPermanentDeliveryError(err))
+}
+
+func TestGateway_DeliverMessage_MaxBodySize(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	g, err := New(WithMaxBodySize(16))
+	require.NoError(t, err)
+
+	err = g.DeliverMessage(context.Background(), server.URL, newTestMessage())
+
+	var webhookErr *Error
+	require.ErrorAs(t, err, &webhookErr)
+	assert.Equal(t, KindRequest, webhookErr.Kind)
+	assert.Contains(t, err.Error(), "exceeds max body size")
+	assert.True(t, pubsub.IsPermanentDeliveryError(err))
+	assert.False(t, called)
+}
+
+func TestGateway_DeliverMessage_InvalidRequest(t *testing.T) {
+	g, err := New()
+	require.NoError(t, err)
+
+	err = g.DeliverMessage(context.Background(), "://invalid", newTestMessage())
+
+	var webhookErr *Error
+	require.ErrorAs(t, err, &webhookErr)
+	assert.Equal(t, KindRequest, webhookErr.Kind)
+
+	err = g.DeliverMessage(context.Background(), "http://localhost", nil)
+	require.ErrorAs(t, err, &webhookErr)
+	assert.Equal(t, KindRequest, webhookErr.Kind)
+}
@@ -0,0 +1,168 @@
+package webhook
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Option is a function that configures a Gateway.
+//
+// Example:
+//
+//	gateway, err := webhook.New(
+//	    webhook.WithTimeout(5*time.Second),
+//	    webhook.WithMaxIdleConnsPerHost(50),
+//	    webhook.WithSecretProvider(secrets),
+//	)
+type Option func(*Gateway) error
+
+// WithTimeout sets the overall timeout for a single delivery request,
+// including connection, TLS handshake, and reading the response.
+// Default: 10 seconds.
+func WithTimeout(timeout time.Duration) Option {
+	return func(g *Gateway) error {
+		if timeout <= 0 {
+			return fmt.Errorf("timeout must be > 0, got %v", timeout)
+		}
+		g.client.Timeout = timeout
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used for HTTPS webhooks,
+// e.g. to trust a private CA or present a client certificate.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(g *Gateway) error {
+		if config == nil {
+			return fmt.Errorf("TLS config cannot be nil")
+		}
+		g.transport.TLSClientConfig = config
+		return nil
+	}
+}
+
+// WithHeader adds a custom header to every delivery request.
+// Can be used multiple times. Content-Type, User-Agent and the X-PubSub-* headers
+// are always set by the gateway and cannot be overridden.
+func WithHeader(key, value string) Option {
+	return func(g *Gateway) error {
+		if key == "" {
+			return fmt.Errorf("header key cannot be empty")
+		}
+		g.headers.Add(key, value)
+		return nil
+	}
+}
+
+// WithUserAgent overrides the User-Agent header sent with every request.
+// Default: "coregx-pubsub-webhook/1.0".
+func WithUserAgent(userAgent string) Option {
+	return func(g *Gateway) error {
+		if userAgent == "" {
+			return fmt.Errorf("user agent cannot be empty")
+		}
+		g.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithMaxBodySize rejects messages whose encoded payload exceeds size bytes
+// instead of sending them. Zero means no limit (default).
+func WithMaxBodySize(size int64) Option {
+	return func(g *Gateway) error {
+		if size < 0 {
+			return fmt.Errorf("max body size must be >= 0, got %d", size)
+		}
+		g.maxBodySize = size
+		return nil
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections kept across all hosts.
+// Default: 100.
+func WithMaxIdleConns(n int) Option {
+	return func(g *Gateway) error {
+		if n < 0 {
+			return fmt.Errorf("max idle conns must be >= 0, got %d", n)
+		}
+		g.transport.MaxIdleConns = n
+		return nil
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle connections kept per webhook host.
+// Raise it when many deliveries go to the same subscriber. Default: 10.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(g *Gateway) error {
+		if n < 0 {
+			return fmt.Errorf("max idle conns per host must be >= 0, got %d", n)
+		}
+		g.transport.MaxIdleConnsPerHost = n
+		return nil
+	}
+}
+
+// WithMaxConnsPerHost limits the total number of connections per webhook host,
+// including connections in use. Zero means no limit (default).
+func WithMaxConnsPerHost(n int) Option {
+	return func(g *Gateway) error {
+		if n < 0 {
+			return fmt.Errorf("max conns per host must be >= 0, got %d", n)
+		}
+		g.transport.MaxConnsPerHost = n
+		return nil
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle pooled connection is kept open.
+// Default: 90 seconds.
+func WithIdleConnTimeout(timeout time.Duration) Option {
+	return func(g *Gateway) error {
+		if timeout < 0 {
+			return fmt.Errorf("idle conn timeout must be >= 0, got %v", timeout)
+		}
+		g.transport.IdleConnTimeout = timeout
+		return nil
+	}
+}
+
+// WithHTTPClient replaces the HTTP client used for deliveries.
+//
+// The client is used as-is: WithTLSConfig and the connection pool options only
+// affect the gateway's own transport and have no effect on a custom client.
+// Set CheckRedirect to return http.ErrUseLastResponse to keep redirects from being followed.
+func WithHTTPClient(client *http.Client) Option {
+	return func(g *Gateway) error {
+		if client == nil {
+			return fmt.Errorf("HTTP client cannot be nil")
+		}
+		g.client = client
+		return nil
+	}
+}
+
+// WithSecretProvider sets the provider of per-subscriber signing secrets.
+// Requests are signed only when the provider returns a non-empty secret.
+func WithSecretProvider(provider SecretProvider) Option {
+	return func(g *Gateway) error {
+		if provider == nil {
+			return fmt.Errorf("secret provider cannot be nil")
+		}
+		g.secrets = provider
+		return nil
+	}
+}
+
+// WithSigningSecret signs every request with the same secret, regardless of subscriber.
+// Shorthand for WithSecretProvider(StaticSecret(secret)).
+func WithSigningSecret(secret string) Option {
+	return func(g *Gateway) error {
+		if secret == "" {
+			return fmt.Errorf("signing secret cannot be empty")
+		}
+		g.secrets = StaticSecret(secret)
+		return nil
+	}
+}
@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on every delivery request.
+const (
+	// HeaderSignature carries the HMAC-SHA256 signature: "sha256=<hex>".
+	HeaderSignature = "X-PubSub-Signature"
+
+	// HeaderTimestamp carries the Unix time (seconds) the request was signed at.
+	HeaderTimestamp = "X-PubSub-Timestamp"
+
+	// HeaderMessageID carries the ID of the delivered message.
+	HeaderMessageID = "X-PubSub-Message-ID"
+
+	// HeaderAttempt carries the 1-based delivery attempt number.
+	HeaderAttempt = "X-PubSub-Attempt"
+)
+
+// signaturePrefix identifies the signing algorithm in HeaderSignature.
+const signaturePrefix = "sha256="
+
+// DefaultTolerance is the recommended maximum age of a signed request.
+const DefaultTolerance = 5 * time.Minute
+
+// Signature verification errors.
+var (
+	// ErrMissingSignature indicates the signature or timestamp header is absent.
+	ErrMissingSignature = errors.New("webhook: missing signature or timestamp header")
+
+	// ErrInvalidSignature indicates the signature does not match the body and timestamp.
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+
+	// ErrTimestampOutOfRange indicates the request is older (or newer) than the tolerance,
+	// which usually means it is being replayed.
+	ErrTimestampOutOfRange = errors.New("webhook: timestamp outside tolerance")
+)
+
+// SecretProvider resolves the HMAC signing secret for a subscriber.
+//
+// Implementations typically read the secret from subscriber configuration
+// or a secrets manager.
+type SecretProvider interface {
+	// GetSigningSecret returns the signing secret for a subscriber.
+	// An empty secret disables signing for that subscriber.
+	GetSigningSecret(ctx context.Context, subscriberID int64) (string, error)
+}
+
+// StaticSecret is a SecretProvider that returns the same secret for every subscriber.
+type StaticSecret string
+
+// GetSigningSecret returns the static secret.
+func (s StaticSecret) GetSigningSecret(_ context.Context, _ int64) (string, error) {
+	return string(s), nil
+}
+
+// Sign computes the signature header value for a request body.
+// The signed content is "<unix timestamp>.<body>", so a captured request
+// cannot be replayed with a fresh timestamp.
+//
+// Returns "sha256=" followed by the hex-encoded HMAC-SHA256.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	return signaturePrefix + hex.EncodeToString(computeMAC(secret, strconv.FormatInt(timestamp.Unix(), 10), body))
+}
+
+// Verify checks a signature produced by Sign.
+//
+// Parameters:
+//   - secret: The subscriber's signing secret
+//   - timestamp: Value of the X-PubSub-Timestamp header
+//   - signature: Value of the X-PubSub-Signature header
+//   - body: Raw request body
+//   - tolerance: Maximum allowed clock difference (0 disables the check)
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration) error {
+	if timestamp == "" || signature == "" {
+		return ErrMissingSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		age := time.Since(time.Unix(unix, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrTimestampOutOfRange
+		}
+	}
+
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil || !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal(expected, computeMAC(secret, timestamp, body)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// VerifyRequest reads the request body and verifies its signature headers.
+// The body is restored on the request so handlers can read it again.
+//
+// Intended for webhook receivers:
+//
+//	body, err := webhook.VerifyRequest(r, secret, webhook.DefaultTolerance)
+//	if err != nil {
+//	    http.Error(w, "invalid signature", http.StatusUnauthorized)
+//	    return
+//	}
+func VerifyRequest(r *http.Request, secret string, tolerance time.Duration) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := Verify(secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, tolerance); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// computeMAC returns HMAC-SHA256(secret, timestamp + "." + body).
+func computeMAC(secret, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign_Deterministic(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	body := []byte(`{"messageID":"1"}`)
+
+	first := Sign("secret", ts, body)
+	second := Sign("secret", ts, body)
+
+	assert.Equal(t, first, second)
+	assert.True(t, strings.HasPrefix(first, "sha256="))
+	assert.NotEqual(t, first, Sign("other-secret", ts, body))
+	assert.NotEqual(t, first, Sign("secret", ts.Add(time.Second), body))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"messageID":"1"}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign("secret", now, body)
+
+	oldTime := now.Add(-10 * time.Minute)
+	oldTimestamp := strconv.FormatInt(oldTime.Unix(), 10)
+	oldSignature := Sign("secret", oldTime, body)
+
+	tests := []struct {
+		name        string
+		secret      string
+		timestamp   string
+		signature   string
+		body        []byte
+		tolerance   time.Duration
+		expectedErr error
+	}{
+		{"Valid", "secret", timestamp, signature, body, DefaultTolerance, nil},
+		{"Wrong secret", "wrong", timestamp, signature, body, DefaultTolerance, ErrInvalidSignature},
+		{"Tampered body", "secret", timestamp, signature, []byte(`{}`), DefaultTolerance, ErrInvalidSignature},
+		{"Missing signature", "secret", timestamp, "", body, DefaultTolerance, ErrMissingSignature},
+		{"Missing timestamp", "secret", "", signature, body, DefaultTolerance, ErrMissingSignature},
+		{"Malformed timestamp", "secret", "yesterday", signature, body, DefaultTolerance, ErrInvalidSignature},
+		{"Missing prefix", "secret", timestamp, strings.TrimPrefix(signature, "sha256="), body, DefaultTolerance, ErrInvalidSignature},
+		{"Replayed request", "secret", oldTimestamp, oldSignature, body, DefaultTolerance, ErrTimestampOutOfRange},
+		{"Replay check disabled", "secret", oldTimestamp, oldSignature, body, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, tt.signature, tt.body, tt.tolerance)
+			if tt.expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"messageID":"1"}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign("secret", now, body))
+
+	verified, err := VerifyRequest(req, "secret", DefaultTolerance)
+	require.NoError(t, err)
+	assert.Equal(t, body, verified)
+
+	// Body must remain readable for the handler
+	again, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, again)
+
+	req = httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	_, err = VerifyRequest(req, "secret", DefaultTolerance)
+	assert.ErrorIs(t, err, ErrMissingSignature)
+}